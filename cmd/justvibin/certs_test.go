@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexcabrera/justvibin/internal/certs"
+	"github.com/alexcabrera/justvibin/internal/config"
+	execx "github.com/alexcabrera/justvibin/internal/exec"
+	"github.com/alexcabrera/justvibin/internal/logging"
+	"github.com/alexcabrera/justvibin/internal/proxy"
+)
+
+// fakeStore trusts roots by fingerprint.
+type fakeStore struct {
+	name    string
+	trusted map[string]bool
+	err     error
+}
+
+func (s *fakeStore) Name() string { return s.name }
+
+func (s *fakeStore) Trusted(_ context.Context, root certs.Root) bool {
+	return s.trusted[root.Fingerprint()]
+}
+
+func (s *fakeStore) Trust(_ context.Context, root certs.Root) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.trusted[root.Fingerprint()] = true
+	return nil
+}
+
+func (s *fakeStore) Untrust(_ context.Context, root certs.Root) error {
+	delete(s.trusted, root.Fingerprint())
+	return nil
+}
+
+func newCertsTestCommand(t *testing.T, stores ...*fakeStore) (certsCommand, string) {
+	t.Helper()
+	caDir := filepath.Join(t.TempDir(), "ca")
+	cmd := defaultCertsCommand()
+	cmd.runner = stubRunner{}
+	cmd.loadSettings = func() (config.Settings, error) {
+		return config.Settings{Proxy: config.ProxySettings{Backend: config.ProxyBackendBuiltin}}, nil
+	}
+	cmd.caDir = func() (string, error) { return caDir, nil }
+	cmd.stores = func(execx.Runner) ([]certs.Store, error) {
+		list := make([]certs.Store, len(stores))
+		for i, store := range stores {
+			list[i] = store
+		}
+		return list, nil
+	}
+	cmd.isRunning = func(context.Context, execx.Runner) bool { return false }
+	return cmd, caDir
+}
+
+func TestCertsStatusShowsFingerprintAndTrust(t *testing.T) {
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	logger := logging.New(stdout, stderr, false)
+	system := &fakeStore{name: "System", trusted: map[string]bool{}}
+	cmd, caDir := newCertsTestCommand(t, system, &fakeStore{name: "Chrome", trusted: map[string]bool{}})
+
+	if code := cmd.run(context.Background(), "trust", nil, logger); code != 0 {
+		t.Fatalf("trust: expected exit 0, got %d: %s", code, stderr.String())
+	}
+	system.trusted = map[string]bool{}
+	stdout.Reset()
+	if code := cmd.run(context.Background(), "status", nil, logger); code != 0 {
+		t.Fatalf("status: expected exit 0, got %d: %s", code, stderr.String())
+	}
+	root, err := certs.ReadRoot(filepath.Join(caDir, "root.crt"))
+	if err != nil {
+		t.Fatalf("expected CA to be created: %v", err)
+	}
+	output := stdout.String()
+	for _, want := range []string{"SHA-256: " + root.Fingerprint(), "Expires: ", "System: not trusted", "Chrome: trusted"} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in %q", want, output)
+		}
+	}
+
+	cmd.now = func() time.Time { return root.Cert.NotAfter.Add(-24 * time.Hour) }
+	stdout.Reset()
+	_ = cmd.run(context.Background(), "status", nil, logger)
+	if !strings.Contains(stdout.String(), "certs rotate") {
+		t.Fatalf("expected expiry warning, got %q", stdout.String())
+	}
+}
+
+func TestCertsExport(t *testing.T) {
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	logger := logging.New(stdout, stderr, false)
+	cmd, caDir := newCertsTestCommand(t)
+	pemOut := &strings.Builder{}
+	cmd.stdout = pemOut
+
+	if code := cmd.run(context.Background(), "export", nil, logger); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	data, _ := os.ReadFile(filepath.Join(caDir, "root.crt"))
+	if pemOut.String() != string(data) || !strings.HasPrefix(pemOut.String(), "-----BEGIN CERTIFICATE-----") {
+		t.Fatalf("expected PEM on stdout, got %q", pemOut.String())
+	}
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if code := cmd.run(context.Background(), "export", []string{path}, logger); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	if exported, _ := os.ReadFile(path); string(exported) != string(data) {
+		t.Fatalf("expected exported PEM, got %q", exported)
+	}
+	if !strings.Contains(stdout.String(), "NODE_EXTRA_CA_CERTS="+path) {
+		t.Fatalf("expected Node hint, got %q", stdout.String())
+	}
+}
+
+func TestCertsRotateMovesTrust(t *testing.T) {
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	logger := logging.New(stdout, stderr, false)
+	system := &fakeStore{name: "System", trusted: map[string]bool{}}
+	firefox := &fakeStore{name: "Firefox", trusted: map[string]bool{}}
+	cmd, caDir := newCertsTestCommand(t, system, firefox)
+	restarted := false
+	cmd.isRunning = func(context.Context, execx.Runner) bool { return true }
+	cmd.servicePath = func() (string, error) { return "/tmp/proxy.service", nil }
+	cmd.restartProxy = func(context.Context, execx.Runner, string) error {
+		restarted = true
+		return nil
+	}
+
+	old, ok := cmd.loadRoot(config.Settings{Proxy: config.ProxySettings{Backend: config.ProxyBackendBuiltin}}, logger)
+	if !ok {
+		t.Fatalf("load: %s", stderr.String())
+	}
+	system.trusted[old.Fingerprint()] = true
+
+	if code := cmd.run(context.Background(), "rotate", nil, logger); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	root, err := certs.ReadRoot(filepath.Join(caDir, "root.crt"))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if root.Fingerprint() == old.Fingerprint() {
+		t.Fatalf("expected a new CA")
+	}
+	if !restarted {
+		t.Fatalf("expected proxy restart")
+	}
+	if system.trusted[old.Fingerprint()] || !system.trusted[root.Fingerprint()] {
+		t.Fatalf("expected system trust to move to the new root")
+	}
+	if len(firefox.trusted) != 0 {
+		t.Fatalf("expected untouched store to stay untrusted")
+	}
+}
+
+func TestCertsRotateClearsCaddySiteCertificates(t *testing.T) {
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	logger := logging.New(stdout, stderr, false)
+	cmd, _ := newCertsTestCommand(t)
+	cmd.loadSettings = func() (config.Settings, error) {
+		return config.Settings{Proxy: config.ProxySettings{Backend: config.ProxyBackendCaddy}}, nil
+	}
+	data := filepath.Join(t.TempDir(), "caddy")
+	authority := filepath.Join(data, "pki", "authorities", "local")
+	cmd.caddyRoot = func() (string, error) { return filepath.Join(authority, proxy.CACertName), nil }
+	if _, err := proxy.LoadOrCreateCA(authority); err != nil {
+		t.Fatalf("create CA: %v", err)
+	}
+	site := filepath.Join(data, "certificates", "local", "myapp.localhost")
+	if err := os.MkdirAll(site, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(site, "myapp.localhost.crt"), []byte("old"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	if code := cmd.run(context.Background(), "rotate", nil, logger); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	for _, dir := range []string{authority, filepath.Join(data, "certificates", "local")} {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Fatalf("expected %s removed, got %v", dir, err)
+		}
+	}
+	if !strings.Contains(stdout.String(), "Removed the old CA") {
+		t.Fatalf("expected removal message, got %q", stdout.String())
+	}
+}
+
+func TestSetupTrustIgnoresBrowserFailures(t *testing.T) {
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	logger := logging.New(stdout, stderr, false)
+	system := &fakeStore{name: "System", trusted: map[string]bool{}}
+	firefox := &fakeStore{name: "Firefox (default)", trusted: map[string]bool{}, err: errors.New("certutil is not installed")}
+	cmd, _ := newCertsTestCommand(t, system, firefox)
+
+	if err := cmd.trustForSetup(context.Background(), logger); err != nil {
+		t.Fatalf("expected browser failure not to fail setup, got %v", err)
+	}
+	if len(system.trusted) != 1 {
+		t.Fatalf("expected the system store to trust the CA")
+	}
+	if !strings.Contains(stdout.String(), "Browsers not updated: Firefox (default)") {
+		t.Fatalf("expected a warning naming the browser, got %q", stdout.String())
+	}
+	if code := cmd.run(context.Background(), "trust", nil, logger); code != 1 {
+		t.Fatalf("expected certs trust to stay strict, got %d", code)
+	}
+
+	system.trusted = map[string]bool{}
+	system.err = errors.New("update-ca-certificates failed")
+	if err := cmd.trustForSetup(context.Background(), logger); err == nil {
+		t.Fatalf("expected a system store failure to fail setup")
+	}
+}
@@ -0,0 +1,432 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/alexcabrera/justvibin/internal/certs"
+	"github.com/alexcabrera/justvibin/internal/config"
+	execx "github.com/alexcabrera/justvibin/internal/exec"
+	"github.com/alexcabrera/justvibin/internal/logging"
+	"github.com/alexcabrera/justvibin/internal/proxy"
+	"github.com/spf13/cobra"
+)
+
+var certsCmd = &cobra.Command{
+	Use:   "certs",
+	Short: "Manage the local HTTPS certificate authority",
+	Long:  "Inspect, trust, export and rotate the local certificate authority that signs the proxy's *.localhost certificates. The CA is Caddy's, or justvibin's own with the builtin proxy backend. Trusting installs the root into the system store and into the NSS databases used by Firefox and Chrome.",
+	Example: `justvibin certs status
+justvibin certs trust
+justvibin certs export ./justvibin-ca.crt
+justvibin certs rotate`,
+}
+
+var certsStatusCmd = &cobra.Command{
+	Use:     "status",
+	Short:   "Show the CA fingerprint, expiry and where it is trusted",
+	Args:    cobra.NoArgs,
+	RunE:    runCertsAction("status"),
+	Example: "justvibin certs status",
+}
+
+var certsTrustCmd = &cobra.Command{
+	Use:     "trust",
+	Short:   "Trust the CA in the system store and browsers (requires sudo)",
+	Args:    cobra.NoArgs,
+	RunE:    runCertsAction("trust"),
+	Example: "justvibin certs trust",
+}
+
+var certsUntrustCmd = &cobra.Command{
+	Use:     "untrust",
+	Short:   "Remove the CA from the system store and browsers (requires sudo)",
+	Args:    cobra.NoArgs,
+	RunE:    runCertsAction("untrust"),
+	Example: "justvibin certs untrust",
+}
+
+var certsExportCmd = &cobra.Command{
+	Use:   "export [path]",
+	Short: "Write the CA certificate as PEM",
+	Long:  "Write the CA root certificate as PEM to path, or to stdout without one, so containers and tools such as Node (NODE_EXTRA_CA_CERTS) can trust it.",
+	Example: `justvibin certs export ./justvibin-ca.crt
+justvibin certs export > ca.pem`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runCertsAction("export"),
+}
+
+var certsRotateCmd = &cobra.Command{
+	Use:     "rotate",
+	Short:   "Replace the CA with a new one",
+	Long:    "Create a new certificate authority, restart the proxy so it issues certificates from it, and move trust from the old root to the new one wherever the old root was trusted.",
+	Args:    cobra.NoArgs,
+	RunE:    runCertsAction("rotate"),
+	Example: "justvibin certs rotate",
+}
+
+func init() {
+	rootCmd.AddCommand(certsCmd)
+	certsCmd.AddCommand(certsStatusCmd)
+	certsCmd.AddCommand(certsTrustCmd)
+	certsCmd.AddCommand(certsUntrustCmd)
+	certsCmd.AddCommand(certsExportCmd)
+	certsCmd.AddCommand(certsRotateCmd)
+}
+
+// caExpiryWarning is how close to expiry status starts warning.
+const caExpiryWarning = 30 * 24 * time.Hour
+
+type certsCommand struct {
+	runner       execx.Runner
+	loadSettings func() (config.Settings, error)
+	caDir        func() (string, error)
+	caddyRoot    func() (string, error)
+	stores       func(execx.Runner) ([]certs.Store, error)
+	isRunning    func(context.Context, execx.Runner) bool
+	restartProxy func(context.Context, execx.Runner, string) error
+	servicePath  func() (string, error)
+	now          func() time.Time
+	caddyWait    time.Duration
+	stdout       io.Writer
+}
+
+var certsCommandFactory = defaultCertsCommand
+
+func defaultCertsCommand() certsCommand {
+	return certsCommand{
+		runner:       execx.NewSystemRunner(),
+		loadSettings: config.LoadSettings,
+		caDir:        config.CADir,
+		caddyRoot:    certs.CaddyRootPath,
+		stores:       certs.Stores,
+		isRunning:    proxy.IsProxyRunning,
+		restartProxy: proxy.RestartProxyService,
+		servicePath:  config.ProxyServicePath,
+		now:          time.Now,
+		caddyWait:    10 * time.Second,
+		stdout:       os.Stdout,
+	}
+}
+
+func runCertsAction(action string) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		output := getOutputSettings(cmd)
+		logger := logging.New(cmd.OutOrStdout(), cmd.ErrOrStderr(), output.Styled)
+		logger.SetSilent(output.Quiet)
+		logger.SetVerbose(output.Verbose)
+
+		impl := certsCommandFactory()
+		impl.stdout = cmd.OutOrStdout()
+		if code := impl.run(cmd.Context(), action, args, logger); code != 0 {
+			return fmt.Errorf("certs %s failed", action)
+		}
+		return nil
+	}
+}
+
+func (c certsCommand) run(ctx context.Context, action string, args []string, logger *logging.Logger) int {
+	settings, err := c.loadSettings()
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to read config: %v", err))
+		return 1
+	}
+	switch action {
+	case "status":
+		return c.status(ctx, settings, logger)
+	case "trust":
+		return c.trust(ctx, settings, logger)
+	case "untrust":
+		return c.untrust(ctx, settings, logger)
+	case "export":
+		return c.export(settings, args, logger)
+	case "rotate":
+		return c.rotate(ctx, settings, logger)
+	default:
+		logger.Error(fmt.Sprintf("Unknown action: %s", action))
+		return 1
+	}
+}
+
+func (c certsCommand) rootPath(settings config.Settings) (string, error) {
+	if settings.BuiltinProxy() {
+		dir, err := c.caDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, proxy.CACertName), nil
+	}
+	return c.caddyRoot()
+}
+
+// loadRoot reads the current root. The builtin CA is created on demand;
+// Caddy creates its own the first time the proxy starts.
+func (c certsCommand) loadRoot(settings config.Settings, logger *logging.Logger) (certs.Root, bool) {
+	path, err := c.rootPath(settings)
+	if err != nil {
+		logger.Error("Failed to resolve CA path")
+		return certs.Root{}, false
+	}
+	if settings.BuiltinProxy() {
+		if _, err := proxy.LoadOrCreateCA(filepath.Dir(path)); err != nil {
+			logger.Error(fmt.Sprintf("Failed to load CA: %v", err))
+			return certs.Root{}, false
+		}
+	}
+	root, err := certs.ReadRoot(path)
+	if errors.Is(err, certs.ErrNoCA) {
+		logger.Error(fmt.Sprintf("No CA at %s yet", path))
+		logger.Info("Start the proxy once so Caddy creates it: justvibin proxy start")
+		return certs.Root{}, false
+	}
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to read CA: %v", err))
+		return certs.Root{}, false
+	}
+	return root, true
+}
+
+func (c certsCommand) status(ctx context.Context, settings config.Settings, logger *logging.Logger) int {
+	root, ok := c.loadRoot(settings, logger)
+	if !ok {
+		return 1
+	}
+	logger.Info(fmt.Sprintf("Backend: %s", settings.Proxy.Backend))
+	logger.Info(fmt.Sprintf("CA: %s", root.Name()))
+	logger.Info(fmt.Sprintf("Path: %s", root.Path))
+	logger.Info(fmt.Sprintf("SHA-256: %s", root.Fingerprint()))
+	expires := fmt.Sprintf("Expires: %s", root.Cert.NotAfter.Format("2006-01-02"))
+	switch left := root.ExpiresIn(c.now()); {
+	case left <= 0:
+		logger.Error(expires + " (expired; run justvibin certs rotate)")
+	case left < caExpiryWarning:
+		logger.Warn(fmt.Sprintf("%s (in %d days; run justvibin certs rotate)", expires, int(left.Hours()/24)))
+	default:
+		logger.Info(fmt.Sprintf("%s (in %d days)", expires, int(left.Hours()/24)))
+	}
+
+	stores, err := c.stores(c.runner)
+	if err != nil {
+		logger.Error("Failed to find trust stores")
+		return 1
+	}
+	for _, store := range stores {
+		if store.Trusted(ctx, root) {
+			logger.Success(fmt.Sprintf("%s: trusted", store.Name()))
+		} else {
+			logger.Warn(fmt.Sprintf("%s: not trusted", store.Name()))
+		}
+	}
+	return 0
+}
+
+func (c certsCommand) trust(ctx context.Context, settings config.Settings, logger *logging.Logger) int {
+	root, ok := c.loadRoot(settings, logger)
+	if !ok {
+		return 1
+	}
+	if err := c.trustRoot(ctx, root, nil, logger); err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+	return 0
+}
+
+func (c certsCommand) untrust(ctx context.Context, settings config.Settings, logger *logging.Logger) int {
+	root, ok := c.loadRoot(settings, logger)
+	if !ok {
+		return 1
+	}
+	if _, err := c.untrustRoot(ctx, root, logger); err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+	return 0
+}
+
+// trustRoot installs root into every trust store, or only into the stores
+// named in only when it is not nil, and fails if any could not be updated.
+func (c certsCommand) trustRoot(ctx context.Context, root certs.Root, only map[string]bool, logger *logging.Logger) error {
+	failed, _, err := c.trustStores(ctx, root, only, logger)
+	if err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("could not trust the CA in %d store(s)", len(failed))
+	}
+	return nil
+}
+
+// trustStores is trustRoot without the verdict: it returns the names of the
+// stores that could not be updated and whether the system store, which
+// certs.Stores lists first, is among them.
+func (c certsCommand) trustStores(ctx context.Context, root certs.Root, only map[string]bool, logger *logging.Logger) ([]string, bool, error) {
+	stores, err := c.stores(c.runner)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to find trust stores: %w", err)
+	}
+	var failed []string
+	systemFailed := false
+	for i, store := range stores {
+		if only != nil && !only[store.Name()] {
+			continue
+		}
+		if store.Trusted(ctx, root) {
+			logger.Info(fmt.Sprintf("%s: already trusted", store.Name()))
+			continue
+		}
+		if err := store.Trust(ctx, root); err != nil {
+			logger.Warn(fmt.Sprintf("%s: %v", store.Name(), err))
+			failed = append(failed, store.Name())
+			systemFailed = systemFailed || i == 0
+			continue
+		}
+		logger.Success(fmt.Sprintf("%s: trusted", store.Name()))
+	}
+	return failed, systemFailed, nil
+}
+
+// untrustRoot removes root from every store that trusts it and returns the
+// names of those stores.
+func (c certsCommand) untrustRoot(ctx context.Context, root certs.Root, logger *logging.Logger) (map[string]bool, error) {
+	stores, err := c.stores(c.runner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find trust stores: %w", err)
+	}
+	removed := map[string]bool{}
+	failed := 0
+	for _, store := range stores {
+		if !store.Trusted(ctx, root) {
+			continue
+		}
+		if err := store.Untrust(ctx, root); err != nil {
+			logger.Warn(fmt.Sprintf("%s: %v", store.Name(), err))
+			failed++
+			continue
+		}
+		removed[store.Name()] = true
+		logger.Success(fmt.Sprintf("%s: no longer trusted", store.Name()))
+	}
+	if len(removed) == 0 && failed == 0 {
+		logger.Info("The CA was not trusted anywhere")
+	}
+	if failed > 0 {
+		return removed, fmt.Errorf("could not untrust the CA in %d store(s)", failed)
+	}
+	return removed, nil
+}
+
+func (c certsCommand) export(settings config.Settings, args []string, logger *logging.Logger) int {
+	root, ok := c.loadRoot(settings, logger)
+	if !ok {
+		return 1
+	}
+	if len(args) == 0 {
+		if _, err := c.stdout.Write(root.PEM); err != nil {
+			logger.Error("Failed to write CA")
+			return 1
+		}
+		return 0
+	}
+	path := args[0]
+	if err := os.WriteFile(path, root.PEM, 0644); err != nil {
+		logger.Error(fmt.Sprintf("Failed to export CA: %v", err))
+		return 1
+	}
+	logger.Success(fmt.Sprintf("Exported %s to %s", root.Name(), path))
+	logger.Info(fmt.Sprintf("Node:   NODE_EXTRA_CA_CERTS=%s", path))
+	logger.Info(fmt.Sprintf("Docker: COPY %s /usr/local/share/ca-certificates/justvibin.crt, then RUN update-ca-certificates", filepath.Base(path)))
+	return 0
+}
+
+func (c certsCommand) rotate(ctx context.Context, settings config.Settings, logger *logging.Logger) int {
+	path, err := c.rootPath(settings)
+	if err != nil {
+		logger.Error("Failed to resolve CA path")
+		return 1
+	}
+	var trustedIn map[string]bool
+	if old, err := certs.ReadRoot(path); err == nil {
+		trustedIn, err = c.untrustRoot(ctx, old, logger)
+		if err != nil {
+			logger.Error(err.Error())
+			logger.Info("Nothing was rotated; fix the error above or run justvibin certs untrust first")
+			return 1
+		}
+	} else if !errors.Is(err, certs.ErrNoCA) {
+		logger.Error(fmt.Sprintf("Failed to read CA: %v", err))
+		return 1
+	}
+
+	dir := filepath.Dir(path)
+	if settings.BuiltinProxy() {
+		for _, name := range []string{proxy.CACertName, proxy.CAKeyName} {
+			if err := os.Remove(filepath.Join(dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				logger.Error(fmt.Sprintf("Failed to remove old CA: %v", err))
+				return 1
+			}
+		}
+		if _, err := proxy.LoadOrCreateCA(dir); err != nil {
+			logger.Error(fmt.Sprintf("Failed to create CA: %v", err))
+			return 1
+		}
+	} else {
+		// Caddy creates a new local authority when it next starts. Its site
+		// certificates were signed by the old root, so they go too and are
+		// reissued instead of served until they expire.
+		for _, old := range []string{dir, certs.CaddySiteCertsDir(path)} {
+			if err := os.RemoveAll(old); err != nil {
+				logger.Error(fmt.Sprintf("Failed to remove old CA: %v", err))
+				return 1
+			}
+		}
+	}
+
+	running := c.isRunning(ctx, c.runner)
+	if running {
+		servicePath, err := c.servicePath()
+		if err != nil {
+			logger.Error("Failed to resolve proxy service path")
+			return 1
+		}
+		if err := c.restartProxy(ctx, c.runner, servicePath); err != nil {
+			logger.Error(fmt.Sprintf("Failed to restart proxy: %v", err))
+			return 1
+		}
+	}
+	root, err := certs.ReadRoot(path)
+	if running && !settings.BuiltinProxy() {
+		root, err = c.waitForRoot(path)
+	}
+	if err != nil {
+		logger.Success("Removed the old CA")
+		logger.Info("Caddy creates the new one when the proxy starts; then run justvibin certs trust")
+		return 0
+	}
+	logger.Success(fmt.Sprintf("Rotated CA: %s", root.Name()))
+	logger.Info(fmt.Sprintf("SHA-256: %s", root.Fingerprint()))
+	if len(trustedIn) > 0 {
+		if err := c.trustRoot(ctx, root, trustedIn, logger); err != nil {
+			logger.Error(err.Error())
+			return 1
+		}
+	}
+	return 0
+}
+
+// waitForRoot gives a restarted Caddy time to write its new root.
+func (c certsCommand) waitForRoot(path string) (certs.Root, error) {
+	deadline := time.Now().Add(c.caddyWait)
+	for {
+		root, err := certs.ReadRoot(path)
+		if err == nil || !errors.Is(err, certs.ErrNoCA) || time.Now().After(deadline) {
+			return root, err
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
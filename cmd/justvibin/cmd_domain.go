@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alexcabrera/justvibin/internal/config"
+	"github.com/alexcabrera/justvibin/internal/logging"
+	"github.com/alexcabrera/justvibin/internal/proxy"
+	"github.com/alexcabrera/justvibin/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+var domainCmd = &cobra.Command{
+	Use:   "domain",
+	Short: "Manage extra domains for the current project",
+	Long:  "Serve the current project on domains besides <name>.localhost, such as myapp.test or a wildcard like *.myapp.localhost for multi-tenant apps. Domains are stored in the registry and the .justvibin marker, and the proxy is updated automatically.",
+	Example: `justvibin domain add '*.myapp.localhost'
+justvibin domain add myapp.test
+justvibin domain remove myapp.test
+justvibin domain list`,
+}
+
+var domainAddCmd = &cobra.Command{
+	Use:     "add <domain>",
+	Short:   "Serve the project on another domain",
+	Args:    cobra.ExactArgs(1),
+	RunE:    runDomainAction("add"),
+	Example: "justvibin domain add '*.myapp.localhost'",
+}
+
+var domainRemoveCmd = &cobra.Command{
+	Use:     "remove <domain>",
+	Aliases: []string{"rm"},
+	Short:   "Stop serving the project on a domain",
+	Args:    cobra.ExactArgs(1),
+	RunE:    runDomainAction("remove"),
+	Example: "justvibin domain remove myapp.test",
+}
+
+var domainListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "List every URL the project is served on",
+	Args:    cobra.NoArgs,
+	RunE:    runDomainAction("list"),
+	Example: "justvibin domain list",
+}
+
+func init() {
+	rootCmd.AddCommand(domainCmd)
+	domainCmd.AddCommand(domainAddCmd)
+	domainCmd.AddCommand(domainRemoveCmd)
+	domainCmd.AddCommand(domainListCmd)
+}
+
+type domainCommand struct {
+	projectsFile        func() (string, error)
+	caddyfilePath       func() (string, error)
+	readMarker          func(string) (registry.Marker, error)
+	updateMarkerAliases func(string, []string) (registry.Marker, error)
+	addAlias            func(path, name, host string) (registry.Project, error)
+	removeAlias         func(path, name, host string) (registry.Project, bool, error)
+	generateCaddy       func(ctx context.Context, projectsPath, caddyfilePath string) (bool, error)
+	reloadProxy         func(ctx context.Context, caddyfilePath string) error
+}
+
+var domainCommandFactory = defaultDomainCommand
+
+func defaultDomainCommand() domainCommand {
+	return domainCommand{
+		projectsFile:        config.ProjectsFile,
+		caddyfilePath:       config.CaddyfilePath,
+		readMarker:          registry.ReadMarker,
+		updateMarkerAliases: registry.UpdateMarkerAliases,
+		addAlias:            registry.AddAlias,
+		removeAlias:         registry.RemoveAlias,
+		generateCaddy: func(ctx context.Context, projectsPath, caddyfilePath string) (bool, error) {
+			return proxy.GenerateCaddyfile(ctx, nil, projectsPath, caddyfilePath)
+		},
+		reloadProxy: func(ctx context.Context, caddyfilePath string) error {
+			return proxy.ReloadProxy(ctx, nil, caddyfilePath)
+		},
+	}
+}
+
+func runDomainAction(action string) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		output := getOutputSettings(cmd)
+		logger := logging.New(cmd.OutOrStdout(), cmd.ErrOrStderr(), output.Styled)
+		logger.SetSilent(output.Quiet)
+		logger.SetVerbose(output.Verbose)
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			logger.Error("Failed to get current directory")
+			return errors.New("domain command failed")
+		}
+		impl := domainCommandFactory()
+		if code := impl.run(context.Background(), cwd, action, args, logger); code != 0 {
+			return errors.New("domain command failed")
+		}
+		return nil
+	}
+}
+
+func (c domainCommand) run(ctx context.Context, projectDir, action string, args []string, logger *logging.Logger) int {
+	if !registry.MarkerExists(projectDir) {
+		logger.Error("Not a justvibin project directory")
+		logger.Info("Run 'justvibin new' or 'justvibin register' first")
+		return 1
+	}
+	marker, err := c.readMarker(projectDir)
+	if err != nil {
+		logger.Error("Failed to read project marker")
+		return 1
+	}
+	if action == "list" {
+		for _, url := range registry.URLs(marker.Name, marker.Aliases) {
+			logger.Info(url)
+		}
+		return 0
+	}
+
+	projectsPath, err := c.projectsFile()
+	if err != nil {
+		logger.Error("Failed to resolve projects file")
+		return 1
+	}
+	var project registry.Project
+	switch action {
+	case "add":
+		project, err = c.addAlias(projectsPath, marker.Name, args[0])
+		if err != nil {
+			logRegistryError(logger, err, fmt.Sprintf("Failed to add domain: %v", err))
+			return 1
+		}
+	case "remove":
+		var removed bool
+		project, removed, err = c.removeAlias(projectsPath, marker.Name, args[0])
+		if err != nil {
+			logRegistryError(logger, err, fmt.Sprintf("Failed to remove domain: %v", err))
+			return 1
+		}
+		if !removed {
+			logger.Error(fmt.Sprintf("%s is not a domain of %s", args[0], marker.Name))
+			return 1
+		}
+	default:
+		logger.Error(fmt.Sprintf("Unknown action: %s", action))
+		return 1
+	}
+	if _, err := c.updateMarkerAliases(projectDir, project.Aliases); err != nil {
+		logger.Error(fmt.Sprintf("Failed to update marker: %v", err))
+		return 1
+	}
+
+	caddyfilePath, err := c.caddyfilePath()
+	if err != nil {
+		logger.Warn("Could not determine Caddyfile path")
+	} else if live, err := c.generateCaddy(ctx, projectsPath, caddyfilePath); err != nil {
+		logger.Warn(fmt.Sprintf("Failed to regenerate Caddyfile: %v", err))
+	} else if !live {
+		if err := c.reloadProxy(ctx, caddyfilePath); err != nil {
+			logger.Warn(fmt.Sprintf("Failed to reload Caddy: %v", err))
+		}
+	}
+
+	if action == "remove" {
+		logger.Success(fmt.Sprintf("Removed domain: %s", args[0]))
+		return 0
+	}
+	host, _ := registry.NormalizeHost(args[0])
+	logger.Success(fmt.Sprintf("Added: https://%s", host))
+	logDomainResolution(logger, host)
+	return 0
+}
+
+// logDomainResolution explains how to make a domain outside .localhost
+// resolve, since only .localhost names point at this machine on their own.
+func logDomainResolution(logger *logging.Logger, host string) {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return
+	}
+	if parent, ok := strings.CutPrefix(host, "*."); ok {
+		logger.Info(fmt.Sprintf("Point %s and its subdomains at 127.0.0.1 with a local resolver, e.g. dnsmasq: address=/%s/127.0.0.1", parent, parent))
+		return
+	}
+	logger.Info(fmt.Sprintf("Add this line to /etc/hosts so %s resolves: 127.0.0.1 %s", host, host))
+}
+
+// logOtherURLs prints every URL of a project after the <name>.localhost one
+// the caller has already shown.
+func logOtherURLs(logger *logging.Logger, name string, aliases []string) {
+	for _, url := range registry.URLs(name, aliases)[1:] {
+		logger.Info(fmt.Sprintf("Also: %s", url))
+	}
+}
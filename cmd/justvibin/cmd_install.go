@@ -9,10 +9,12 @@ import (
 )
 
 var installCmd = &cobra.Command{
-	Use:   "install <git-url>",
+	Use:   "install <git-url|name>[@ref]",
 	Short: "Install a template plugin from a git repository",
-	Long:  "Clone a template repository and install it as a plugin. Templates must contain a justvibin.toml manifest file with template metadata. Use --list-official to browse curated templates without installing, or --name to override the installed template name.",
+	Long:  "Clone a template repository and install it as a plugin. Templates must contain a justvibin.toml manifest file with template metadata. Pass a template name instead of a URL to look it up among the official templates and the template index (see 'justvibin search'). Append @<tag|branch|sha> to the URL or name to pin a revision; the URL, ref and resolved commit are recorded in the template's .source file. Use --list-official to browse curated templates without installing, or --name to override the installed template name.",
 	Example: `justvibin install https://github.com/acme/my-template.git
+justvibin install django-hypermedia
+justvibin install https://github.com/acme/my-template.git@v1.2.0
 justvibin install --name custom-name https://github.com/acme/my-template.git
 justvibin install --list-official
 justvibin --quiet install --list-official`,
@@ -31,12 +31,13 @@ func init() {
 }
 
 type listProject struct {
-	Name     string `json:"name"`
-	Port     int    `json:"port"`
-	Path     string `json:"path"`
-	Template string `json:"template"`
-	URL      string `json:"url"`
-	Running  bool   `json:"running"`
+	Name     string   `json:"name"`
+	Port     int      `json:"port"`
+	Path     string   `json:"path"`
+	Template string   `json:"template"`
+	URL      string   `json:"url"`
+	URLs     []string `json:"urls"`
+	Running  bool     `json:"running"`
 }
 
 func runListCmd(cmd *cobra.Command, _ []string) error {
@@ -56,7 +57,7 @@ func runListCmd(cmd *cobra.Command, _ []string) error {
 
 	entries, err := registry.List(projectsPath)
 	if err != nil {
-		logger.Error("Failed to load projects")
+		logRegistryError(logger, err, "Failed to load projects")
 		return errors.New("list command failed")
 	}
 
@@ -66,12 +67,14 @@ func runListCmd(cmd *cobra.Command, _ []string) error {
 		if runningOnly && !running {
 			continue
 		}
+		urls := registry.URLs(entry.Name, entry.Project.Aliases)
 		projects = append(projects, listProject{
 			Name:     entry.Name,
 			Port:     entry.Project.Port,
 			Path:     entry.Project.Path,
 			Template: entry.Project.Template,
-			URL:      fmt.Sprintf("https://%s.localhost", entry.Name),
+			URL:      urls[0],
+			URLs:     urls,
 			Running:  running,
 		})
 	}
@@ -141,7 +144,7 @@ func listText(projects []listProject, styled bool) string {
 			}
 			lines = append(lines,
 				fmt.Sprintf("  %s %s", runningStyle.Render(statusIcon), nameStyle.Render(p.Name)),
-				urlStyle.Render(fmt.Sprintf("    %s", p.URL)),
+				urlStyle.Render("    "+strings.Join(p.URLs, "\n    ")),
 				metaStyle.Render(fmt.Sprintf("    Template: %s | Port: %d | %s", p.Template, p.Port, statusStyled)),
 				metaStyle.Render(fmt.Sprintf("    Path: %s", p.Path)),
 				"",
@@ -161,7 +164,7 @@ func listText(projects []listProject, styled bool) string {
 		}
 		lines = append(lines,
 			fmt.Sprintf("  %s %s", statusIcon, p.Name),
-			fmt.Sprintf("    %s", p.URL),
+			"    "+strings.Join(p.URLs, "\n    "),
 			fmt.Sprintf("    Template: %s | Port: %d | %s", p.Template, p.Port, statusText),
 			fmt.Sprintf("    Path: %s", p.Path),
 			"",
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"github.com/alexcabrera/justvibin/internal/logfile"
+	"github.com/spf13/cobra"
+)
+
+// logCaptureCommand is the helper `justvibin start` pipes a server's output
+// through, so it is timestamped and rotated after start itself has exited.
+const logCaptureCommand = "__log"
+
+var logCaptureCmd = &cobra.Command{
+	Use:    logCaptureCommand + " <path>",
+	Short:  "Write stdin to a project log (internal)",
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return logfile.Capture(args[0], cmd.InOrStdin())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(logCaptureCmd)
+}
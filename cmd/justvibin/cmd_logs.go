@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/alexcabrera/justvibin/internal/config"
+	"github.com/alexcabrera/justvibin/internal/logfile"
+	"github.com/alexcabrera/justvibin/internal/logging"
+	"github.com/alexcabrera/justvibin/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs [name]",
+	Short: "Show a project's server output",
+	Long:  "Show the captured stdout and stderr of a project's server. Without arguments, shows logs for the project in the current directory. Logs live under the config directory and rotate at 10MB, keeping three old files.",
+	Example: `justvibin logs                 # Last 100 lines for current project
+justvibin logs myapp -f        # Follow new output
+justvibin logs --since 10m     # Output from the last ten minutes
+justvibin logs -n 20           # Last 20 lines`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runLogsCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(logsCmd)
+	logsCmd.Flags().BoolP("follow", "f", false, "Keep printing new output as it is written")
+	logsCmd.Flags().String("since", "", "Only show output newer than a duration (10m) or RFC3339 time")
+	logsCmd.Flags().IntP("lines", "n", 100, "Number of lines to show (0 for all)")
+}
+
+type logsOptions struct {
+	Follow bool
+	Since  string
+	Lines  int
+}
+
+type logsCommand struct {
+	projectsFile func() (string, error)
+	getwd        func() (string, error)
+	logPath      func(name string) (string, error)
+	now          func() time.Time
+	pollInterval time.Duration
+	stdout       io.Writer
+}
+
+var logsCommandFactory = defaultLogsCommand
+
+func defaultLogsCommand() logsCommand {
+	return logsCommand{
+		projectsFile: config.ProjectsFile,
+		getwd:        os.Getwd,
+		logPath:      config.ProjectLogPath,
+		now:          time.Now,
+		pollInterval: 250 * time.Millisecond,
+		stdout:       os.Stdout,
+	}
+}
+
+func runLogsCmd(cmd *cobra.Command, args []string) error {
+	output := getOutputSettings(cmd)
+	logger := logging.New(cmd.OutOrStdout(), cmd.ErrOrStderr(), output.Styled)
+	logger.SetSilent(output.Quiet)
+	logger.SetVerbose(output.Verbose)
+
+	var opts logsOptions
+	opts.Follow, _ = cmd.Flags().GetBool("follow")
+	opts.Since, _ = cmd.Flags().GetString("since")
+	opts.Lines, _ = cmd.Flags().GetInt("lines")
+
+	impl := logsCommandFactory()
+	impl.stdout = cmd.OutOrStdout()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	code := impl.run(ctx, args, logger, opts)
+	if code != 0 {
+		return errors.New("logs command failed")
+	}
+	return nil
+}
+
+func (c logsCommand) run(ctx context.Context, args []string, logger *logging.Logger, opts logsOptions) int {
+	since, err := parseSince(opts.Since, c.now())
+	if err != nil {
+		logger.Error(fmt.Sprintf("Invalid --since value: %s", opts.Since))
+		return 1
+	}
+
+	projectName, ok := c.projectName(args, logger)
+	if !ok {
+		return 1
+	}
+	logPath, err := c.logPath(projectName)
+	if err != nil {
+		logger.Error("Failed to resolve log file")
+		return 1
+	}
+
+	lines, err := logfile.Tail(logPath, since, opts.Lines)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		logger.Error(fmt.Sprintf("Failed to read logs: %v", err))
+		return 1
+	}
+	if errors.Is(err, os.ErrNotExist) && !opts.Follow {
+		logger.Info(fmt.Sprintf("No logs for '%s' yet", projectName))
+		return 0
+	}
+	for _, line := range lines {
+		fmt.Fprintln(c.stdout, line)
+	}
+
+	if !opts.Follow {
+		return 0
+	}
+	if err := logfile.Follow(ctx, logPath, c.stdout, c.pollInterval); err != nil {
+		logger.Error(fmt.Sprintf("Failed to follow logs: %v", err))
+		return 1
+	}
+	return 0
+}
+
+// projectName resolves the project from the argument or, without one, from
+// the marker in the current directory.
+func (c logsCommand) projectName(args []string, logger *logging.Logger) (string, bool) {
+	if len(args) > 0 {
+		projectsPath, err := c.projectsFile()
+		if err != nil {
+			logger.Error("Failed to resolve projects file")
+			return "", false
+		}
+		_, ok, err := registry.Get(projectsPath, args[0])
+		if err != nil {
+			logRegistryError(logger, err, "Failed to load project registry")
+			return "", false
+		}
+		if !ok {
+			logger.Error(fmt.Sprintf("Project '%s' not found", args[0]))
+			return "", false
+		}
+		return args[0], true
+	}
+
+	cwd, err := c.getwd()
+	if err != nil {
+		logger.Error("Failed to get current directory")
+		return "", false
+	}
+	if !registry.MarkerExists(cwd) {
+		logger.Error("Not a justvibin project directory")
+		return "", false
+	}
+	marker, err := registry.ReadMarker(cwd)
+	if err != nil {
+		logger.Error("Failed to read project marker")
+		return "", false
+	}
+	return marker.Name, true
+}
+
+// parseSince accepts a duration before now (10m, 2h) or an RFC3339 time.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
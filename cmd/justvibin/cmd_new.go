@@ -15,7 +15,7 @@ var newCmd = &cobra.Command{
 	Use:   "new [name]",
 	Short: "Create a new project from a template",
 	Long:  "Create a new project directory from a curated template. Templates are cloned from git repositories and initialized with a fresh git repo. If you omit the name, you can pass it via --name or be prompted when running interactively. For headless usage, provide --name or a positional name along with any flags.",
-	Example: "justvibin new myapp\njustvibin new --template hypertext myapp\njustvibin new --local ./templates/hypertext --name myapp\njustvibin --json templates",
+	Example: "justvibin new myapp\njustvibin new --template hypertext myapp\njustvibin new --local ./templates/hypertext --name myapp\njustvibin new --template django --var db_name=shop myapp\njustvibin --json templates",
 	Args:  cobra.MaximumNArgs(1),
 	RunE:  runNewCmd,
 }
@@ -26,6 +26,7 @@ func init() {
 	newCmd.Flags().StringP("template", "t", "django-hypermedia", "Specify template name. Default: django-hypermedia")
 	newCmd.Flags().String("local", "", "Use local template directory instead of cloning. Default: empty")
 	newCmd.Flags().StringP("name", "n", "", "Project name (alternative to positional arg). Default: empty")
+	newCmd.Flags().StringArray("var", nil, "Set a template variable as key=value. Repeatable. Default: prompt or template default")
 }
 
 func runNewCmd(cmd *cobra.Command, args []string) error {
@@ -54,7 +55,12 @@ func runNewCmd(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	newArgs := make([]string, 0, 6)
+	vars, err := cmd.Flags().GetStringArray("var")
+	if err != nil {
+		return err
+	}
+
+	newArgs := make([]string, 0, 6+2*len(vars))
 	if name != "" {
 		newArgs = append(newArgs, name)
 	}
@@ -64,6 +70,9 @@ func runNewCmd(cmd *cobra.Command, args []string) error {
 	if templateChanged {
 		newArgs = append(newArgs, "--template", templateName)
 	}
+	for _, v := range vars {
+		newArgs = append(newArgs, "--var", v)
+	}
 
 	cmdImpl := newCommandFactory()
 	interactive := term.IsTerminal(int(os.Stdin.Fd()))
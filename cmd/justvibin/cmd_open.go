@@ -64,9 +64,9 @@ func runOpenCmd(cmd *cobra.Command, args []string) error {
 		return errors.New("open command failed")
 	}
 
-	_, ok, err := registry.Get(projectsPath, projectName)
+	project, ok, err := registry.Get(projectsPath, projectName)
 	if err != nil {
-		logger.Error("Failed to load project registry")
+		logRegistryError(logger, err, "Failed to load project registry")
 		return errors.New("open command failed")
 	}
 	if !ok {
@@ -82,6 +82,7 @@ func runOpenCmd(cmd *cobra.Command, args []string) error {
 
 	_ = console
 	logger.Success(fmt.Sprintf("Opened: %s", url))
+	logOtherURLs(logger, projectName, project.Aliases)
 	return nil
 }
 
@@ -35,7 +35,7 @@ type portCommand struct {
 	readMarker       func(string) (registry.Marker, error)
 	updateMarkerPort func(string, int) (registry.Marker, error)
 	updatePort       func(path, name string, port int) (registry.Project, error)
-	generateCaddy    func(ctx context.Context, projectsPath, caddyfilePath string) error
+	generateCaddy    func(ctx context.Context, projectsPath, caddyfilePath string) (bool, error)
 	reloadProxy      func(ctx context.Context, caddyfilePath string) error
 }
 
@@ -48,7 +48,7 @@ func defaultPortCommand() portCommand {
 		readMarker:       registry.ReadMarker,
 		updateMarkerPort: registry.UpdateMarkerPort,
 		updatePort:       registry.UpdatePort,
-		generateCaddy: func(ctx context.Context, projectsPath, caddyfilePath string) error {
+		generateCaddy: func(ctx context.Context, projectsPath, caddyfilePath string) (bool, error) {
 			return proxy.GenerateCaddyfile(ctx, nil, projectsPath, caddyfilePath)
 		},
 		reloadProxy: func(ctx context.Context, caddyfilePath string) error {
@@ -128,9 +128,9 @@ func (c portCommand) run(ctx context.Context, console *ui.UI, logger *logging.Lo
 	if err != nil {
 		logger.Warn("Could not determine Caddyfile path")
 	} else {
-		if err := c.generateCaddy(ctx, projectsPath, caddyfilePath); err != nil {
+		if live, err := c.generateCaddy(ctx, projectsPath, caddyfilePath); err != nil {
 			logger.Warn(fmt.Sprintf("Failed to regenerate Caddyfile: %v", err))
-		} else {
+		} else if !live {
 			// Reload Caddy
 			if err := c.reloadProxy(ctx, caddyfilePath); err != nil {
 				logger.Warn(fmt.Sprintf("Failed to reload Caddy: %v", err))
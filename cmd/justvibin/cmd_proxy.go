@@ -137,6 +137,11 @@ func runProxyStatusCmd(cmd *cobra.Command, _ []string) error {
 		return errors.New("proxy status failed")
 	}
 	count, minPort, maxPort, ok := projectStats(projects)
+	settings, err := cmdImpl.settings()
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to read config: %v", err))
+		return errors.New("proxy status failed")
+	}
 	caddyfilePath, err := cmdImpl.caddyfilePath()
 	if err != nil {
 		logger.Error("Failed to resolve Caddyfile path")
@@ -145,6 +150,7 @@ func runProxyStatusCmd(cmd *cobra.Command, _ []string) error {
 
 	payload := map[string]interface{}{
 		"running":        running,
+		"backend":        settings.Proxy.Backend,
 		"projects":       count,
 		"ports":          nil,
 		"caddyfile_path": caddyfilePath,
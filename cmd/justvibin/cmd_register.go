@@ -39,10 +39,9 @@ type registerCommand struct {
 	runner        execx.Runner
 	projectsFile  func() (string, error)
 	caddyfilePath func() (string, error)
-	nextPort      func(string) (int, error)
-	register      func(path, name string, port int, projectPath, template string) (registry.Project, error)
+	register      func(path, name, projectPath, template string) (registry.Project, error)
 	writeMarker   func(projectDir, name, template string, port int) (registry.Marker, error)
-	generateCaddy func(context.Context, execx.Runner, string, string) error
+	generateCaddy func(context.Context, execx.Runner, string, string) (bool, error)
 	reloadProxy   func(context.Context, execx.Runner, string) error
 	markerExists  func(string) bool
 }
@@ -54,8 +53,7 @@ func defaultRegisterCommand() registerCommand {
 		runner:        execx.NewSystemRunner(),
 		projectsFile:  config.ProjectsFile,
 		caddyfilePath: config.CaddyfilePath,
-		nextPort:      registry.NextPort,
-		register:      registry.Register,
+		register:      registry.RegisterNext,
 		writeMarker:   registry.WriteMarker,
 		generateCaddy: proxy.GenerateCaddyfile,
 		reloadProxy:   proxy.ReloadProxy,
@@ -129,10 +127,14 @@ func (c registerCommand) run(ctx context.Context, args []string, console *ui.UI,
 		logger.Info(fmt.Sprintf("Detected template type: %s", templateName))
 	}
 
-	port, err := c.nextPort(projectsPath)
-	if err != nil {
-		logger.Error("Failed to assign port")
-		return 1
+	port := 0
+	if c.register != nil {
+		project, err := c.register(projectsPath, projectName, projectDir, templateName)
+		if err != nil {
+			logRegistryError(logger, err, "Failed to register project")
+			return 1
+		}
+		port = project.Port
 	}
 
 	if c.writeMarker != nil {
@@ -142,27 +144,21 @@ func (c registerCommand) run(ctx context.Context, args []string, console *ui.UI,
 		}
 	}
 
-	if c.register != nil {
-		if _, err := c.register(projectsPath, projectName, port, projectDir, templateName); err != nil {
-			logger.Error("Failed to register project")
-			return 1
-		}
-	}
-
 	caddyfilePath, err := c.caddyfilePath()
 	if err != nil {
 		logger.Error("Failed to resolve Caddyfile path")
 		return 1
 	}
 
+	live := false
 	if c.generateCaddy != nil {
-		if err := c.generateCaddy(ctx, c.runner, projectsPath, caddyfilePath); err != nil {
+		if live, err = c.generateCaddy(ctx, c.runner, projectsPath, caddyfilePath); err != nil {
 			logger.Error("Failed to generate Caddyfile")
 			return 1
 		}
 	}
 
-	if c.reloadProxy != nil {
+	if c.reloadProxy != nil && !live {
 		if err := c.reloadProxy(ctx, c.runner, caddyfilePath); err != nil {
 			logger.Error("Failed to reload proxy")
 			return 1
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/alexcabrera/justvibin/internal/config"
+	execx "github.com/alexcabrera/justvibin/internal/exec"
+	"github.com/alexcabrera/justvibin/internal/logging"
+	"github.com/alexcabrera/justvibin/internal/proxy"
+	"github.com/alexcabrera/justvibin/internal/registry"
+	"github.com/alexcabrera/justvibin/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var registryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: "Inspect and maintain the project registry",
+	Long:  "Maintenance commands for the project registry stored in projects.json.",
+}
+
+var registryRepairCmd = &cobra.Command{
+	Use:   "repair [path]",
+	Short: "Repair a corrupt project registry",
+	Long:  "Restore a corrupt projects.json from projects.json.bak. If the backup is missing or also unreadable, rebuild the registry by scanning for .justvibin marker files (the home directory by default). The corrupt file is kept as projects.json.corrupt.",
+	Example: `justvibin registry repair           # Restore from backup or scan home directory
+justvibin registry repair ~/Code    # Scan a specific path if the backup is unusable`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRegistryRepairCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(registryCmd)
+	registryCmd.AddCommand(registryRepairCmd)
+}
+
+type registryCommand struct {
+	runner        execx.Runner
+	projectsFile  func() (string, error)
+	caddyfilePath func() (string, error)
+	repair        func(path, scanRoot string) (string, map[string]registry.Project, error)
+	generateCaddy func(context.Context, execx.Runner, string, string) (bool, error)
+	reloadProxy   func(context.Context, execx.Runner, string) error
+}
+
+var registryCommandFactory = defaultRegistryCommand
+
+func defaultRegistryCommand() registryCommand {
+	return registryCommand{
+		runner:        execx.NewSystemRunner(),
+		projectsFile:  config.ProjectsFile,
+		caddyfilePath: config.CaddyfilePath,
+		repair:        registry.Repair,
+		generateCaddy: proxy.GenerateCaddyfile,
+		reloadProxy:   proxy.ReloadProxy,
+	}
+}
+
+func runRegistryRepairCmd(cmd *cobra.Command, args []string) error {
+	output := getOutputSettings(cmd)
+	console := ui.New(cmd.OutOrStdout(), cmd.ErrOrStderr(), output.Styled)
+	logger := logging.New(cmd.OutOrStdout(), cmd.ErrOrStderr(), output.Styled)
+	logger.SetSilent(output.Quiet)
+	logger.SetVerbose(output.Verbose)
+
+	impl := registryCommandFactory()
+	code := impl.runRepair(context.Background(), args, console, logger)
+	if code != 0 {
+		return errors.New("registry repair command failed")
+	}
+	return nil
+}
+
+func (c registryCommand) runRepair(ctx context.Context, args []string, console *ui.UI, logger *logging.Logger) int {
+	_ = console
+
+	projectsPath, err := c.projectsFile()
+	if err != nil {
+		logger.Error("Failed to resolve projects file")
+		return 1
+	}
+
+	scanPath := os.Getenv("HOME")
+	if len(args) > 0 {
+		scanPath = args[0]
+	}
+
+	source, projects, err := c.repair(projectsPath, scanPath)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to repair registry: %v", err))
+		return 1
+	}
+
+	switch source {
+	case registry.RepairNone:
+		logger.Success(fmt.Sprintf("Registry is healthy (%d project(s))", len(projects)))
+		return 0
+	case registry.RepairBackup:
+		logger.Success(fmt.Sprintf("Restored %d project(s) from %s", len(projects), registry.BackupPath(projectsPath)))
+	case registry.RepairMarkers:
+		logger.Warn("Backup unavailable; rebuilt registry from .justvibin markers")
+		logger.Success(fmt.Sprintf("Recovered %d project(s) from %s", len(projects), scanPath))
+	}
+	logger.Info(fmt.Sprintf("Corrupt registry saved to %s.corrupt", projectsPath))
+
+	caddyfilePath, err := c.caddyfilePath()
+	if err != nil {
+		logger.Error("Failed to resolve Caddyfile path")
+		return 1
+	}
+	live := false
+	if c.generateCaddy != nil {
+		live, _ = c.generateCaddy(ctx, c.runner, projectsPath, caddyfilePath)
+	}
+	if c.reloadProxy != nil && !live {
+		_ = c.reloadProxy(ctx, c.runner, caddyfilePath)
+	}
+	return 0
+}
+
+// logRegistryError reports a registry load failure, pointing at
+// `justvibin registry repair` when the file is corrupt.
+func logRegistryError(logger *logging.Logger, err error, msg string) {
+	if errors.Is(err, registry.ErrCorruptRegistry) {
+		logger.Error("Project registry is corrupt")
+		logger.Info("Run 'justvibin registry repair' to restore it")
+		return
+	}
+	logger.Error(msg)
+}
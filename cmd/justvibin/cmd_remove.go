@@ -6,9 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
-	"syscall"
 
 	"github.com/alexcabrera/justvibin/internal/config"
 	"github.com/alexcabrera/justvibin/internal/logging"
@@ -77,7 +75,7 @@ func runRemoveCmd(cmd *cobra.Command, args []string) error {
 
 	project, ok, err := registry.Get(projectsPath, projectName)
 	if err != nil {
-		logger.Error("Failed to load project registry")
+		logRegistryError(logger, err, "Failed to load project registry")
 		return errors.New("remove command failed")
 	}
 	if !ok {
@@ -102,11 +100,9 @@ func runRemoveCmd(cmd *cobra.Command, args []string) error {
 
 	if projectPath != "" {
 		pidFile := filepath.Join(projectPath, serve.DefaultPIDFile)
-		if pidData, err := os.ReadFile(pidFile); err == nil {
-			if pid, err := strconv.Atoi(string(pidData)); err == nil {
-				if process, err := os.FindProcess(pid); err == nil {
-					_ = process.Signal(syscall.SIGTERM)
-				}
+		if record, err := serve.ReadPIDFile(pidFile); err == nil {
+			if err := record.VerifyPort(project.Port); !errors.Is(err, serve.ErrStalePID) {
+				_, _ = serve.Stop(record.PID, serve.DefaultStopTimeout, nil)
 			}
 			_ = os.Remove(pidFile)
 		}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/alexcabrera/justvibin/internal/logging"
+	"github.com/alexcabrera/justvibin/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search [query]",
+	Short: "Search the template index",
+	Long:  "Search the official templates and the template index configured under [templates] in config.toml by name, description and tags. Every word of the query must match. Without a query, lists every template. Install a result with 'justvibin install <name>'.",
+	Example: `justvibin search django
+justvibin search htmx static
+justvibin --json search`,
+	RunE: runSearchCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+}
+
+func runSearchCmd(cmd *cobra.Command, args []string) error {
+	output := getOutputSettings(cmd)
+	console := ui.New(cmd.OutOrStdout(), cmd.ErrOrStderr(), output.Styled)
+	out := cmd.OutOrStdout()
+	if output.JSON {
+		// Keep stdout valid JSON; warnings go to stderr.
+		out = cmd.ErrOrStderr()
+	}
+	logger := logging.New(out, cmd.ErrOrStderr(), output.Styled)
+	logger.SetSilent(output.Quiet)
+	logger.SetVerbose(output.Verbose)
+
+	impl := searchCommandFactory()
+	if code := impl.run(cmd.Context(), strings.Join(args, " "), console, logger, output); code != 0 {
+		return errors.New("search command failed")
+	}
+	return nil
+}
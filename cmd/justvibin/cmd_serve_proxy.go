@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/alexcabrera/justvibin/internal/config"
+	"github.com/alexcabrera/justvibin/internal/proxy"
+	"github.com/spf13/cobra"
+)
+
+// serveProxyCmd is the daemon the proxy service runs when config.toml sets
+// [proxy] backend = "builtin".
+var serveProxyCmd = &cobra.Command{
+	Use:    proxy.BuiltinProxyCommand,
+	Short:  "Run the builtin HTTPS proxy (internal)",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	RunE:   runServeProxyCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(serveProxyCmd)
+}
+
+func runServeProxyCmd(cmd *cobra.Command, _ []string) error {
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return err
+	}
+	projectsPath, err := config.ProjectsFile()
+	if err != nil {
+		return err
+	}
+	caDir, err := config.CADir()
+	if err != nil {
+		return err
+	}
+	ca, err := proxy.LoadOrCreateCA(caDir)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	server := &proxy.Builtin{
+		ProjectsPath: projectsPath,
+		CA:           ca,
+		HTTPSAddr:    settings.Proxy.HTTPSAddr,
+		HTTPAddr:     settings.Proxy.HTTPAddr,
+		Log:          cmd.OutOrStdout(),
+	}
+	return server.Run(ctx)
+}
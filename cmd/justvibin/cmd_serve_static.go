@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/alexcabrera/justvibin/internal/serve"
+	"github.com/spf13/cobra"
+)
+
+// serveStaticCmd is the helper process `justvibin start` launches for static
+// projects, so serving files does not depend on Caddy.
+var serveStaticCmd = &cobra.Command{
+	Use:    "__serve-static",
+	Short:  "Serve a directory over HTTP (internal)",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	RunE:   runServeStaticCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(serveStaticCmd)
+	serveStaticCmd.Flags().Int("port", 0, "Port to listen on")
+	serveStaticCmd.Flags().String("root", ".", "Directory to serve")
+	serveStaticCmd.Flags().StringArray("ext", nil, "Extension to try for clean URLs (repeatable)")
+	serveStaticCmd.Flags().Bool("spa", false, "Serve index.html for unknown paths")
+	serveStaticCmd.Flags().Bool("live-reload", false, "Reload open pages when files change")
+	serveStaticCmd.Flags().StringArray("exclude", nil, "Pattern whose changes do not trigger a reload (repeatable)")
+}
+
+func runServeStaticCmd(cmd *cobra.Command, _ []string) error {
+	port, _ := cmd.Flags().GetInt("port")
+	root, _ := cmd.Flags().GetString("root")
+	exts, _ := cmd.Flags().GetStringArray("ext")
+	spa, _ := cmd.Flags().GetBool("spa")
+	liveReload, _ := cmd.Flags().GetBool("live-reload")
+	exclude, _ := cmd.Flags().GetStringArray("exclude")
+	if port <= 0 {
+		return errors.New("--port is required")
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return fmt.Errorf("root %s is not a directory", root)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	opts := serve.StaticOptions{Extensions: exts, SPA: spa, LiveReload: liveReload, Exclude: exclude}
+	var reload *serve.LiveReload
+	if liveReload {
+		reload = serve.NewLiveReload(root, exclude)
+		go reload.Watch(ctx, func(err error) {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Live reload watcher failed, restarting: %v\n", err)
+		})
+	}
+	handler := serve.StaticHandler(root, opts, reload)
+	fmt.Fprintf(cmd.OutOrStdout(), "Serving %s on :%d\n", root, port)
+	return serve.ServeStatic(ctx, fmt.Sprintf(":%d", port), handler, cmd.OutOrStdout())
+}
@@ -31,7 +31,7 @@ func init() {
 	setupCmd.Flags().Bool("migrate", false, "Migrate from srv without prompting. Default: false")
 	setupCmd.Flags().Bool("no-migrate", false, "Skip srv migration without prompting. Default: false")
 
-	setupCmd.Flags().Bool("trust-ca", false, "Trust Caddy CA without prompting. Requires sudo. Default: false")
+	setupCmd.Flags().Bool("trust-ca", false, "Trust the local CA without prompting. Requires sudo. Default: false")
 	setupCmd.Flags().Bool("no-trust-ca", false, "Skip CA trust without prompting. Default: false")
 
 	setupCmd.Flags().Bool("install-templates", false, "Install all official templates without prompting. Default: false")
@@ -114,7 +114,7 @@ func (f setupFlags) answerFor(question string) (bool, bool) {
 		if f.noMigrate {
 			return false, true
 		}
-	case "Trust Caddy CA? (requires sudo)":
+	case "Trust local CA? (requires sudo)":
 		if f.trustCA || f.yes {
 			return true, true
 		}
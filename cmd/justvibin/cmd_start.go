@@ -4,11 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/alexcabrera/justvibin/internal/config"
+	"github.com/alexcabrera/justvibin/internal/logfile"
 	"github.com/alexcabrera/justvibin/internal/logging"
 	"github.com/alexcabrera/justvibin/internal/manifest"
 	"github.com/alexcabrera/justvibin/internal/proxy"
@@ -24,7 +31,9 @@ var startCmd = &cobra.Command{
 	Long:  "Start a development or production server for a justvibin project. Without arguments, starts the project in the current directory. Supports static file serving and command-based servers defined in the template manifest.",
 	Example: `justvibin start              # Start current project
 justvibin start myapp        # Start specific project
-justvibin start --prod       # Start in production mode`,
+justvibin start --prod       # Start in production mode
+justvibin start --foreground # Stay attached and restart the server if it crashes
+justvibin start --detach     # Run in the background, logging to a file`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runStartCmd,
 }
@@ -32,6 +41,19 @@ justvibin start --prod       # Start in production mode`,
 func init() {
 	rootCmd.AddCommand(startCmd)
 	startCmd.Flags().Bool("prod", false, "Run in production mode")
+	startCmd.Flags().Bool("foreground", false, "Stay attached, forward signals and restart the server on crash")
+	startCmd.Flags().Bool("detach", false, "Run in the background with output in the project log file")
+	startCmd.Flags().Bool("detached", false, "")
+	_ = startCmd.Flags().MarkHidden("detached")
+}
+
+type startOptions struct {
+	Prod       bool
+	Foreground bool
+	Detach     bool
+	// Detached is set on the child that --detach re-executes; all output
+	// then goes to the project log only.
+	Detached bool
 }
 
 type startCommand struct {
@@ -39,11 +61,28 @@ type startCommand struct {
 	projectsFile  func() (string, error)
 	readMarker    func(string) (registry.Marker, error)
 	readFile      func(string) ([]byte, error)
-	startStatic   func(ctx context.Context, runner serve.CommandRunner, port int, root string) (int, error)
-	startCommand  func(ctx context.Context, dir string, cmd string, port int, portEnv string) (int, error)
+	startStatic   func(ctx context.Context, runner serve.CommandRunner, dir string, port int, root string, opts serve.StaticOptions, probe serve.Probe) (int, error)
+	startCommand  func(ctx context.Context, dir string, cmd string, port int, portEnv string, out *os.File, probe serve.Probe) (int, error)
 	isPortInUse   func(int) bool
+	isRunning     func(projectDir string, port int) bool
+	assignPorts   func(path, name string, processes []string) (map[string]int, error)
+	setRoutes     func(path, name string, routes map[string]string) error
+	supervise     func(ctx context.Context, sup serve.Supervisor) error
+	foreground    func(ctx context.Context, fg serve.Foreground) error
+	commandRunner func(serve.SystemRunner) serve.CommandRunner
+	executable    func() (string, error)
+	logPath       func(name string) (string, error)
+	captureLog    func(exe, logPath string) (*os.File, func(), error)
+	stdout        io.Writer
+	stderr        io.Writer
+	styled        bool
+	aliases       []string
 }
 
+// startupOutputLines is how much server output start prints when the server
+// fails its readiness check.
+const startupOutputLines = 20
+
 var startCommandFactory = defaultStartCommand
 
 func defaultStartCommand() startCommand {
@@ -55,6 +94,17 @@ func defaultStartCommand() startCommand {
 		startStatic:   serve.StartStaticServer,
 		startCommand:  startCommandServer,
 		isPortInUse:   isPortInUse,
+		isRunning:     projectRunning,
+		assignPorts:   registry.AssignProcessPorts,
+		setRoutes:     registry.SetRoutes,
+		supervise:     func(ctx context.Context, sup serve.Supervisor) error { return sup.Run(ctx) },
+		foreground:    func(ctx context.Context, fg serve.Foreground) error { return fg.Run(ctx) },
+		commandRunner: func(r serve.SystemRunner) serve.CommandRunner { return r },
+		executable:    os.Executable,
+		logPath:       config.ProjectLogPath,
+		captureLog:    startLogCapture,
+		stdout:        os.Stdout,
+		stderr:        os.Stderr,
 	}
 }
 
@@ -65,17 +115,29 @@ func runStartCmd(cmd *cobra.Command, args []string) error {
 	logger.SetSilent(output.Quiet)
 	logger.SetVerbose(output.Verbose)
 
-	prodMode, _ := cmd.Flags().GetBool("prod")
+	var opts startOptions
+	opts.Prod, _ = cmd.Flags().GetBool("prod")
+	opts.Foreground, _ = cmd.Flags().GetBool("foreground")
+	opts.Detach, _ = cmd.Flags().GetBool("detach")
+	opts.Detached, _ = cmd.Flags().GetBool("detached")
 
 	impl := startCommandFactory()
-	code := impl.run(context.Background(), args, console, logger, prodMode)
+	impl.stdout = cmd.OutOrStdout()
+	impl.stderr = cmd.ErrOrStderr()
+	impl.styled = output.Styled
+	code := impl.run(context.Background(), args, console, logger, opts)
 	if code != 0 {
 		return errors.New("start command failed")
 	}
 	return nil
 }
 
-func (c startCommand) run(ctx context.Context, args []string, console *ui.UI, logger *logging.Logger, prodMode bool) int {
+func (c startCommand) run(ctx context.Context, args []string, console *ui.UI, logger *logging.Logger, opts startOptions) int {
+	if opts.Foreground && opts.Detach {
+		logger.Error("--foreground and --detach cannot be combined")
+		return 1
+	}
+
 	var projectDir string
 	var projectName string
 
@@ -88,7 +150,7 @@ func (c startCommand) run(ctx context.Context, args []string, console *ui.UI, lo
 		}
 		project, ok, err := registry.Get(projectsPath, projectName)
 		if err != nil {
-			logger.Error("Failed to load project registry")
+			logRegistryError(logger, err, "Failed to load project registry")
 			return 1
 		}
 		if !ok {
@@ -118,10 +180,15 @@ func (c startCommand) run(ctx context.Context, args []string, console *ui.UI, lo
 	projectName = marker.Name
 	port := marker.Port
 	templateName := marker.Template
+	c.aliases = marker.Aliases
 
-	if c.isPortInUse(port) {
+	// The pid file is what a [processes] group without a routed process
+	// leaves behind, since nothing then listens on the project port. The
+	// child --detach starts already finds its own pid there.
+	if !opts.Detached && (c.isRunning(projectDir, port) || c.isPortInUse(port)) {
 		logger.Warn(fmt.Sprintf("Project already running on port %d", port))
 		logger.Info(fmt.Sprintf("URL: https://%s.localhost", projectName))
+		logOtherURLs(logger, projectName, c.aliases)
 		return 0
 	}
 
@@ -145,6 +212,55 @@ func (c startCommand) run(ctx context.Context, args []string, console *ui.UI, lo
 		}
 	}
 
+	// Restarting on file changes needs a process that outlives this command,
+	// so a watched server always runs detached unless asked to stay attached.
+	if opts.Detach || (watchesFiles(serveType, mf) && !opts.Foreground) {
+		return c.detach(ctx, projectDir, projectName, port, mf, opts, logger)
+	}
+	if opts.Foreground || len(mf.Processes) > 0 {
+		projectLog, err := c.openLog(projectName)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to open log file: %v", err))
+			return 1
+		}
+		defer projectLog.Close()
+		if opts.Detached {
+			logger = logging.New(projectLog, projectLog, false)
+			c.stdout, c.stderr = io.Discard, io.Discard
+		}
+		if len(mf.Processes) > 0 {
+			return c.runProcesses(ctx, projectDir, projectName, port, mf, projectLog, logger)
+		}
+		return c.runForeground(ctx, projectDir, projectName, port, serveType, mf, opts, projectLog, logger)
+	}
+
+	logPath, err := c.logPath(projectName)
+	if err != nil {
+		logger.Error("Failed to resolve log file")
+		return 1
+	}
+	exe, err := c.executable()
+	if err != nil {
+		logger.Error("Failed to resolve justvibin executable")
+		return 1
+	}
+	var offset int64
+	if info, err := os.Stat(logPath); err == nil {
+		offset = info.Size()
+	}
+	logFile, drained, err := c.captureLog(exe, logPath)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to open log file: %v", err))
+		return 1
+	}
+	defer logFile.Close()
+	failed := func() {
+		_ = logFile.Close()
+		drained()
+		c.printStartupOutput(logPath, offset, logger)
+	}
+	probe := readyProbe(mf, port)
+
 	logger.Info(fmt.Sprintf("Starting %s on port %d...", projectName, port))
 
 	switch serveType {
@@ -153,13 +269,14 @@ func (c startCommand) run(ctx context.Context, args []string, console *ui.UI, lo
 		if mf.Serve.Static.Root != "" {
 			staticRoot = filepath.Join(projectDir, mf.Serve.Static.Root)
 		}
-		_, err := c.startStatic(ctx, nil, port, staticRoot)
+		_, err := c.startStatic(ctx, serve.SystemRunner{Stdout: logFile, Stderr: logFile, Setpgid: true}, projectDir, port, staticRoot, staticOptions(mf), probe)
 		if err != nil {
 			logger.Error(fmt.Sprintf("Failed to start static server: %v", err))
+			failed()
 			return 1
 		}
 	case "command":
-		cmdStr := manifest.ServeCommand(mf, modeString(prodMode))
+		cmdStr := manifest.ServeCommand(mf, modeString(opts.Prod))
 		if cmdStr == "" {
 			logger.Error("No serve command defined in manifest")
 			return 1
@@ -168,9 +285,10 @@ func (c startCommand) run(ctx context.Context, args []string, console *ui.UI, lo
 		if portEnv == "" {
 			portEnv = "PORT"
 		}
-		_, err := c.startCommand(ctx, projectDir, cmdStr, port, portEnv)
+		_, err := c.startCommand(ctx, projectDir, cmdStr, port, portEnv, logFile, probe)
 		if err != nil {
 			logger.Error(fmt.Sprintf("Failed to start server: %v", err))
+			failed()
 			return 1
 		}
 	default:
@@ -178,39 +296,375 @@ func (c startCommand) run(ctx context.Context, args []string, console *ui.UI, lo
 		return 1
 	}
 
-	// Regenerate Caddyfile and ensure proxy is running
+	c.refreshProxy(ctx, projectName, logger)
+
+	logger.Success(fmt.Sprintf("Started: https://%s.localhost", projectName))
+	logOtherURLs(logger, projectName, c.aliases)
+	logger.Info(fmt.Sprintf("Logs: %s", logPath))
+	return 0
+}
+
+func staticOptions(mf manifest.Manifest) serve.StaticOptions {
+	return serve.StaticOptions{
+		Extensions: mf.Serve.Static.Extensions,
+		SPA:        mf.Serve.Static.SPA,
+		LiveReload: mf.Serve.Static.LiveReload,
+		Exclude:    mf.Scaffold.Exclude,
+	}
+}
+
+// readyProbe builds the startup health check from the manifest. A
+// [processes] group only serves the project port through its routed
+// process; without one there is no port to probe.
+func readyProbe(mf manifest.Manifest, port int) serve.Probe {
+	if len(mf.Processes) > 0 && !hasRoutedProcess(mf) {
+		port = 0
+	}
+	return serve.Probe{Port: port, HealthPath: mf.Serve.HealthPath, Timeout: manifest.ReadyTimeout(mf)}
+}
+
+func hasRoutedProcess(mf manifest.Manifest) bool {
+	for _, process := range mf.Processes {
+		if process.Route {
+			return true
+		}
+	}
+	return false
+}
+
+// projectRunning reports whether the project's pid file names a live server.
+func projectRunning(projectDir string, port int) bool {
+	record, err := serve.ReadPIDFile(filepath.Join(projectDir, serve.DefaultPIDFile))
+	return err == nil && record.VerifyPort(port) == nil
+}
+
+// printStartupOutput shows the tail of what a failed server wrote to its log
+// after offset.
+func (c startCommand) printStartupOutput(logPath string, offset int64, logger *logging.Logger) {
+	lines, err := logfile.LinesAfter(logPath, offset, startupOutputLines)
+	if err != nil || len(lines) == 0 {
+		logger.Info(fmt.Sprintf("See logs: %s", logPath))
+		return
+	}
+	logger.Info(fmt.Sprintf("Last %d line(s) of server output (%s):", len(lines), logPath))
+	for _, line := range lines {
+		fmt.Fprintf(c.stderr, "  %s\n", line)
+	}
+}
+
+// openLog opens the rotating, timestamped log for projectName.
+func (c startCommand) openLog(projectName string) (*logfile.Writer, error) {
+	path, err := c.logPath(projectName)
+	if err != nil {
+		return nil, err
+	}
+	return logfile.Open(path)
+}
+
+// runProcesses supervises every [processes.<name>] entry in the foreground
+// until interrupted or until one of them exits. The pid file holds this
+// process so `justvibin stop` tears down the whole group.
+func (c startCommand) runProcesses(ctx context.Context, projectDir, projectName string, port int, mf manifest.Manifest, projectLog io.Writer, logger *logging.Logger) int {
+	names := manifest.ProcessNames(mf)
+	var needPorts []string
+	for _, name := range names {
+		process := mf.Processes[name]
+		if process.PortEnv != "" && !process.Route {
+			needPorts = append(needPorts, name)
+		}
+	}
 	projectsPath, err := c.projectsFile()
-	if err == nil {
-		caddyfilePath, err := config.CaddyfilePath()
-		if err == nil {
-			if err := proxy.GenerateCaddyfile(ctx, nil, projectsPath, caddyfilePath); err != nil {
-				logger.Warn(fmt.Sprintf("Failed to regenerate Caddyfile: %v", err))
-			} else {
-				// Start proxy if not running, otherwise reload
-				if !proxy.IsProxyRunning(ctx, nil) {
-					plistPath, err := config.ProxyPlistPath()
-					if err == nil {
-						logPath, _ := config.ProxyLogPath()
-						errPath, _ := config.ProxyErrPath()
-						if err := proxy.CreatePlist(ctx, nil, plistPath, caddyfilePath, logPath, errPath); err != nil {
-							logger.Warn(fmt.Sprintf("Failed to create proxy plist: %v", err))
-						} else if err := proxy.InstallProxyService(ctx, nil, plistPath); err != nil {
-							logger.Warn(fmt.Sprintf("Failed to start proxy: %v", err))
-						} else {
-							logger.Info("Started HTTPS proxy")
-						}
-					}
-				} else if err := proxy.ReloadProxy(ctx, nil, caddyfilePath); err != nil {
-					logger.Warn(fmt.Sprintf("Failed to reload proxy: %v", err))
-				}
-			}
+	if err != nil {
+		logger.Error("Failed to resolve projects file")
+		return 1
+	}
+	ports := map[string]int{}
+	if len(needPorts) > 0 {
+		ports, err = c.assignPorts(projectsPath, projectName, needPorts)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to assign process ports: %v", err))
+			return 1
+		}
+	}
+	// Recorded on every start so routes removed from the manifest stop being
+	// served.
+	if err := c.setRoutes(projectsPath, projectName, mf.Routes); err != nil {
+		logger.Error(fmt.Sprintf("Failed to record routes: %v", err))
+		return 1
+	}
+
+	processes := make([]serve.Process, 0, len(names))
+	for _, name := range names {
+		process := mf.Processes[name]
+		env := make([]string, 0, len(process.Env)+1)
+		for key, value := range process.Env {
+			env = append(env, fmt.Sprintf("%s=%s", key, value))
+		}
+		sort.Strings(env)
+		processPort := ports[name]
+		if process.Route {
+			processPort = port
+		}
+		if process.PortEnv != "" {
+			env = append(env, fmt.Sprintf("%s=%d", process.PortEnv, processPort))
+			logger.Info(fmt.Sprintf("Starting %s on port %d...", name, processPort))
+		} else {
+			logger.Info(fmt.Sprintf("Starting %s...", name))
 		}
+		processes = append(processes, serve.Process{Name: name, Command: process.Command, Dir: projectDir, Env: env})
 	}
 
+	pidFile := filepath.Join(projectDir, serve.DefaultPIDFile)
+	if err := serve.WritePIDFile(pidFile, os.Getpid()); err != nil {
+		logger.Error("Failed to write PID file")
+		return 1
+	}
+	defer func() { _ = os.Remove(pidFile) }()
+
+	c.refreshProxy(ctx, projectName, logger)
+	logger.Success(fmt.Sprintf("Started: https://%s.localhost", projectName))
+	logOtherURLs(logger, projectName, c.aliases)
+	logger.Info("Press Ctrl+C to stop")
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	err = c.supervise(ctx, serve.Supervisor{Processes: processes, Output: c.stdout, Log: projectLog, Styled: c.styled})
+	if err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+	logger.Success(fmt.Sprintf("Stopped: %s", projectName))
+	return 0
+}
+
+// runForeground keeps the server attached to the terminal, forwarding signals
+// to it and restarting it with backoff when it crashes.
+func (c startCommand) runForeground(ctx context.Context, projectDir, projectName string, port int, serveType string, mf manifest.Manifest, opts startOptions, projectLog io.Writer, logger *logging.Logger) int {
+	exe, err := c.executable()
+	if err != nil {
+		logger.Error("Failed to resolve justvibin executable")
+		return 1
+	}
+	name, args, env, err := serverCommand(exe, projectDir, port, serveType, mf, opts.Prod)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Cannot start server: %v", err))
+		return 1
+	}
+
+	pidFile := filepath.Join(projectDir, serve.DefaultPIDFile)
+	if err := serve.WritePIDFile(pidFile, os.Getpid()); err != nil {
+		logger.Error("Failed to write PID file")
+		return 1
+	}
+	defer func() { _ = os.Remove(pidFile) }()
+
+	logger.Info(fmt.Sprintf("Starting %s on port %d...", projectName, port))
+	c.refreshProxy(ctx, projectName, logger)
 	logger.Success(fmt.Sprintf("Started: https://%s.localhost", projectName))
+	logOtherURLs(logger, projectName, c.aliases)
+	logger.Info("Press Ctrl+C to stop")
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	runner := c.commandRunner(serve.SystemRunner{
+		Dir:     projectDir,
+		Env:     env,
+		Stdout:  io.MultiWriter(c.stdout, projectLog),
+		Stderr:  io.MultiWriter(c.stderr, projectLog),
+		Setpgid: true,
+	})
+	var restart chan struct{}
+	if watchesFiles(serveType, mf) {
+		restart = make(chan struct{}, 1)
+		watchCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go watchForRestart(watchCtx, projectDir, mf, restart, func(message string) {
+			logger.Info(message)
+			if !opts.Detached {
+				fmt.Fprintln(projectLog, message)
+			}
+		})
+		logger.Info(fmt.Sprintf("Watching %s for changes", strings.Join(mf.Serve.Watch.Include, ", ")))
+	}
+	err = c.foreground(ctx, serve.Foreground{
+		Runner:  runner,
+		Name:    name,
+		Args:    args,
+		Signals: signals,
+		Restart: restart,
+		OnCrash: func(err error, delay time.Duration) {
+			message := fmt.Sprintf("Server exited (%v); restarting in %s", err, delay)
+			logger.Warn(message)
+			if !opts.Detached {
+				fmt.Fprintln(projectLog, message)
+			}
+		},
+	})
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to start server: %v", err))
+		return 1
+	}
+	logger.Success(fmt.Sprintf("Stopped: %s", projectName))
+	return 0
+}
+
+func watchesFiles(serveType string, mf manifest.Manifest) bool {
+	return serveType == "command" && len(mf.Serve.Watch.Include) > 0
+}
+
+// watchForRestart asks the server to restart through restart whenever files
+// matching [serve.watch] change, until ctx is cancelled.
+func watchForRestart(ctx context.Context, projectDir string, mf manifest.Manifest, restart chan<- struct{}, report func(string)) {
+	watcher := serve.Watcher{
+		Root:     projectDir,
+		Include:  mf.Serve.Watch.Include,
+		Exclude:  mf.Serve.Watch.Exclude,
+		Debounce: manifest.WatchDebounce(mf),
+	}
+	err := watcher.Run(ctx, func() {
+		report("Files changed; restarting server")
+		select {
+		case restart <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil {
+		report(fmt.Sprintf("Stopped watching for changes: %v", err))
+	}
+}
+
+// detach re-runs `justvibin start --foreground` in a new session, where it
+// writes to the project log file, and records its PID.
+func (c startCommand) detach(ctx context.Context, projectDir, projectName string, port int, mf manifest.Manifest, opts startOptions, logger *logging.Logger) int {
+	exe, err := c.executable()
+	if err != nil {
+		logger.Error("Failed to resolve justvibin executable")
+		return 1
+	}
+	logPath, err := c.logPath(projectName)
+	if err != nil {
+		logger.Error("Failed to resolve log file")
+		return 1
+	}
+	// Anything the child prints before its own log writer is open, such as
+	// a startup error, still lands in the log.
+	logFile, err := logfile.OpenAppend(logPath)
+	if err != nil {
+		logger.Error("Failed to open log file")
+		return 1
+	}
+	defer logFile.Close()
+	var offset int64
+	if info, err := logFile.Stat(); err == nil {
+		offset = info.Size()
+	}
+
+	args := []string{"start", "--foreground", "--detached"}
+	if opts.Prod {
+		args = append(args, "--prod")
+	}
+	runner := c.commandRunner(serve.SystemRunner{Dir: projectDir, Stderr: logFile, Setsid: true})
+	cmd, err := runner.Start(exe, args...)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to start server: %v", err))
+		return 1
+	}
+	pid := cmd.Process.Pid
+	pidFile := filepath.Join(projectDir, serve.DefaultPIDFile)
+	if err := serve.WritePIDFile(pidFile, pid); err != nil {
+		_ = cmd.Process.Kill()
+		logger.Error("Failed to write PID file")
+		return 1
+	}
+
+	go func() { _ = cmd.Wait() }()
+	if err := serve.WaitReady(ctx, pid, readyProbe(mf, port)); err != nil {
+		_, _ = serve.Stop(pid, time.Second, nil)
+		_ = os.Remove(pidFile)
+		logger.Error(fmt.Sprintf("Failed to start server: %v", err))
+		c.printStartupOutput(logPath, offset, logger)
+		return 1
+	}
+
+	logger.Success(fmt.Sprintf("Started %s in the background on port %d (pid %d)", projectName, port, pid))
+	logger.Info(fmt.Sprintf("URL: https://%s.localhost", projectName))
+	logOtherURLs(logger, projectName, c.aliases)
+	logger.Info(fmt.Sprintf("Logs: %s", logPath))
 	return 0
 }
 
+// serverCommand returns the program, arguments and extra environment that
+// serve a single-server project.
+func serverCommand(exe, projectDir string, port int, serveType string, mf manifest.Manifest, prod bool) (string, []string, []string, error) {
+	switch serveType {
+	case "static":
+		root := projectDir
+		if mf.Serve.Static.Root != "" {
+			root = filepath.Join(projectDir, mf.Serve.Static.Root)
+		}
+		return exe, serve.StaticServerArgs(port, root, staticOptions(mf)), nil, nil
+	case "command":
+		cmdStr := manifest.ServeCommand(mf, modeString(prod))
+		if cmdStr == "" {
+			return "", nil, nil, errors.New("no serve command defined in manifest")
+		}
+		portEnv := mf.Serve.PortEnv
+		if portEnv == "" {
+			portEnv = "PORT"
+		}
+		return "bash", []string{"-c", cmdStr}, []string{fmt.Sprintf("%s=%d", portEnv, port)}, nil
+	default:
+		return "", nil, nil, fmt.Errorf("unknown serve type: %s", serveType)
+	}
+}
+
+// refreshProxy records the start, regenerates the Caddyfile and makes sure
+// the proxy is running so the project's URL resolves.
+func (c startCommand) refreshProxy(ctx context.Context, projectName string, logger *logging.Logger) {
+	projectsPath, err := c.projectsFile()
+	if err != nil {
+		return
+	}
+	if err := registry.MarkStarted(projectsPath, projectName, time.Now()); err != nil {
+		logger.Warn(fmt.Sprintf("Failed to record start time: %v", err))
+	}
+	caddyfilePath, err := config.CaddyfilePath()
+	if err != nil {
+		return
+	}
+	live, err := proxy.GenerateCaddyfile(ctx, nil, projectsPath, caddyfilePath)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("Failed to regenerate Caddyfile: %v", err))
+		return
+	}
+	if live {
+		return
+	}
+
+	// Start proxy if not running, otherwise reload
+	if proxy.IsProxyRunning(ctx, nil) {
+		if err := proxy.ReloadProxy(ctx, nil, caddyfilePath); err != nil {
+			logger.Warn(fmt.Sprintf("Failed to reload proxy: %v", err))
+		}
+		return
+	}
+	plistPath, err := config.ProxyServicePath()
+	if err != nil {
+		return
+	}
+	logPath, _ := config.ProxyLogPath()
+	errPath, _ := config.ProxyErrPath()
+	if err := proxy.CreatePlist(ctx, nil, plistPath, caddyfilePath, logPath, errPath); err != nil {
+		logger.Warn(fmt.Sprintf("Failed to create proxy service: %v", err))
+	} else if err := proxy.InstallProxyService(ctx, nil, plistPath); err != nil {
+		logger.Warn(fmt.Sprintf("Failed to start proxy: %v", err))
+	} else {
+		logger.Info("Started HTTPS proxy")
+	}
+}
+
 func modeString(prod bool) string {
 	if prod {
 		return "prod"
@@ -218,12 +672,54 @@ func modeString(prod bool) string {
 	return "dev"
 }
 
-func startCommandServer(ctx context.Context, dir string, cmdStr string, port int, portEnv string) (int, error) {
+// startLogCapture starts the `justvibin __log` helper in a session of its
+// own and returns the pipe a server should write its output to. The helper
+// timestamps and rotates the log until every copy of the pipe is closed, so
+// it outlives start. drained waits briefly for it to finish once the server
+// is gone, so its last output can be shown.
+func startLogCapture(exe, logPath string) (*os.File, func(), error) {
+	// Fail here rather than in the helper, where nobody would see it.
+	w, err := logfile.Open(logPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	_ = w.Close()
+
+	r, pipe, err := os.Pipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer r.Close()
+	cmd := exec.Command(exe, logCaptureCommand, logPath)
+	cmd.Stdin = r
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		_ = pipe.Close()
+		return nil, nil, err
+	}
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(exited)
+	}()
+	drained := func() {
+		select {
+		case <-exited:
+		case <-time.After(time.Second):
+		}
+	}
+	return pipe, drained, nil
+}
+
+func startCommandServer(ctx context.Context, dir string, cmdStr string, port int, portEnv string, out *os.File, probe serve.Probe) (int, error) {
 	cmd := exec.CommandContext(ctx, "bash", "-c", cmdStr)
 	cmd.Dir = dir
 	cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%d", portEnv, port))
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdout = out
+	cmd.Stderr = out
+	// A group of its own lets `justvibin stop` reach everything the command
+	// spawns, not just the bash wrapper.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 
 	if err := cmd.Start(); err != nil {
 		return 0, err
@@ -231,10 +727,15 @@ func startCommandServer(ctx context.Context, dir string, cmdStr string, port int
 
 	pid := cmd.Process.Pid
 	pidFile := filepath.Join(dir, serve.DefaultPIDFile)
-	if err := os.WriteFile(pidFile, []byte(fmt.Sprintf("%d", pid)), 0644); err != nil {
+	if err := serve.WritePIDFile(pidFile, pid); err != nil {
 		_ = cmd.Process.Kill()
 		return 0, err
 	}
 
+	if err := serve.WaitReady(ctx, pid, probe); err != nil {
+		_, _ = serve.Stop(pid, time.Second, nil)
+		_ = os.Remove(pidFile)
+		return 0, err
+	}
 	return pid, nil
 }
@@ -6,7 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
-	"syscall"
+	"strings"
 
 	"github.com/alexcabrera/justvibin/internal/config"
 	"github.com/alexcabrera/justvibin/internal/logging"
@@ -19,15 +19,17 @@ import (
 var stopCmd = &cobra.Command{
 	Use:   "stop [name]",
 	Short: "Stop a running project",
-	Long:  "Stop a running justvibin project by terminating its server process. Without arguments, stops the project in the current directory.",
+	Long:  "Stop a running justvibin project by sending SIGTERM to its server's process group and every child process, then waiting for the project port to be released. Processes still running after --timeout are killed. Without arguments, stops the project in the current directory.",
 	Example: `justvibin stop              # Stop current project
-justvibin stop myapp        # Stop specific project`,
+justvibin stop myapp        # Stop specific project
+justvibin stop --timeout 3s # Kill anything still running after 3 seconds`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runStopCmd,
 }
 
 func init() {
 	rootCmd.AddCommand(stopCmd)
+	stopCmd.Flags().Duration("timeout", serve.DefaultStopTimeout, "How long to wait after SIGTERM before sending SIGKILL")
 }
 
 func runStopCmd(cmd *cobra.Command, args []string) error {
@@ -36,6 +38,7 @@ func runStopCmd(cmd *cobra.Command, args []string) error {
 	logger := logging.New(cmd.OutOrStdout(), cmd.ErrOrStderr(), output.Styled)
 	logger.SetSilent(output.Quiet)
 	logger.SetVerbose(output.Verbose)
+	timeout, _ := cmd.Flags().GetDuration("timeout")
 
 	var projectDir string
 	var projectName string
@@ -49,7 +52,7 @@ func runStopCmd(cmd *cobra.Command, args []string) error {
 		}
 		project, ok, err := registry.Get(projectsPath, projectName)
 		if err != nil {
-			logger.Error("Failed to load project registry")
+			logRegistryError(logger, err, "Failed to load project registry")
 			return errors.New("stop command failed")
 		}
 		if !ok {
@@ -78,38 +81,61 @@ func runStopCmd(cmd *cobra.Command, args []string) error {
 	projectName = marker.Name
 
 	pidFile := filepath.Join(projectDir, serve.DefaultPIDFile)
-	pidData, err := os.ReadFile(pidFile)
+	record, err := serve.ReadPIDFile(pidFile)
 	if err != nil {
 		if os.IsNotExist(err) {
 			logger.Info(fmt.Sprintf("Project '%s' is not running", projectName))
 			return nil
 		}
-		logger.Error("Failed to read PID file")
+		logger.Error(fmt.Sprintf("Invalid PID file: %v", err))
+		_ = os.Remove(pidFile)
 		return errors.New("stop command failed")
 	}
+	pid := record.PID
 
-	pid, err := strconv.Atoi(string(pidData))
-	if err != nil {
-		logger.Error("Invalid PID file")
+	// A server whose leader already exited may still have children in its
+	// process group, so only a PID now owned by another process is skipped.
+	if err := record.VerifyPort(marker.Port); errors.Is(err, serve.ErrStalePID) {
+		logger.Warn(fmt.Sprintf("PID %d no longer belongs to '%s'; not signalling it", pid, projectName))
+		logger.Info(fmt.Sprintf("Project '%s' is not running (stale PID file removed)", projectName))
 		_ = os.Remove(pidFile)
-		return errors.New("stop command failed")
+		return nil
 	}
 
-	process, err := os.FindProcess(pid)
-	if err != nil {
+	var released func() bool
+	if marker.Port > 0 {
+		released = func() bool { return !isPortInUse(marker.Port) }
+	}
+	result, err := serve.Stop(pid, timeout, released)
+	if errors.Is(err, serve.ErrNotRunning) {
 		logger.Info(fmt.Sprintf("Project '%s' is not running (process not found)", projectName))
 		_ = os.Remove(pidFile)
 		return nil
 	}
-
-	if err := process.Signal(syscall.SIGTERM); err != nil {
-		if err := process.Signal(syscall.SIGKILL); err != nil {
-			logger.Warn(fmt.Sprintf("Failed to stop process %d", pid))
-		}
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to stop process %d: %v", pid, err))
+		return errors.New("stop command failed")
 	}
 
 	_ = os.Remove(pidFile)
 	_ = console
+	if len(result.Killed) > 0 {
+		logger.Warn(fmt.Sprintf("Killed PIDs %s after %s without exiting on SIGTERM", formatPIDs(result.Killed), timeout))
+	}
+	if result.PortBusy {
+		logger.Warn(fmt.Sprintf("Port %d is still in use by another process", marker.Port))
+	}
+	if len(result.Stopped) > 0 {
+		logger.Info(fmt.Sprintf("Terminated PIDs %s", formatPIDs(result.Stopped)))
+	}
 	logger.Success(fmt.Sprintf("Stopped: %s", projectName))
 	return nil
 }
+
+func formatPIDs(pids []int) string {
+	parts := make([]string, len(pids))
+	for i, pid := range pids {
+		parts[i] = strconv.Itoa(pid)
+	}
+	return strings.Join(parts, ", ")
+}
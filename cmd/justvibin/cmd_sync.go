@@ -4,9 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io/fs"
 	"os"
-	"path/filepath"
+	"time"
 
 	"github.com/alexcabrera/justvibin/internal/config"
 	execx "github.com/alexcabrera/justvibin/internal/exec"
@@ -37,12 +36,9 @@ type syncCommand struct {
 	runner        execx.Runner
 	projectsFile  func() (string, error)
 	caddyfilePath func() (string, error)
-	register      func(path, name string, port int, projectPath, template string) (registry.Project, error)
-	generateCaddy func(context.Context, execx.Runner, string, string) error
+	update        func(string, func(map[string]registry.Project) error) error
+	generateCaddy func(context.Context, execx.Runner, string, string) (bool, error)
 	reloadProxy   func(context.Context, execx.Runner, string) error
-	loadProjects  func(string) (map[string]registry.Project, error)
-	unregister    func(string, string) (bool, error)
-	saveProjects  func(string, map[string]registry.Project) error
 }
 
 var syncCommandFactory = defaultSyncCommand
@@ -52,12 +48,9 @@ func defaultSyncCommand() syncCommand {
 		runner:        execx.NewSystemRunner(),
 		projectsFile:  config.ProjectsFile,
 		caddyfilePath: config.CaddyfilePath,
-		register:      registry.Register,
+		update:        registry.Update,
 		generateCaddy: proxy.GenerateCaddyfile,
 		reloadProxy:   proxy.ReloadProxy,
-		loadProjects:  registry.Load,
-		unregister:    registry.Unregister,
-		saveProjects:  registry.Save,
 	}
 }
 
@@ -104,66 +97,47 @@ func (c syncCommand) run(ctx context.Context, args []string, console *ui.UI, log
 
 	logger.Info(fmt.Sprintf("Scanning for .justvibin files in %s...", scanPath))
 
-	if err := c.saveProjects(projectsPath, map[string]registry.Project{}); err != nil {
-		logger.Error("Failed to clear registry")
-		return 1
-	}
-
+	found := map[string]registry.Project{}
 	count := 0
-	skipDirs := map[string]bool{
-		".git":         true,
-		"node_modules": true,
-		".venv":        true,
-		"venv":         true,
-		"__pycache__":  true,
-		".config":      true,
-		"Library":      true,
-		".Trash":       true,
+	err = registry.ScanMarkers(scanPath, func(projectDir string, marker registry.Marker) {
+		found[marker.Name] = registry.Project{Port: marker.Port, Path: projectDir, Template: marker.Template, Aliases: marker.Aliases}
+		logger.Success(fmt.Sprintf("Found: %s (%s)", marker.Name, projectDir))
+		count++
+	})
+	if err != nil {
+		logger.Warn(fmt.Sprintf("Scan error: %v", err))
 	}
 
-	err = filepath.WalkDir(scanPath, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return nil
-		}
-		if d.IsDir() {
-			if skipDirs[d.Name()] {
-				return filepath.SkipDir
+	err = c.update(projectsPath, func(projects map[string]registry.Project) error {
+		now := time.Now().UTC().Format(time.RFC3339)
+		for name, project := range found {
+			if existing, ok := projects[name]; ok && existing.Path == project.Path {
+				existing.Port = project.Port
+				existing.Template = project.Template
+				existing.Aliases = project.Aliases
+				project = existing
 			}
-			return nil
-		}
-		if d.Name() != ".justvibin" {
-			return nil
-		}
-		if count >= 100 {
-			return filepath.SkipAll
-		}
-
-		projectDir := filepath.Dir(path)
-		marker, err := registry.ReadMarker(projectDir)
-		if err != nil {
-			return nil
-		}
-		if marker.Name == "" || marker.Port == 0 {
-			return nil
-		}
-
-		if c.register != nil {
-			if _, err := c.register(projectsPath, marker.Name, marker.Port, projectDir, marker.Template); err != nil {
-				return nil
+			if project.Created == "" {
+				project.Created = now
 			}
+			found[name] = project
+		}
+		clear(projects)
+		for name, project := range found {
+			projects[name] = project
 		}
-		logger.Success(fmt.Sprintf("Found: %s (%s)", marker.Name, projectDir))
-		count++
 		return nil
 	})
-	if err != nil && err != filepath.SkipAll {
-		logger.Warn(fmt.Sprintf("Scan error: %v", err))
+	if err != nil {
+		logRegistryError(logger, err, "Failed to update registry")
+		return 1
 	}
 
+	live := false
 	if c.generateCaddy != nil {
-		_ = c.generateCaddy(ctx, c.runner, projectsPath, caddyfilePath)
+		live, _ = c.generateCaddy(ctx, c.runner, projectsPath, caddyfilePath)
 	}
-	if c.reloadProxy != nil {
+	if c.reloadProxy != nil && !live {
 		_ = c.reloadProxy(ctx, c.runner, caddyfilePath)
 	}
 
@@ -174,37 +148,27 @@ func (c syncCommand) run(ctx context.Context, args []string, console *ui.UI, log
 func (c syncCommand) runClean(ctx context.Context, projectsPath, caddyfilePath string, logger *logging.Logger) int {
 	logger.Info("Cleaning stale registry entries...")
 
-	projects, err := c.loadProjects(projectsPath)
-	if err != nil {
-		logger.Error("Failed to load projects")
-		return 1
-	}
-
 	removed := 0
-	for name, project := range projects {
-		if _, err := os.Stat(project.Path); os.IsNotExist(err) {
-			if c.unregister != nil {
-				if _, err := c.unregister(projectsPath, name); err == nil {
-					logger.Warn(fmt.Sprintf("Removing stale: %s (%s)", name, project.Path))
-					removed++
-				}
-			}
-			continue
-		}
-		if !registry.MarkerExists(project.Path) {
-			if c.unregister != nil {
-				if _, err := c.unregister(projectsPath, name); err == nil {
-					logger.Warn(fmt.Sprintf("Removing stale: %s (%s)", name, project.Path))
-					removed++
-				}
+	err := c.update(projectsPath, func(projects map[string]registry.Project) error {
+		for name, project := range projects {
+			if _, err := os.Stat(project.Path); os.IsNotExist(err) || !registry.MarkerExists(project.Path) {
+				delete(projects, name)
+				logger.Warn(fmt.Sprintf("Removing stale: %s (%s)", name, project.Path))
+				removed++
 			}
 		}
+		return nil
+	})
+	if err != nil {
+		logRegistryError(logger, err, "Failed to load projects")
+		return 1
 	}
 
+	live := false
 	if c.generateCaddy != nil {
-		_ = c.generateCaddy(ctx, c.runner, projectsPath, caddyfilePath)
+		live, _ = c.generateCaddy(ctx, c.runner, projectsPath, caddyfilePath)
 	}
-	if c.reloadProxy != nil {
+	if c.reloadProxy != nil && !live {
 		_ = c.reloadProxy(ctx, c.runner, caddyfilePath)
 	}
 
@@ -53,7 +53,7 @@ func runTunnelCmd(cmd *cobra.Command, args []string) error {
 		}
 		project, ok, err := registry.Get(projectsPath, projectName)
 		if err != nil {
-			logger.Error("Failed to load project registry")
+			logRegistryError(logger, err, "Failed to load project registry")
 			return errors.New("tunnel command failed")
 		}
 		if !ok {
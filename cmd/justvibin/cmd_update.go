@@ -11,8 +11,10 @@ import (
 var updateCmd = &cobra.Command{
 	Use:   "update <template-name>",
 	Short: "Update installed templates from their source repositories",
-	Long:  "Re-fetch a template from its original git source URL. Use --all to update all templates at once. Requires git to be installed and the template to have a valid .source file.",
+	Long:  "Re-fetch a template from its original git source URL. Use --all to update all templates at once. Templates installed at a ref are re-fetched at that ref; use --to to move to another tag, branch or commit. The new version is validated before it replaces the installed one, and the replaced version is kept for --rollback. Requires git to be installed and the template to have a valid .source file.",
 	Example: `justvibin update hypertext    # Update specific template
+justvibin update hypertext --to v2.0.0
+justvibin update --rollback hypertext  # Restore the version before the last update
 justvibin update --all        # Update all installed templates`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runUpdateCmd,
@@ -20,6 +22,8 @@ justvibin update --all        # Update all installed templates`,
 
 func init() {
 	updateCmd.Flags().Bool("all", false, "Update all installed templates")
+	updateCmd.Flags().String("to", "", "Tag, branch or commit to update to")
+	updateCmd.Flags().Bool("rollback", false, "Restore the version replaced by the last update")
 	rootCmd.AddCommand(updateCmd)
 }
 
@@ -31,6 +35,8 @@ func runUpdateCmd(cmd *cobra.Command, args []string) error {
 	logger.SetVerbose(output.Verbose)
 
 	updateAll, _ := cmd.Flags().GetBool("all")
+	ref, _ := cmd.Flags().GetString("to")
+	rollback, _ := cmd.Flags().GetBool("rollback")
 
 	if !updateAll && len(args) == 0 {
 		logger.Error("Missing template name")
@@ -41,10 +47,16 @@ func runUpdateCmd(cmd *cobra.Command, args []string) error {
 
 	cmdImpl := updateCommandFactory()
 
-	argsToRun := make([]string, 0, 2)
+	argsToRun := make([]string, 0, 5)
 	if updateAll {
 		argsToRun = append(argsToRun, "--all")
 	}
+	if ref != "" {
+		argsToRun = append(argsToRun, "--to", ref)
+	}
+	if rollback {
+		argsToRun = append(argsToRun, "--rollback")
+	}
 	if len(args) > 0 {
 		argsToRun = append(argsToRun, args[0])
 	}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/alexcabrera/justvibin/internal/logging"
+	"github.com/spf13/cobra"
+)
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Bring template changes into the current project",
+	Long:  "Merge what changed in the project's template, between the revision the project was created or last upgraded from and the installed one, into the project. Files you have not touched are updated, edited files are merged line by line, and conflicts are left with conflict markers and reported. Run 'justvibin update <template>' first to fetch the latest template.",
+	Example: `justvibin upgrade --dry-run
+justvibin upgrade`,
+	Args: cobra.NoArgs,
+	RunE: runUpgradeCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(upgradeCmd)
+	upgradeCmd.Flags().Bool("dry-run", false, "Show what would change without writing files")
+}
+
+func runUpgradeCmd(cmd *cobra.Command, _ []string) error {
+	output := getOutputSettings(cmd)
+	logger := logging.New(cmd.OutOrStdout(), cmd.ErrOrStderr(), output.Styled)
+	logger.SetSilent(output.Quiet)
+	logger.SetVerbose(output.Verbose)
+
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		logger.Error("Failed to get current directory")
+		return errors.New("upgrade command failed")
+	}
+	impl := upgradeCommandFactory()
+	if code := impl.run(context.Background(), cwd, dryRun, logger); code != 0 {
+		return errors.New("upgrade command failed")
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexcabrera/justvibin/internal/logging"
+	"github.com/alexcabrera/justvibin/internal/registry"
+)
+
+func TestDomainAddRemoveList(t *testing.T) {
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	logger := logging.New(stdout, stderr, false)
+	projectDir := t.TempDir()
+	projectsPath := filepath.Join(t.TempDir(), "projects.json")
+	if _, err := registry.Register(projectsPath, "myapp", 3000, projectDir, "hypertext"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if _, err := registry.WriteMarker(projectDir, "myapp", "hypertext", 3000); err != nil {
+		t.Fatalf("marker: %v", err)
+	}
+
+	regenerated := 0
+	cmd := defaultDomainCommand()
+	cmd.projectsFile = func() (string, error) { return projectsPath, nil }
+	cmd.caddyfilePath = func() (string, error) { return "/tmp/Caddyfile", nil }
+	cmd.generateCaddy = func(context.Context, string, string) (bool, error) {
+		regenerated++
+		return false, nil
+	}
+	cmd.reloadProxy = func(context.Context, string) error { return nil }
+	ctx := context.Background()
+
+	for _, host := range []string{"*.myapp.localhost", "MyApp.test"} {
+		if code := cmd.run(ctx, projectDir, "add", []string{host}, logger); code != 0 {
+			t.Fatalf("add %s: expected exit 0, got %d: %s", host, code, stderr.String())
+		}
+	}
+	if !strings.Contains(stdout.String(), "127.0.0.1 myapp.test") {
+		t.Fatalf("expected /etc/hosts hint, got %q", stdout.String())
+	}
+	marker, _ := registry.ReadMarker(projectDir)
+	if strings.Join(marker.Aliases, ",") != "*.myapp.localhost,myapp.test" || regenerated != 2 {
+		t.Fatalf("expected marker aliases and proxy update, got %v (%d)", marker.Aliases, regenerated)
+	}
+
+	if code := cmd.run(ctx, projectDir, "remove", []string{"myapp.test"}, logger); code != 0 {
+		t.Fatalf("remove: expected exit 0, got %d: %s", code, stderr.String())
+	}
+	if code := cmd.run(ctx, projectDir, "remove", []string{"myapp.test"}, logger); code == 0 {
+		t.Fatalf("expected removing a missing domain to fail")
+	}
+	stdout.Reset()
+	if code := cmd.run(ctx, projectDir, "list", nil, logger); code != 0 {
+		t.Fatalf("list: expected exit 0, got %d", code)
+	}
+	if output := stdout.String(); !strings.Contains(output, "https://myapp.localhost") || !strings.Contains(output, "https://*.myapp.localhost") || strings.Contains(output, "myapp.test") {
+		t.Fatalf("unexpected list output %q", output)
+	}
+}
@@ -6,15 +6,22 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/alexcabrera/justvibin/internal/config"
 	execx "github.com/alexcabrera/justvibin/internal/exec"
+	"github.com/alexcabrera/justvibin/internal/index"
 	"github.com/alexcabrera/justvibin/internal/logging"
 	"github.com/alexcabrera/justvibin/internal/manifest"
 	"github.com/alexcabrera/justvibin/internal/ui"
+	"github.com/alexcabrera/justvibin/internal/version"
 )
 
+// indexNamePattern matches a template name, optionally with an @ref, as
+// opposed to a git URL or path.
+var indexNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*(@[^@:/]+)?$`)
+
 type installCommand struct {
 	runner      execx.Runner
 	readFile    func(string) ([]byte, error)
@@ -23,6 +30,7 @@ type installCommand struct {
 	tempDir     func(string, string) (string, error)
 	rename      func(string, string) error
 	templatesDir func() (string, error)
+	loadIndex   func(ctx context.Context, logger *logging.Logger) (index.Index, error)
 	spin        func(message string, work func() error) error
 }
 
@@ -35,6 +43,7 @@ func defaultInstallCommand() installCommand {
 		tempDir:      os.MkdirTemp,
 		rename:       os.Rename,
 		templatesDir: config.TemplatesDir,
+		loadIndex:    loadTemplateIndex,
 	}
 }
 
@@ -84,6 +93,15 @@ func (c installCommand) run(ctx context.Context, args []string, console *ui.UI,
 		logger.Error("Missing template git URL")
 		return 1
 	}
+	source := templateSource{}
+	source.URL, source.Ref = splitSourceRef(url)
+	if indexNamePattern.MatchString(url) {
+		resolved, ok := c.resolveIndexName(ctx, url, logger)
+		if !ok {
+			return 1
+		}
+		source = resolved
+	}
 	if !execx.CommandAvailable(c.runner, "git") {
 		logger.Error("git is required to install templates")
 		return 1
@@ -112,7 +130,9 @@ func (c installCommand) run(ctx context.Context, args []string, console *ui.UI,
 	}
 
 	if err := spin("Cloning template", func() error {
-		return c.runner.Run(ctx, "git", "clone", "--depth", "1", url, tmpDir)
+		commit, err := cloneTemplate(ctx, c.runner, source, tmpDir)
+		source.Commit = commit
+		return err
 	}); err != nil {
 		logger.Error(err.Error())
 		return 1
@@ -124,11 +144,14 @@ func (c installCommand) run(ctx context.Context, args []string, console *ui.UI,
 		logger.Error("Template missing justvibin.toml manifest")
 		return 1
 	}
-	parsedManifest, err := manifest.Parse(manifestData)
+	parsedManifest, warnings, err := manifest.ParseStrict(manifestData)
 	if err != nil {
-		logger.Error(err.Error())
+		logger.Error(fmt.Sprintf("Invalid justvibin.toml: %v", err))
 		return 1
 	}
+	for _, warning := range warnings {
+		logger.Warn(fmt.Sprintf("justvibin.toml: %s", warning))
+	}
 	if err := manifest.Validate(parsedManifest); err != nil {
 		logger.Error(err.Error())
 		return 1
@@ -156,16 +179,46 @@ func (c installCommand) run(ctx context.Context, args []string, console *ui.UI,
 	}
 	tmpDir = ""
 
-	sourcePath := filepath.Join(target, ".source")
-	if err := c.writeFile(sourcePath, []byte(url), 0644); err != nil {
+	sourcePath := filepath.Join(target, sourceFileName)
+	if err := c.writeFile(sourcePath, source.encode(), 0644); err != nil {
 		logger.Error("Failed to write template source")
 		return 1
 	}
 
+	if source.Commit != "" {
+		logger.Success(fmt.Sprintf("Installed template: %s (%s)", name, shortCommit(source.Commit)))
+		return 0
+	}
 	logger.Success(fmt.Sprintf("Installed template: %s", name))
 	return 0
 }
 
+// resolveIndexName looks up a template name, with an optional @ref, in the
+// template index and returns the source to clone.
+func (c installCommand) resolveIndexName(ctx context.Context, arg string, logger *logging.Logger) (templateSource, bool) {
+	name, ref, _ := strings.Cut(arg, "@")
+	loadIndex := c.loadIndex
+	if loadIndex == nil {
+		loadIndex = loadTemplateIndex
+	}
+	idx, err := loadIndex(ctx, logger)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to load template index: %v", err))
+		return templateSource{}, false
+	}
+	entry, ok := idx.Find(name)
+	if !ok {
+		logger.Error(fmt.Sprintf("Template '%s' not found in the index", name))
+		logger.Info("Run 'justvibin search' to list available templates")
+		return templateSource{}, false
+	}
+	if !entry.Supports(version.Version) {
+		logger.Error(fmt.Sprintf("Template '%s' requires justvibin %s or newer (this is %s)", name, entry.MinVersion, version.Version))
+		return templateSource{}, false
+	}
+	return templateSource{URL: entry.URL, Ref: ref}, true
+}
+
 func officialTemplatesText(styled bool) string {
 	return availableTemplatesText(config.DefaultTemplates(), config.DefaultTemplatesPath, styled)
 }
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	execx "github.com/alexcabrera/justvibin/internal/exec"
+	"github.com/alexcabrera/justvibin/internal/index"
 	"github.com/alexcabrera/justvibin/internal/logging"
 	"github.com/alexcabrera/justvibin/internal/manifest"
 	"github.com/alexcabrera/justvibin/internal/ui"
@@ -95,6 +96,41 @@ func TestInstallCommandWritesSource(t *testing.T) {
 	}
 }
 
+func TestInstallCommandPinsRef(t *testing.T) {
+	stdout := &strings.Builder{}
+	logger := logging.New(stdout, &strings.Builder{}, false)
+	templatesDir := t.TempDir()
+	var calls []string
+	cmd := defaultInstallCommand()
+	cmd.runner = gitRunner{calls: &calls, commit: "3f9a1c2b4d5e"}
+	cmd.templatesDir = func() (string, error) { return templatesDir, nil }
+	cmd.tempDir = func(_, _ string) (string, error) { return t.TempDir(), nil }
+	cmd.readFile = func(string) ([]byte, error) {
+		return []byte("[template]\nname = \"hypertext\"\ndescription = \"desc\"\n\n[serve]\ntype = \"static\"\n"), nil
+	}
+	cmd.rename = func(_, newPath string) error { return os.MkdirAll(newPath, 0755) }
+	cmd.removeAll = func(string) error { return nil }
+
+	code := cmd.run(context.Background(), []string{"https://example.com/repo.git@v1.2.0"}, ui.New(&strings.Builder{}, &strings.Builder{}, false), logger, false)
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d", code)
+	}
+	if !strings.Contains(calls[0], "clone --depth 1 --branch v1.2.0 https://example.com/repo.git") {
+		t.Fatalf("expected clone of v1.2.0, got %v", calls)
+	}
+	data, err := os.ReadFile(filepath.Join(templatesDir, "hypertext", ".source"))
+	if err != nil {
+		t.Fatalf("read source: %v", err)
+	}
+	want := templateSource{URL: "https://example.com/repo.git", Ref: "v1.2.0", Commit: "3f9a1c2b4d5e"}
+	if got := parseTemplateSource(data); got != want {
+		t.Fatalf("expected %#v, got %#v", want, got)
+	}
+	if !strings.Contains(stdout.String(), "Installed template: hypertext (3f9a1c2)") {
+		t.Fatalf("expected commit in output, got %q", stdout.String())
+	}
+}
+
 func TestInstallCommandListOfficial(t *testing.T) {
 	stdout := &strings.Builder{}
 	stderr := &strings.Builder{}
@@ -252,5 +288,80 @@ func TestInstallCommandUsesManifestValidation(t *testing.T) {
 	}
 }
 
+func TestInstallCommandWarnsOnUnknownManifestKeys(t *testing.T) {
+	stdout := &strings.Builder{}
+	logger := logging.New(stdout, &strings.Builder{}, false)
+	cmd := defaultInstallCommand()
+	cmd.runner = installRunner{}
+	cmd.templatesDir = func() (string, error) { return t.TempDir(), nil }
+	cmd.tempDir = func(_, _ string) (string, error) { return t.TempDir(), nil }
+	cmd.readFile = func(string) ([]byte, error) {
+		return []byte("[template]\nname=\"valid\"\ndescription=\"desc\"\n[serve]\ntype=\"static\"\nport_evn=\"PORT\"\n"), nil
+	}
+	cmd.rename = func(oldPath, newPath string) error {
+		return os.MkdirAll(newPath, 0755)
+	}
+	cmd.removeAll = func(string) error { return nil }
+	cmd.writeFile = os.WriteFile
+
+	code := cmd.run(context.Background(), []string{"https://example.com/repo.git"}, ui.New(&strings.Builder{}, &strings.Builder{}, false), logger, false)
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), "serve.port_evn") {
+		t.Fatalf("expected unknown key warning, got %q", stdout.String())
+	}
+}
+
 var _ = execx.Runner(installRunner{})
 var _ = manifest.Manifest{}
+
+func TestInstallCommandResolvesIndexName(t *testing.T) {
+	templatesDir := t.TempDir()
+	var calls []string
+	cmd := defaultInstallCommand()
+	cmd.runner = gitRunner{calls: &calls, commit: "3f9a1c2b4d5e"}
+	cmd.templatesDir = func() (string, error) { return templatesDir, nil }
+	cmd.tempDir = func(_, _ string) (string, error) { return t.TempDir(), nil }
+	cmd.readFile = func(string) ([]byte, error) {
+		return []byte("[template]\nname = \"htmx-go\"\ndescription = \"desc\"\n\n[serve]\ntype = \"static\"\n"), nil
+	}
+	cmd.rename = func(_, newPath string) error { return os.MkdirAll(newPath, 0755) }
+	cmd.removeAll = func(string) error { return nil }
+	cmd.loadIndex = func(context.Context, *logging.Logger) (index.Index, error) {
+		return index.Index{Templates: []index.Entry{{Name: "htmx-go", URL: "https://example.com/htmx-go.git"}}}, nil
+	}
+
+	code := cmd.run(context.Background(), []string{"htmx-go@v2"}, ui.New(&strings.Builder{}, &strings.Builder{}, false), logging.New(&strings.Builder{}, &strings.Builder{}, false), false)
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d", code)
+	}
+	if !strings.Contains(calls[0], "clone --depth 1 --branch v2 https://example.com/htmx-go.git") {
+		t.Fatalf("expected clone of the indexed URL, got %v", calls)
+	}
+}
+
+func TestInstallCommandIndexNameErrors(t *testing.T) {
+	cases := []struct {
+		arg  string
+		want string
+	}{
+		{"missing", "Template 'missing' not found in the index"},
+		{"future", "Template 'future' requires justvibin 99.0 or newer"},
+	}
+	for _, tc := range cases {
+		stderr := &strings.Builder{}
+		cmd := defaultInstallCommand()
+		cmd.runner = installRunner{}
+		cmd.loadIndex = func(context.Context, *logging.Logger) (index.Index, error) {
+			return index.Index{Templates: []index.Entry{{Name: "future", URL: "https://example.com/future.git", MinVersion: "99.0"}}}, nil
+		}
+		code := cmd.run(context.Background(), []string{tc.arg}, ui.New(&strings.Builder{}, &strings.Builder{}, false), logging.New(&strings.Builder{}, stderr, false), false)
+		if code != 1 {
+			t.Fatalf("%s: expected exit 1, got %d", tc.arg, code)
+		}
+		if !strings.Contains(stderr.String(), tc.want) {
+			t.Fatalf("%s: expected %q, got %q", tc.arg, tc.want, stderr.String())
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexcabrera/justvibin/internal/logging"
+	"github.com/alexcabrera/justvibin/internal/registry"
+)
+
+func writeLogsFixture(t *testing.T, contents string) string {
+	t.Helper()
+	baseDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", baseDir)
+
+	projectsPath := filepath.Join(baseDir, "justvibin", "projects.json")
+	if err := os.MkdirAll(filepath.Dir(projectsPath), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	projects := map[string]registry.Project{
+		"myapp": {Port: 59999, Path: t.TempDir(), Template: "hypertext"},
+	}
+	if err := registry.Save(projectsPath, projects); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	logPath := filepath.Join(baseDir, "justvibin", "logs", "myapp.log")
+	if contents != "" {
+		if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(logPath, []byte(contents), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	return logPath
+}
+
+func TestLogsCmdShowsRecentLines(t *testing.T) {
+	writeLogsFixture(t, "2024-01-01T00:00:00Z one\n2024-01-01T00:05:00Z two\n2024-01-01T00:10:00Z three\n")
+
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	logger := logging.New(stdout, stderr, false)
+	cmd := defaultLogsCommand()
+	cmd.stdout = stdout
+	cmd.now = func() time.Time { return time.Date(2024, 1, 1, 0, 12, 0, 0, time.UTC) }
+	code := cmd.run(context.Background(), []string{"myapp"}, logger, logsOptions{Since: "10m", Lines: 1})
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	if stdout.String() != "2024-01-01T00:10:00Z three\n" {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+}
+
+func TestLogsCmdNoLogsYet(t *testing.T) {
+	writeLogsFixture(t, "")
+
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	resetRootFlags(t)
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(stderr)
+	rootCmd.SetArgs([]string{"logs", "myapp"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("expected exit 0, got %v", err)
+	}
+	if !strings.Contains(stdout.String(), "No logs for 'myapp'") {
+		t.Fatalf("expected no logs message, got %q", stdout.String())
+	}
+}
+
+func TestLogsCmdRejectsBadSince(t *testing.T) {
+	writeLogsFixture(t, "")
+
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	logger := logging.New(stdout, stderr, false)
+	cmd := defaultLogsCommand()
+	code := cmd.run(context.Background(), []string{"myapp"}, logger, logsOptions{Since: "yesterday"})
+	if code != 1 {
+		t.Fatalf("expected exit 1")
+	}
+}
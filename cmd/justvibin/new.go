@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"maps"
 	"os"
 	osexec "os/exec"
 	"path/filepath"
@@ -36,43 +37,47 @@ type pluginTemplate struct {
 }
 
 type newCommand struct {
-	runner       execx.Runner
-	copyDir      func(src, dst string) error
-	removeGitDir func(path string) error
-	isExecutable func(path string) (bool, error)
-	templatesDir func() (string, error)
-	readDir      func(string) ([]os.DirEntry, error)
-	readFile     func(string) ([]byte, error)
-	writeMarker  func(projectDir, name, template string, port int) (registry.Marker, error)
-	migrateSrv   func(projectDir string) (registry.Marker, bool, error)
-	register     func(path, name string, port int, projectPath, template string) (registry.Project, error)
-	nextPort     func(path string) (int, error)
-	projectsFile func() (string, error)
-	caddyfilePath func() (string, error)
-	generateCaddy func(context.Context, execx.Runner, string, string) error
-	reloadProxy   func(context.Context, execx.Runner, string) error
-	spin         func(message string, work func() error) error
+	runner         execx.Runner
+	copyDir        func(src, dst string) error
+	removeGitDir   func(path string) error
+	isExecutable   func(path string) (bool, error)
+	templatesDir   func() (string, error)
+	readDir        func(string) ([]os.DirEntry, error)
+	readFile       func(string) ([]byte, error)
+	writeMarker    func(projectDir, name, template string, port int) (registry.Marker, error)
+	recordTemplate func(projectDir, version, commit string, variables map[string]string) (registry.Marker, error)
+	migrateSrv     func(projectDir string) (registry.Marker, bool, error)
+	register       func(path, name, projectPath, template string) (registry.Project, error)
+	unregister     func(path, name string) (bool, error)
+	projectsFile   func() (string, error)
+	caddyfilePath  func() (string, error)
+	generateCaddy  func(context.Context, execx.Runner, string, string) (bool, error)
+	reloadProxy    func(context.Context, execx.Runner, string) error
+	spin           func(message string, work func() error) error
+	prompt         variablePrompt
 }
 
 var newCommandFactory = defaultNewCommand
 
 func defaultNewCommand() newCommand {
 	return newCommand{
-		runner:        execx.NewSystemRunner(),
-		copyDir:       fsutil.CopyDir,
-		removeGitDir:  fsutil.RemoveGitDir,
-		isExecutable:  fsutil.IsExecutable,
-		templatesDir:  config.TemplatesDir,
-		readDir:       os.ReadDir,
-		readFile:      os.ReadFile,
-		writeMarker:   registry.WriteMarker,
-		migrateSrv:    registry.MigrateSrvMarker,
-		register:      registry.Register,
-		nextPort:      registry.NextPort,
-		projectsFile:  config.ProjectsFile,
-		caddyfilePath: config.CaddyfilePath,
-		generateCaddy: proxy.GenerateCaddyfile,
-		reloadProxy:   proxy.ReloadProxy,
+		runner:         execx.NewSystemRunner(),
+		copyDir:        fsutil.CopyDir,
+		removeGitDir:   fsutil.RemoveGitDir,
+		isExecutable:   fsutil.IsExecutable,
+		templatesDir:   config.TemplatesDir,
+		readDir:        os.ReadDir,
+		readFile:       os.ReadFile,
+		writeMarker:    registry.WriteMarker,
+		recordTemplate: registry.UpdateMarkerTemplate,
+		migrateSrv:     registry.MigrateSrvMarker,
+		register:       registry.RegisterNext,
+		unregister:     registry.Unregister,
+		projectsFile:   config.ProjectsFile,
+		caddyfilePath:  config.CaddyfilePath,
+		generateCaddy:  proxy.GenerateCaddyfile,
+		reloadProxy:    proxy.ReloadProxy,
+		prompt:         promptVariable,
 	}
 }
 
@@ -91,6 +96,7 @@ func (c newCommand) run(ctx context.Context, args []string, console *ui.UI, logg
 	projectName := ""
 	localPath := ""
 	templateName := ""
+	given := map[string]string{}
 
 	for i := 0; i < len(args); i++ {
 		arg := args[i]
@@ -109,6 +115,18 @@ func (c newCommand) run(ctx context.Context, args []string, console *ui.UI, logg
 			}
 			templateName = args[i+1]
 			i++
+		case "--var":
+			if i+1 >= len(args) {
+				logger.Error("Missing value for --var")
+				return 1
+			}
+			key, value, err := parseVarFlag(args[i+1])
+			if err != nil {
+				logger.Error(err.Error())
+				return 1
+			}
+			given[key] = value
+			i++
 		default:
 			if strings.HasPrefix(arg, "-") {
 				logger.Error(fmt.Sprintf("Unknown option: %s", arg))
@@ -154,6 +172,9 @@ func (c newCommand) run(ctx context.Context, args []string, console *ui.UI, logg
 	}
 
 	scaffold := manifest.Scaffold{}
+	var variables map[string]manifest.Variable
+	templateVersion := ""
+	templateCommit := ""
 
 	if localPath == "" {
 		templatesDir, err := c.templatesDir()
@@ -189,9 +210,39 @@ func (c newCommand) run(ctx context.Context, args []string, console *ui.UI, logg
 			return 1
 		}
 		scaffold = match.Manifest.Scaffold
+		variables = match.Manifest.Variables
+		templateVersion = match.Manifest.Template.Version
+		if data, err := c.readFile(filepath.Join(match.Path, sourceFileName)); err == nil {
+			templateCommit = parseTemplateSource(data).Commit
+		}
 		localPath = match.Path
-	} else if templateName == "" {
-		templateName = filepath.Base(localPath)
+	} else {
+		if templateName == "" {
+			templateName = filepath.Base(localPath)
+		}
+		manifestPath := filepath.Join(localPath, "justvibin.toml")
+		if data, err := c.readFile(manifestPath); err == nil {
+			parsed, err := manifest.Parse(data)
+			if err == nil {
+				err = manifest.Validate(parsed)
+			}
+			if err != nil {
+				logger.Error(fmt.Sprintf("Invalid template manifest %s: %v", manifestPath, err))
+				return 1
+			}
+			scaffold = parsed.Scaffold
+			variables = parsed.Variables
+			templateVersion = parsed.Template.Version
+		} else if !errors.Is(err, os.ErrNotExist) {
+			logger.Error(fmt.Sprintf("Failed to read %s", manifestPath))
+			return 1
+		}
+	}
+
+	values, err := resolveVariables(variables, given, map[string]string{"project_name": projectName}, interactive, c.prompt)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Invalid template variables: %v", err))
+		return 1
 	}
 
 	logger.Info(fmt.Sprintf("Creating project: %s", projectName))
@@ -242,21 +293,33 @@ func (c newCommand) run(ctx context.Context, args []string, console *ui.UI, logg
 		logger.Error("Failed to resolve projects registry")
 		return 1
 	}
-	port, err := c.nextPort(projectsPath)
-	if err != nil {
-		logger.Error("Failed to assign project port")
-		return 1
-	}
 	fullPath, err := filepath.Abs(projectName)
 	if err != nil {
 		logger.Error("Failed to resolve project path")
 		return 1
 	}
+	port := 0
 	if c.register != nil {
-		if _, err := c.register(projectsPath, projectName, port, fullPath, templateName); err != nil {
-			logger.Error("Failed to register project")
+		project, err := c.register(projectsPath, projectName, fullPath, templateName)
+		if err != nil {
+			logRegistryError(logger, err, "Failed to register project")
 			return 1
 		}
+		port = project.Port
+	}
+	rendered := maps.Clone(values)
+	rendered["project_name"] = projectName
+	rendered["port"] = strconv.Itoa(port)
+	// The port comes from registering, so rendering has to follow it; a
+	// project that fails to render is taken out of the registry again.
+	if err := renderProject(projectName, rendered); err != nil {
+		logger.Error(fmt.Sprintf("Failed to render template variables: %v", err))
+		if c.register != nil && c.unregister != nil {
+			if _, err := c.unregister(projectsPath, projectName); err != nil {
+				logRegistryError(logger, err, "Failed to unregister project")
+			}
+		}
+		return 1
 	}
 	if c.writeMarker != nil {
 		if _, err := c.writeMarker(projectName, projectName, templateName, port); err != nil {
@@ -264,6 +327,12 @@ func (c newCommand) run(ctx context.Context, args []string, console *ui.UI, logg
 			return 1
 		}
 	}
+	if c.recordTemplate != nil && (templateVersion != "" || templateCommit != "" || len(values) > 0) {
+		if _, err := c.recordTemplate(projectName, templateVersion, templateCommit, values); err != nil {
+			logger.Error("Failed to record template revision in .justvibin marker")
+			return 1
+		}
+	}
 	if c.migrateSrv != nil {
 		if _, migrated, err := c.migrateSrv(projectName); err != nil {
 			logger.Error("Failed to migrate .srv marker")
@@ -278,13 +347,14 @@ func (c newCommand) run(ctx context.Context, args []string, console *ui.UI, logg
 		logger.Error("Failed to resolve Caddyfile path")
 		return 1
 	}
+	live := false
 	if c.generateCaddy != nil {
-		if err := c.generateCaddy(ctx, c.runner, projectsPath, caddyfilePath); err != nil {
+		if live, err = c.generateCaddy(ctx, c.runner, projectsPath, caddyfilePath); err != nil {
 			logger.Error("Failed to generate Caddyfile")
 			return 1
 		}
 	}
-	if c.reloadProxy != nil {
+	if c.reloadProxy != nil && !live {
 		if err := c.reloadProxy(ctx, c.runner, caddyfilePath); err != nil {
 			logger.Error("Failed to reload proxy")
 			return 1
@@ -315,7 +385,7 @@ func loadPluginTemplates(templatesDir string, readDir func(string) ([]os.DirEntr
 	}
 	templates := make([]pluginTemplate, 0, len(entries))
 	for _, entry := range entries {
-		if !entry.IsDir() {
+		if !isTemplateDir(entry) {
 			continue
 		}
 		path := filepath.Join(templatesDir, entry.Name())
@@ -326,7 +396,7 @@ func loadPluginTemplates(templatesDir string, readDir func(string) ([]os.DirEntr
 		}
 		parsed, err := manifest.Parse(data)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%s: %w", manifestPath, err)
 		}
 		if err := manifest.Validate(parsed); err != nil {
 			return nil, err
@@ -409,22 +479,6 @@ func normalizeExcludes(excludes []string) []string {
 	return combined
 }
 
-func shouldExclude(rel string, excludes []string) bool {
-	rel = filepath.Clean(rel)
-	for _, pattern := range excludes {
-		if rel == pattern {
-			return true
-		}
-		if strings.HasPrefix(rel, pattern+string(os.PathSeparator)) {
-			return true
-		}
-		if match, _ := filepath.Match(pattern, filepath.Base(rel)); match {
-			return true
-		}
-	}
-	return false
-}
-
 func copyTemplate(src, dst string, excludes []string) error {
 	info, err := os.Stat(src)
 	if err != nil {
@@ -447,7 +501,7 @@ func copyTemplate(src, dst string, excludes []string) error {
 		if rel == "." {
 			return nil
 		}
-		if shouldExclude(rel, excludes) {
+		if fsutil.Match(rel, excludes) {
 			if d.IsDir() {
 				return fs.SkipDir
 			}
@@ -9,6 +9,7 @@ import (
 
 	execx "github.com/alexcabrera/justvibin/internal/exec"
 	"github.com/alexcabrera/justvibin/internal/logging"
+	"github.com/alexcabrera/justvibin/internal/manifest"
 	"github.com/alexcabrera/justvibin/internal/registry"
 	"github.com/alexcabrera/justvibin/internal/ui"
 )
@@ -176,13 +177,15 @@ func newTestCommand(t *testing.T) newCommand {
 	cmd := defaultNewCommand()
 	cmd.runner = &fakeRunner{}
 	cmd.removeGitDir = func(string) error { return nil }
-	cmd.nextPort = func(string) (int, error) { return 4000, nil }
 	cmd.projectsFile = func() (string, error) { return filepath.Join(t.TempDir(), "projects.json"), nil }
 	cmd.caddyfilePath = func() (string, error) { return filepath.Join(t.TempDir(), "Caddyfile"), nil }
-	cmd.generateCaddy = func(context.Context, execx.Runner, string, string) error { return nil }
+	cmd.generateCaddy = func(context.Context, execx.Runner, string, string) (bool, error) { return false, nil }
 	cmd.reloadProxy = func(context.Context, execx.Runner, string) error { return nil }
-	cmd.register = nil
+	cmd.register = func(path, name, projectPath, template string) (registry.Project, error) {
+		return registry.Project{Port: 4000, Path: projectPath, Template: template}, nil
+	}
 	cmd.writeMarker = nil
+	cmd.recordTemplate = nil
 	cmd.migrateSrv = nil
 	cmd.spin = func(_ string, work func() error) error { return work() }
 	return cmd
@@ -301,10 +304,13 @@ func TestNewCommandRegistersProject(t *testing.T) {
 	cmd := newTestCommand(t)
 	cmd.templatesDir = func() (string, error) { return templatesDir, nil }
 	cmd.projectsFile = func() (string, error) { return projectsPath, nil }
-	cmd.register = func(path, name string, port int, projectPath, template string) (registry.Project, error) {
+	cmd.register = func(path, name, projectPath, template string) (registry.Project, error) {
 		registeredName = name
+		return registry.Project{Port: 4000, Path: projectPath, Template: template}, nil
+	}
+	cmd.writeMarker = func(projectDir, name, template string, port int) (registry.Marker, error) {
 		registeredPort = port
-		return registry.Project{Port: port, Path: projectPath, Template: template}, nil
+		return registry.Marker{Name: name, Template: template, Port: port}, nil
 	}
 	code := cmd.run(context.Background(), []string{"proj", "--template", "alpha"}, ui.New(&strings.Builder{}, &strings.Builder{}, false), logger, false)
 	if code != 0 {
@@ -314,7 +320,35 @@ func TestNewCommandRegistersProject(t *testing.T) {
 		t.Fatalf("expected registered name 'proj', got %s", registeredName)
 	}
 	if registeredPort != 4000 {
-		t.Fatalf("expected registered port 4000, got %d", registeredPort)
+		t.Fatalf("expected marker port 4000, got %d", registeredPort)
+	}
+}
+
+func TestNewCommandUnregistersWhenRenderFails(t *testing.T) {
+	restore := withWorkDir(t)
+	defer restore()
+	stderr := &strings.Builder{}
+	logger := logging.New(&strings.Builder{}, stderr, false)
+	templatesDir := t.TempDir()
+	templateDir := writePluginTemplate(t, templatesDir, "alpha", "")
+	// Renaming {{project_name}}.txt collides with proj.txt.
+	for _, name := range []string{"{{project_name}}.txt", "proj.txt"} {
+		if err := os.WriteFile(filepath.Join(templateDir, name), []byte("x"), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	projectsPath := filepath.Join(t.TempDir(), "projects.json")
+	cmd := newTestCommand(t)
+	cmd.templatesDir = func() (string, error) { return templatesDir, nil }
+	cmd.projectsFile = func() (string, error) { return projectsPath, nil }
+	cmd.register = registry.RegisterNext
+
+	code := cmd.run(context.Background(), []string{"proj", "--template", "alpha"}, ui.New(&strings.Builder{}, &strings.Builder{}, false), logger, false)
+	if code != 1 || !strings.Contains(stderr.String(), "Failed to render template variables") {
+		t.Fatalf("expected render failure, got %d: %s", code, stderr.String())
+	}
+	if exists, err := registry.Exists(projectsPath, "proj"); err != nil || exists {
+		t.Fatalf("expected project unregistered, got %v (%v)", exists, err)
 	}
 }
 
@@ -370,3 +404,196 @@ func TestNewCommandPickerShownForMultipleTemplates(t *testing.T) {
 }
 
 var _ = execx.Runner(&fakeRunner{})
+
+func TestNewCommandRendersVariables(t *testing.T) {
+	restore := withWorkDir(t)
+	defer restore()
+	logger := logging.New(&strings.Builder{}, &strings.Builder{}, false)
+	templatesDir := t.TempDir()
+	templateDir := writePluginTemplate(t, templatesDir, "alpha", strings.Join([]string{
+		"[variables.db_name]",
+		"prompt = \"Database name\"",
+		"default = \"{{project_name}}_db\"",
+		"pattern = \"^[a-z_]+$\"",
+		"",
+		"[variables.author]",
+		"default = \"nobody\"",
+		"",
+	}, "\n"))
+	files := map[string]string{
+		"settings.py":                      "NAME = '{{project_name}}'\nPORT = {{port}}\nDB = '{{db_name}}'\nBY = '{{author}}'\n",
+		"page.html":                        "<h1>{{ title }}</h1> {{unknown}}",
+		"{{project_name}}/__init__.py":     "# {{project_name}}",
+		"{{project_name}}/{{db_name}}.sql": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(templateDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	binary := []byte("\x00{{project_name}}")
+	if err := os.WriteFile(filepath.Join(templateDir, "logo.png"), binary, 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	cmd := newTestCommand(t)
+	cmd.templatesDir = func() (string, error) { return templatesDir, nil }
+	code := cmd.run(context.Background(), []string{"shop", "--template", "alpha", "--var", "author=Ada"}, ui.New(&strings.Builder{}, &strings.Builder{}, false), logger, false)
+	if code != 0 {
+		t.Fatalf("expected exit 0")
+	}
+
+	data, err := os.ReadFile(filepath.Join("shop", "settings.py"))
+	if err != nil {
+		t.Fatalf("read settings: %v", err)
+	}
+	if want := "NAME = 'shop'\nPORT = 4000\nDB = 'shop_db'\nBY = 'Ada'\n"; string(data) != want {
+		t.Fatalf("expected %q, got %q", want, data)
+	}
+	if data, _ := os.ReadFile(filepath.Join("shop", "page.html")); string(data) != files["page.html"] {
+		t.Fatalf("expected other placeholders untouched, got %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join("shop", "shop", "__init__.py")); string(data) != "# shop" {
+		t.Fatalf("expected renamed package, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join("shop", "shop", "shop_db.sql")); err != nil {
+		t.Fatalf("expected renamed file: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join("shop", "logo.png")); string(data) != string(binary) {
+		t.Fatalf("expected binary file copied as is, got %q", data)
+	}
+}
+
+func TestNewCommandLocalRendersVariables(t *testing.T) {
+	restore := withWorkDir(t)
+	defer restore()
+	stderr := &strings.Builder{}
+	logger := logging.New(&strings.Builder{}, stderr, false)
+	local := writePluginTemplate(t, t.TempDir(), "local", strings.Join([]string{
+		"[scaffold]",
+		"exclude = [\"notes.md\"]",
+		"",
+		"[variables.author]",
+		"default = \"nobody\"",
+		"",
+	}, "\n"))
+	files := map[string]string{
+		"settings.py": "NAME = '{{project_name}}'\nBY = '{{author}}'\n",
+		"notes.md":    "scratch",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(local, name), []byte(content), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	cmd := newTestCommand(t)
+	code := cmd.run(context.Background(), []string{"shop", "--local", local, "--var", "author=Ada"}, ui.New(&strings.Builder{}, &strings.Builder{}, false), logger, false)
+	if code != 0 {
+		t.Fatalf("expected exit 0: %s", stderr)
+	}
+	data, err := os.ReadFile(filepath.Join("shop", "settings.py"))
+	if err != nil {
+		t.Fatalf("read settings: %v", err)
+	}
+	if want := "NAME = 'shop'\nBY = 'Ada'\n"; string(data) != want {
+		t.Fatalf("expected %q, got %q", want, data)
+	}
+	if _, err := os.Stat(filepath.Join("shop", "notes.md")); !os.IsNotExist(err) {
+		t.Fatalf("expected scaffold exclude to apply, got %v", err)
+	}
+}
+
+func TestNewCommandRejectsInvalidVariables(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"unknown", []string{"--var", "other=x"}, `unknown variable "other"`},
+		{"pattern", []string{"--var", "db_name=Bad Name"}, `variable "db_name" must match`},
+		{"required", nil, `variable "db_name" is required, pass --var db_name=<value>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restore := withWorkDir(t)
+			defer restore()
+			stderr := &strings.Builder{}
+			logger := logging.New(&strings.Builder{}, stderr, false)
+			templatesDir := t.TempDir()
+			writePluginTemplate(t, templatesDir, "alpha", "[variables.db_name]\npattern = \"^[a-z_]+$\"\n")
+			cmd := newTestCommand(t)
+			cmd.templatesDir = func() (string, error) { return templatesDir, nil }
+			args := append([]string{"proj", "--template", "alpha"}, tt.args...)
+			code := cmd.run(context.Background(), args, ui.New(&strings.Builder{}, stderr, false), logger, false)
+			if code != 1 {
+				t.Fatalf("expected exit 1")
+			}
+			if !strings.Contains(stderr.String(), tt.want) {
+				t.Fatalf("expected %q, got %q", tt.want, stderr.String())
+			}
+			if _, err := os.Stat("proj"); !os.IsNotExist(err) {
+				t.Fatalf("expected no project directory")
+			}
+		})
+	}
+}
+
+func TestNewCommandPromptsForVariables(t *testing.T) {
+	restore := withWorkDir(t)
+	defer restore()
+	logger := logging.New(&strings.Builder{}, &strings.Builder{}, false)
+	templatesDir := t.TempDir()
+	templateDir := writePluginTemplate(t, templatesDir, "alpha", "[variables.db_name]\nprompt = \"Database name\"\ndefault = \"{{project_name}}_db\"\n")
+	if err := os.WriteFile(filepath.Join(templateDir, "db.txt"), []byte("{{db_name}}"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	var prompted string
+	cmd := newTestCommand(t)
+	cmd.templatesDir = func() (string, error) { return templatesDir, nil }
+	cmd.prompt = func(name string, variable manifest.Variable, value string) (string, error) {
+		prompted = variable.Prompt + "=" + value
+		return "custom", nil
+	}
+	code := cmd.run(context.Background(), []string{"proj", "--template", "alpha"}, ui.New(&strings.Builder{}, &strings.Builder{}, false), logger, true)
+	if code != 0 {
+		t.Fatalf("expected exit 0")
+	}
+	if prompted != "Database name=proj_db" {
+		t.Fatalf("expected prompt with rendered default, got %q", prompted)
+	}
+	if data, _ := os.ReadFile(filepath.Join("proj", "db.txt")); string(data) != "custom" {
+		t.Fatalf("expected prompted value, got %q", data)
+	}
+}
+
+func TestNewCommandRecordsTemplateRevision(t *testing.T) {
+	restore := withWorkDir(t)
+	defer restore()
+	logger := logging.New(&strings.Builder{}, &strings.Builder{}, false)
+	templatesDir := t.TempDir()
+	templateDir := writePluginTemplate(t, templatesDir, "alpha", "[variables.db_name]\ndefault = \"{{project_name}}_db\"\n")
+	source := templateSource{URL: "https://example.com/alpha.git", Commit: "3f9a1c2b4d"}
+	if err := os.WriteFile(filepath.Join(templateDir, ".source"), source.encode(), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	var version, commit string
+	var variables map[string]string
+	cmd := newTestCommand(t)
+	cmd.templatesDir = func() (string, error) { return templatesDir, nil }
+	cmd.recordTemplate = func(_, v, c string, vars map[string]string) (registry.Marker, error) {
+		version, commit, variables = v, c, vars
+		return registry.Marker{}, nil
+	}
+	code := cmd.run(context.Background(), []string{"proj", "--template", "alpha"}, ui.New(&strings.Builder{}, &strings.Builder{}, false), logger, false)
+	if code != 0 {
+		t.Fatalf("expected exit 0")
+	}
+	if commit != "3f9a1c2b4d" || version != "" || len(variables) != 1 || variables["db_name"] != "proj_db" {
+		t.Fatalf("unexpected revision %q %q %v", version, commit, variables)
+	}
+}
@@ -24,6 +24,7 @@ type proxyCommand struct {
 	restart      func(context.Context, execx.Runner, string) error
 	isRunning    func(context.Context, execx.Runner) bool
 	loadRegistry func(string) (map[string]registry.Project, error)
+	loadSettings func() (config.Settings, error)
 	caddyfilePath func() (string, error)
 	plistPath    func() (string, error)
 	logPath      func() (string, error)
@@ -42,8 +43,9 @@ func defaultProxyCommand() proxyCommand {
 		restart:       proxy.RestartProxyService,
 		isRunning:     proxy.IsProxyRunning,
 		loadRegistry:  registry.Load,
+		loadSettings:  config.LoadSettings,
 		caddyfilePath: config.CaddyfilePath,
-		plistPath:     config.ProxyPlistPath,
+		plistPath:     config.ProxyServicePath,
 		logPath:       config.ProxyLogPath,
 		errPath:       config.ProxyErrPath,
 		projectsFile:  config.ProjectsFile,
@@ -101,7 +103,7 @@ func (c proxyCommand) start(ctx context.Context, logger *logging.Logger) int {
 
 	plistPath, err := c.plistPath()
 	if err != nil {
-		logger.Error("Failed to resolve proxy plist path")
+		logger.Error("Failed to resolve proxy service path")
 		return 1
 	}
 	caddyfilePath, err := c.caddyfilePath()
@@ -121,7 +123,7 @@ func (c proxyCommand) start(ctx context.Context, logger *logging.Logger) int {
 	}
 
 	if err := c.createPlist(ctx, c.runner, plistPath, caddyfilePath, logPath, errPath); err != nil {
-		logger.Error("Failed to create proxy plist")
+		logger.Error("Failed to create proxy service")
 		return 1
 	}
 	if err := c.install(ctx, c.runner, plistPath); err != nil {
@@ -139,7 +141,7 @@ func (c proxyCommand) stop(ctx context.Context, logger *logging.Logger) int {
 	}
 	plistPath, err := c.plistPath()
 	if err != nil {
-		logger.Error("Failed to resolve proxy plist path")
+		logger.Error("Failed to resolve proxy service path")
 		return 1
 	}
 	if err := c.uninstall(ctx, c.runner, plistPath); err != nil {
@@ -153,7 +155,7 @@ func (c proxyCommand) stop(ctx context.Context, logger *logging.Logger) int {
 func (c proxyCommand) restartService(ctx context.Context, logger *logging.Logger) int {
 	plistPath, err := c.plistPath()
 	if err != nil {
-		logger.Error("Failed to resolve proxy plist path")
+		logger.Error("Failed to resolve proxy service path")
 		return 1
 	}
 	if err := c.restart(ctx, c.runner, plistPath); err != nil {
@@ -179,7 +181,7 @@ func (c proxyCommand) status(ctx context.Context, logger *logging.Logger) int {
 	}
 	projects, err := c.loadRegistry(projectsPath)
 	if err != nil {
-		logger.Error("Failed to load project registry")
+		logRegistryError(logger, err, "Failed to load project registry")
 		return 1
 	}
 	count, minPort, maxPort, ok := projectStats(projects)
@@ -190,6 +192,16 @@ func (c proxyCommand) status(ctx context.Context, logger *logging.Logger) int {
 		logger.Info("Ports: none")
 	}
 
+	settings, err := c.settings()
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to read config: %v", err))
+		return 1
+	}
+	logger.Info(fmt.Sprintf("Backend: %s", settings.Proxy.Backend))
+	if settings.BuiltinProxy() {
+		logger.Info(fmt.Sprintf("Listening: %s, %s", settings.Proxy.HTTPSAddr, settings.Proxy.HTTPAddr))
+		return 0
+	}
 	caddyfilePath, err := c.caddyfilePath()
 	if err != nil {
 		logger.Error("Failed to resolve Caddyfile path")
@@ -199,6 +211,13 @@ func (c proxyCommand) status(ctx context.Context, logger *logging.Logger) int {
 	return 0
 }
 
+func (c proxyCommand) settings() (config.Settings, error) {
+	if c.loadSettings == nil {
+		return config.LoadSettings()
+	}
+	return c.loadSettings()
+}
+
 func (c proxyCommand) logs(logger *logging.Logger) int {
 	logPath, err := c.logPath()
 	if err != nil {
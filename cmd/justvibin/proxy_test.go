@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/alexcabrera/justvibin/internal/config"
 	execx "github.com/alexcabrera/justvibin/internal/exec"
 	"github.com/alexcabrera/justvibin/internal/logging"
 	"github.com/alexcabrera/justvibin/internal/registry"
@@ -67,6 +68,29 @@ func TestProxyStatusRunning(t *testing.T) {
 	}
 }
 
+func TestProxyStatusBuiltinBackend(t *testing.T) {
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	logger := logging.New(stdout, stderr, false)
+	console := ui.New(stdout, stderr, false)
+	cmd := defaultProxyCommand()
+	cmd.initConfig = func() (bool, error) { return false, nil }
+	cmd.isRunning = func(context.Context, execx.Runner) bool { return true }
+	cmd.loadRegistry = func(string) (map[string]registry.Project, error) { return map[string]registry.Project{}, nil }
+	cmd.projectsFile = func() (string, error) { return "/tmp/projects.json", nil }
+	cmd.loadSettings = func() (config.Settings, error) {
+		return config.Settings{Proxy: config.ProxySettings{Backend: config.ProxyBackendBuiltin, HTTPSAddr: ":8443", HTTPAddr: ":8080"}}, nil
+	}
+
+	if code := cmd.run(context.Background(), []string{"status"}, console, logger); code != 0 {
+		t.Fatalf("expected exit 0, got %d", code)
+	}
+	output := stdout.String()
+	if !strings.Contains(output, "Backend: builtin") || !strings.Contains(output, "Listening: :8443, :8080") || strings.Contains(output, "Caddyfile") {
+		t.Fatalf("unexpected status output %q", output)
+	}
+}
+
 func TestProxyStatusJSON(t *testing.T) {
 	stdout := &strings.Builder{}
 	stderr := &strings.Builder{}
@@ -120,9 +120,9 @@ func TestRegisterCmdSuccess(t *testing.T) {
 	stderr := &strings.Builder{}
 	logger := logging.New(stdout, stderr, false)
 	cmd := newTestRegisterCommand(t)
-	cmd.register = func(path, name string, port int, projectPath, template string) (registry.Project, error) {
+	cmd.register = func(path, name, projectPath, template string) (registry.Project, error) {
 		registeredName = name
-		return registry.Project{Port: port, Path: projectPath, Template: template}, nil
+		return registry.Project{Port: 4000, Path: projectPath, Template: template}, nil
 	}
 	cmd.writeMarker = func(projectDir, name, template string, port int) (registry.Marker, error) {
 		markerWritten = true
@@ -167,9 +167,9 @@ func TestRegisterCmdDetectsDjango(t *testing.T) {
 	stderr := &strings.Builder{}
 	logger := logging.New(stdout, stderr, false)
 	cmd := newTestRegisterCommand(t)
-	cmd.register = func(path, name string, port int, projectPath, template string) (registry.Project, error) {
+	cmd.register = func(path, name, projectPath, template string) (registry.Project, error) {
 		registeredTemplate = template
-		return registry.Project{Port: port, Path: projectPath, Template: template}, nil
+		return registry.Project{Port: 4000, Path: projectPath, Template: template}, nil
 	}
 	code := cmd.run(context.Background(), []string{"myapp"}, ui.New(stdout, stderr, false), logger, "")
 	if code != 0 {
@@ -183,8 +183,10 @@ func TestRegisterCmdDetectsDjango(t *testing.T) {
 func newTestRegisterCommand(t *testing.T) registerCommand {
 	t.Helper()
 	cmd := defaultRegisterCommand()
-	cmd.generateCaddy = func(context.Context, execx.Runner, string, string) error { return nil }
+	cmd.generateCaddy = func(context.Context, execx.Runner, string, string) (bool, error) { return false, nil }
 	cmd.reloadProxy = func(context.Context, execx.Runner, string) error { return nil }
-	cmd.nextPort = func(string) (int, error) { return 4000, nil }
+	cmd.register = func(path, name, projectPath, template string) (registry.Project, error) {
+		return registry.Project{Port: 4000, Path: projectPath, Template: template}, nil
+	}
 	return cmd
 }
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	execx "github.com/alexcabrera/justvibin/internal/exec"
+	"github.com/alexcabrera/justvibin/internal/logging"
+	"github.com/alexcabrera/justvibin/internal/registry"
+	"github.com/alexcabrera/justvibin/internal/ui"
+)
+
+func TestRegistryRepairRebuildsFromMarkers(t *testing.T) {
+	baseDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", baseDir)
+
+	projectsPath := filepath.Join(baseDir, "justvibin", "projects.json")
+	if err := os.MkdirAll(filepath.Dir(projectsPath), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(projectsPath, []byte("{bad"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	scanDir := t.TempDir()
+	projectDir := filepath.Join(scanDir, "myproject")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if _, err := registry.WriteMarker(projectDir, "myproject", "hypertext", 4000); err != nil {
+		t.Fatalf("marker: %v", err)
+	}
+
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	logger := logging.New(stdout, stderr, false)
+	cmd := newTestRegistryCommand(t)
+	code := cmd.runRepair(context.Background(), []string{scanDir}, ui.New(stdout, stderr, false), logger)
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), "Recovered 1 project") {
+		t.Fatalf("expected recovered message, got %q", stdout.String())
+	}
+	if _, ok, err := registry.Get(projectsPath, "myproject"); err != nil || !ok {
+		t.Fatalf("expected myproject in registry: %v", err)
+	}
+}
+
+func TestListCmdReportsCorruptRegistry(t *testing.T) {
+	baseDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", baseDir)
+
+	projectsPath := filepath.Join(baseDir, "justvibin", "projects.json")
+	if err := os.MkdirAll(filepath.Dir(projectsPath), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(projectsPath, []byte("{bad"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	resetRootFlags(t)
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(stderr)
+	rootCmd.SetArgs([]string{"list"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Fatalf("expected error")
+	}
+	if !strings.Contains(stdout.String()+stderr.String(), "justvibin registry repair") {
+		t.Fatalf("expected repair hint, got %q", stdout.String()+stderr.String())
+	}
+}
+
+func newTestRegistryCommand(t *testing.T) registryCommand {
+	t.Helper()
+	cmd := defaultRegistryCommand()
+	cmd.generateCaddy = func(context.Context, execx.Runner, string, string) (bool, error) { return false, nil }
+	cmd.reloadProxy = func(context.Context, execx.Runner, string) error { return nil }
+	return cmd
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/alexcabrera/justvibin/internal/config"
+	"github.com/alexcabrera/justvibin/internal/index"
+	"github.com/alexcabrera/justvibin/internal/logging"
+	"github.com/alexcabrera/justvibin/internal/ui"
+	"github.com/alexcabrera/justvibin/internal/version"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type searchCommand struct {
+	loadIndex func(ctx context.Context, logger *logging.Logger) (index.Index, error)
+}
+
+var searchCommandFactory = defaultSearchCommand
+
+func defaultSearchCommand() searchCommand {
+	return searchCommand{loadIndex: loadTemplateIndex}
+}
+
+// loadTemplateIndex returns the index configured under [templates] in
+// config.toml followed by the official templates it does not list. When the
+// configured index cannot be loaded, e.g. offline without a cache, it warns
+// and returns only the official templates.
+func loadTemplateIndex(ctx context.Context, logger *logging.Logger) (index.Index, error) {
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return index.Index{}, err
+	}
+	idx := index.Index{}
+	if settings.Templates.Index != "" {
+		cachePath, err := config.IndexCachePath()
+		if err != nil {
+			return index.Index{}, err
+		}
+		if idx, err = index.Load(ctx, settings.Templates.Index, cachePath, index.DefaultMaxAge); err != nil {
+			logger.Warn(fmt.Sprintf("Could not load template index %s: %v", settings.Templates.Index, err))
+			logger.Info("Showing official templates only")
+			idx = index.Index{}
+		}
+	}
+	for _, tpl := range config.DefaultTemplates().Ordered {
+		if _, ok := idx.Find(tpl.Name); !ok {
+			idx.Templates = append(idx.Templates, index.Entry{Name: tpl.Name, Description: tpl.DisplayName, URL: tpl.URL})
+		}
+	}
+	return idx, nil
+}
+
+func (c searchCommand) run(ctx context.Context, query string, console *ui.UI, logger *logging.Logger, output OutputSettings) int {
+	idx, err := c.loadIndex(ctx, logger)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to load template index: %v", err))
+		return 1
+	}
+	matches := idx.Search(query)
+
+	if output.JSON {
+		if matches == nil {
+			matches = []index.Entry{}
+		}
+		data, err := json.MarshalIndent(matches, "", "  ")
+		if err != nil {
+			logger.Error("Failed to encode JSON")
+			return 1
+		}
+		console.PrintHelp(string(data))
+		return 0
+	}
+	if len(matches) == 0 {
+		logger.Info(fmt.Sprintf("No templates match '%s'", query))
+		return 0
+	}
+	console.PrintHelp(searchText(matches, output.Styled))
+	return 0
+}
+
+func searchText(entries []index.Entry, styled bool) string {
+	nameStyle := lipgloss.NewStyle()
+	descStyle := lipgloss.NewStyle()
+	metaStyle := lipgloss.NewStyle()
+	if styled {
+		nameStyle = nameStyle.Foreground(lipgloss.Color("220")).Bold(true)
+		descStyle = descStyle.Foreground(lipgloss.Color("240"))
+		metaStyle = metaStyle.Foreground(lipgloss.Color("159"))
+	}
+	lines := []string{""}
+	for _, entry := range entries {
+		name := entry.Name
+		if !entry.Supports(version.Version) {
+			name = fmt.Sprintf("%s (requires justvibin %s)", entry.Name, entry.MinVersion)
+		}
+		lines = append(lines, nameStyle.Render("  "+name))
+		if entry.Description != "" {
+			lines = append(lines, descStyle.Render("    "+entry.Description))
+		}
+		if len(entry.Tags) > 0 {
+			lines = append(lines, metaStyle.Render("    Tags: "+strings.Join(entry.Tags, ", ")))
+		}
+		lines = append(lines, "")
+	}
+	lines = append(lines, descStyle.Render("Install one: justvibin install <name>"), "")
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexcabrera/justvibin/internal/config"
+	"github.com/alexcabrera/justvibin/internal/index"
+	"github.com/alexcabrera/justvibin/internal/logging"
+	"github.com/alexcabrera/justvibin/internal/ui"
+)
+
+func testSearchIndex(context.Context, *logging.Logger) (index.Index, error) {
+	return index.Index{Templates: []index.Entry{
+		{Name: "django-hypermedia", Description: "Django with htmx", Tags: []string{"python"}, URL: "https://example.com/django.git"},
+		{Name: "htmx-go", Description: "Go server with htmx", Tags: []string{"go"}, URL: "https://example.com/go.git", MinVersion: "99.0"},
+	}}, nil
+}
+
+func TestSearchCommandText(t *testing.T) {
+	stdout := &strings.Builder{}
+	cmd := searchCommand{loadIndex: testSearchIndex}
+	code := cmd.run(context.Background(), "go server", ui.New(stdout, &strings.Builder{}, false), logging.New(stdout, &strings.Builder{}, false), OutputSettings{})
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d", code)
+	}
+	out := stdout.String()
+	if !strings.Contains(out, "htmx-go (requires justvibin 99.0)") || strings.Contains(out, "django-hypermedia") {
+		t.Fatalf("unexpected output %q", out)
+	}
+}
+
+func TestSearchCommandJSON(t *testing.T) {
+	stdout := &strings.Builder{}
+	cmd := searchCommand{loadIndex: testSearchIndex}
+	code := cmd.run(context.Background(), "", ui.New(stdout, &strings.Builder{}, false), logging.New(stdout, &strings.Builder{}, false), OutputSettings{JSON: true})
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d", code)
+	}
+	var entries []index.Entry
+	if err := json.Unmarshal([]byte(stdout.String()), &entries); err != nil {
+		t.Fatalf("decode %q: %v", stdout.String(), err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %v", entries)
+	}
+}
+
+func TestSearchCommandNoMatches(t *testing.T) {
+	stdout := &strings.Builder{}
+	cmd := searchCommand{loadIndex: testSearchIndex}
+	code := cmd.run(context.Background(), "rails", ui.New(stdout, &strings.Builder{}, false), logging.New(stdout, &strings.Builder{}, false), OutputSettings{})
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), "No templates match 'rails'") {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+}
+
+func TestSearchCommandLoadError(t *testing.T) {
+	stderr := &strings.Builder{}
+	cmd := searchCommand{loadIndex: func(context.Context, *logging.Logger) (index.Index, error) {
+		return index.Index{}, errors.New("offline")
+	}}
+	code := cmd.run(context.Background(), "", ui.New(&strings.Builder{}, stderr, false), logging.New(&strings.Builder{}, stderr, false), OutputSettings{})
+	if code != 1 || !strings.Contains(stderr.String(), "Failed to load template index: offline") {
+		t.Fatalf("expected load error, got %d %q", code, stderr.String())
+	}
+}
+
+func TestLoadTemplateIndexAddsOfficialTemplates(t *testing.T) {
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	indexPath := filepath.Join(t.TempDir(), "index.toml")
+	data := "[[templates]]\nname = \"htmx-go\"\nurl = \"https://example.com/go.git\"\n"
+	if err := os.WriteFile(indexPath, []byte(data), 0644); err != nil {
+		t.Fatalf("write index: %v", err)
+	}
+	configPath := filepath.Join(configHome, "justvibin", config.ConfigFileName)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(configPath, []byte("[templates]\nindex = \"file://"+filepath.ToSlash(indexPath)+"\"\n"), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	idx, err := loadTemplateIndex(context.Background(), logging.New(&strings.Builder{}, &strings.Builder{}, false))
+	if err != nil {
+		t.Fatalf("load index: %v", err)
+	}
+	if idx.Templates[0].Name != "htmx-go" {
+		t.Fatalf("expected indexed template first, got %v", idx.Templates)
+	}
+	for _, tpl := range config.DefaultTemplates().Ordered {
+		if _, ok := idx.Find(tpl.Name); !ok {
+			t.Fatalf("expected official template %s, got %v", tpl.Name, idx.Templates)
+		}
+	}
+}
+
+func TestSearchCommandFallsBackToOfficialTemplatesOffline(t *testing.T) {
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	configPath := filepath.Join(configHome, "justvibin", config.ConfigFileName)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(configPath, []byte("[templates]\nindex = \"http://127.0.0.1:1/index.toml\"\n"), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	cmd := defaultSearchCommand()
+	code := cmd.run(context.Background(), "django", ui.New(stdout, stderr, false), logging.New(stdout, stderr, false), OutputSettings{})
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Could not load template index http://127.0.0.1:1/index.toml") {
+		t.Fatalf("expected a warning, got %q", stdout.String())
+	}
+	if !strings.Contains(stdout.String(), "django-hypermedia") {
+		t.Fatalf("expected official templates, got %q", stdout.String())
+	}
+}
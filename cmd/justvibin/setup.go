@@ -24,14 +24,15 @@ import (
 type setupCommand struct {
 	runner          execx.Runner
 	initConfig      func() (bool, error)
+	loadSettings    func() (config.Settings, error)
 	detectSrv       func() (bool, error)
 	migrateSrv      func(context.Context, execx.Runner, string, *logging.Logger) error
 	confirm         func(string) (bool, error)
 	spin            func(message string, work func() error) error
-	generateCaddy   func(context.Context, execx.Runner, string, string) error
+	generateCaddy   func(context.Context, execx.Runner, string, string) (bool, error)
 	createPlist     func(context.Context, execx.Runner, string, string, string, string) error
 	installProxy    func(context.Context, execx.Runner, string) error
-	trustCA         func(context.Context, execx.Runner) error
+	trustCA         func(context.Context, execx.Runner, *logging.Logger) error
 	installTemplate func(context.Context, string, *ui.UI, *logging.Logger, bool) int
 	templatesDir    func() (string, error)
 	projectsFile    func() (string, error)
@@ -45,16 +46,17 @@ func defaultSetupCommand() setupCommand {
 	return setupCommand{
 		runner:        execx.NewSystemRunner(),
 		initConfig:    config.InitConfig,
+		loadSettings:  config.LoadSettings,
 		detectSrv:     config.DetectSrvConfig,
 		migrateSrv:    migrateFromSrv,
 		generateCaddy: proxy.GenerateCaddyfile,
 		createPlist:   proxy.CreatePlist,
 		installProxy:  proxy.InstallProxyService,
-		trustCA:       trustCaddyCA,
+		trustCA:       trustLocalCA,
 		templatesDir:  config.TemplatesDir,
 		projectsFile:  config.ProjectsFile,
 		caddyfilePath: config.CaddyfilePath,
-		plistPath:     config.ProxyPlistPath,
+		plistPath:     config.ProxyServicePath,
 		logPath:       config.ProxyLogPath,
 		errPath:       config.ProxyErrPath,
 		installTemplate: func(ctx context.Context, url string, console *ui.UI, logger *logging.Logger, styled bool) int {
@@ -90,6 +92,9 @@ func (c setupCommand) run(ctx context.Context, args []string, console *ui.UI, lo
 	if c.initConfig == nil {
 		c.initConfig = config.InitConfig
 	}
+	if c.loadSettings == nil {
+		c.loadSettings = config.LoadSettings
+	}
 	if c.detectSrv == nil {
 		c.detectSrv = config.DetectSrvConfig
 	}
@@ -106,7 +111,7 @@ func (c setupCommand) run(ctx context.Context, args []string, console *ui.UI, lo
 		c.installProxy = proxy.InstallProxyService
 	}
 	if c.trustCA == nil {
-		c.trustCA = trustCaddyCA
+		c.trustCA = trustLocalCA
 	}
 	if c.templatesDir == nil {
 		c.templatesDir = config.TemplatesDir
@@ -118,7 +123,7 @@ func (c setupCommand) run(ctx context.Context, args []string, console *ui.UI, lo
 		c.caddyfilePath = config.CaddyfilePath
 	}
 	if c.plistPath == nil {
-		c.plistPath = config.ProxyPlistPath
+		c.plistPath = config.ProxyServicePath
 	}
 	if c.logPath == nil {
 		c.logPath = config.ProxyLogPath
@@ -152,8 +157,15 @@ func (c setupCommand) run(ctx context.Context, args []string, console *ui.UI, lo
 		}
 	}
 
+	settings, err := c.loadSettings()
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to read config: %v", err))
+		return 1
+	}
+	builtinProxy := settings.BuiltinProxy()
+
 	logger.Info("Setting up justvibin...")
-	if !c.checkAllDependencies(ctx, logger) {
+	if !c.checkAllDependencies(ctx, logger, !builtinProxy) {
 		logger.Error("Missing required dependencies")
 		return 1
 	}
@@ -203,15 +215,17 @@ func (c setupCommand) run(ctx context.Context, args []string, console *ui.UI, lo
 		logger.Error("Failed to resolve Caddyfile path")
 		return 1
 	}
-	if err := c.generateCaddy(ctx, c.runner, projectsPath, caddyfilePath); err != nil {
-		logger.Error("Failed to generate Caddyfile")
-		return 1
+	if !builtinProxy {
+		if _, err := c.generateCaddy(ctx, c.runner, projectsPath, caddyfilePath); err != nil {
+			logger.Error("Failed to generate Caddyfile")
+			return 1
+		}
+		logger.Success("Caddyfile generated")
 	}
-	logger.Success("Caddyfile generated")
 
 	plistPath, err := c.plistPath()
 	if err != nil {
-		logger.Error("Failed to resolve proxy plist path")
+		logger.Error("Failed to resolve proxy service path")
 		return 1
 	}
 	logPath, err := c.logPath()
@@ -225,7 +239,7 @@ func (c setupCommand) run(ctx context.Context, args []string, console *ui.UI, lo
 		return 1
 	}
 	if err := c.createPlist(ctx, c.runner, plistPath, caddyfilePath, logPath, errPath); err != nil {
-		logger.Error("Failed to create proxy plist")
+		logger.Error("Failed to create proxy service")
 		return 1
 	}
 	if err := c.installProxy(ctx, c.runner, plistPath); err != nil {
@@ -234,17 +248,18 @@ func (c setupCommand) run(ctx context.Context, args []string, console *ui.UI, lo
 	}
 	logger.Success("Proxy service started")
 
-	trust, err := c.confirm("Trust Caddy CA? (requires sudo)")
+	trust, err := c.confirm("Trust local CA? (requires sudo)")
 	if err != nil {
 		logger.Error("Failed to read CA trust confirmation")
 		return 1
 	}
 	if trust {
-		if err := c.trustCA(ctx, c.runner); err != nil {
-			logger.Error("Failed to trust Caddy CA")
+		if err := c.trustCA(ctx, c.runner, logger); err != nil {
+			logger.Error(fmt.Sprintf("Failed to trust local CA: %v", err))
+			logger.Info("Retry with: justvibin certs trust")
 			return 1
 		}
-		logger.Success("Caddy CA trusted")
+		logger.Success("Local CA trusted")
 	}
 
 	installTemplates, err := c.confirm("Install official templates?")
@@ -264,12 +279,14 @@ func (c setupCommand) run(ctx context.Context, args []string, console *ui.UI, lo
 	return 0
 }
 
-func (c setupCommand) checkAllDependencies(ctx context.Context, logger *logging.Logger) bool {
+// checkAllDependencies reports whether every required tool is installed.
+// Caddy is only required when it is the proxy backend.
+func (c setupCommand) checkAllDependencies(ctx context.Context, logger *logging.Logger, needCaddy bool) bool {
 	allOK := true
 	if !c.checkDependency(ctx, logger, "jq", "jq", true) {
 		allOK = false
 	}
-	if !c.checkDependency(ctx, logger, "caddy", "caddy", true) {
+	if needCaddy && !c.checkDependency(ctx, logger, "caddy", "caddy", true) {
 		allOK = false
 	}
 	c.checkDependency(ctx, logger, "gum", "gum", false)
@@ -348,11 +365,49 @@ func (c setupCommand) offerOfficialTemplates(ctx context.Context, console *ui.UI
 	return nil
 }
 
-func trustCaddyCA(ctx context.Context, runner execx.Runner) error {
-	if runner == nil {
-		runner = execx.NewSystemRunner()
+// trustLocalCA installs the proxy's root into the system store and the
+// browser NSS databases, as justvibin certs trust does.
+func trustLocalCA(ctx context.Context, runner execx.Runner, logger *logging.Logger) error {
+	certsCmd := defaultCertsCommand()
+	if runner != nil {
+		certsCmd.runner = runner
+	}
+	return certsCmd.trustForSetup(ctx, logger)
+}
+
+// trustForSetup only fails when the system store cannot trust the root.
+// Browser databases are best effort, as `caddy trust` was during setup: a
+// Firefox profile on a machine without certutil should not stop it.
+func (c certsCommand) trustForSetup(ctx context.Context, logger *logging.Logger) error {
+	settings, err := c.loadSettings()
+	if err != nil {
+		return err
 	}
-	return runner.Run(ctx, "sudo", "caddy", "trust")
+	path, err := c.rootPath(settings)
+	if err != nil {
+		return err
+	}
+	if settings.BuiltinProxy() {
+		if _, err := proxy.LoadOrCreateCA(filepath.Dir(path)); err != nil {
+			return err
+		}
+	}
+	// Caddy writes its root shortly after the proxy service first starts.
+	root, err := c.waitForRoot(path)
+	if err != nil {
+		return err
+	}
+	failed, systemFailed, err := c.trustStores(ctx, root, nil, logger)
+	if err != nil {
+		return err
+	}
+	if systemFailed {
+		return errors.New("could not trust the CA in the system store")
+	}
+	if len(failed) > 0 {
+		logger.Warn(fmt.Sprintf("Browsers not updated: %s. Retry with: justvibin certs trust", strings.Join(failed, ", ")))
+	}
+	return nil
 }
 
 type srvProject struct {
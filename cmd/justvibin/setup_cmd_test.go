@@ -25,10 +25,10 @@ func TestSetupCmdYesSkipsPrompts(t *testing.T) {
 		cmd.plistPath = func() (string, error) { return "/tmp/proxy.plist", nil }
 		cmd.logPath = func() (string, error) { return "/tmp/proxy.log", nil }
 		cmd.errPath = func() (string, error) { return "/tmp/proxy.err", nil }
-		cmd.generateCaddy = func(context.Context, execx.Runner, string, string) error { return nil }
+		cmd.generateCaddy = func(context.Context, execx.Runner, string, string) (bool, error) { return false, nil }
 		cmd.createPlist = func(context.Context, execx.Runner, string, string, string, string) error { return nil }
 		cmd.installProxy = func(context.Context, execx.Runner, string) error { return nil }
-		cmd.trustCA = func(context.Context, execx.Runner) error { return nil }
+		cmd.trustCA = func(context.Context, execx.Runner, *logging.Logger) error { return nil }
 		cmd.migrateSrv = func(context.Context, execx.Runner, string, *logging.Logger) error { return nil }
 		cmd.installTemplate = func(context.Context, string, *ui.UI, *logging.Logger, bool) int { return 0 }
 		return cmd
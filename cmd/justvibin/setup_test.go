@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/alexcabrera/justvibin/internal/config"
 	execx "github.com/alexcabrera/justvibin/internal/exec"
 	"github.com/alexcabrera/justvibin/internal/logging"
 	"github.com/alexcabrera/justvibin/internal/registry"
@@ -70,10 +71,10 @@ func TestSetupCmdNoTemplatesSkipsInstall(t *testing.T) {
 		cmd.plistPath = func() (string, error) { return "/tmp/proxy.plist", nil }
 		cmd.logPath = func() (string, error) { return "/tmp/proxy.log", nil }
 		cmd.errPath = func() (string, error) { return "/tmp/proxy.err", nil }
-		cmd.generateCaddy = func(context.Context, execx.Runner, string, string) error { return nil }
+		cmd.generateCaddy = func(context.Context, execx.Runner, string, string) (bool, error) { return false, nil }
 		cmd.createPlist = func(context.Context, execx.Runner, string, string, string, string) error { return nil }
 		cmd.installProxy = func(context.Context, execx.Runner, string) error { return nil }
-		cmd.trustCA = func(context.Context, execx.Runner) error { return nil }
+		cmd.trustCA = func(context.Context, execx.Runner, *logging.Logger) error { return nil }
 		cmd.migrateSrv = func(context.Context, execx.Runner, string, *logging.Logger) error { return nil }
 		cmd.installTemplate = func(context.Context, string, *ui.UI, *logging.Logger, bool) int { return 0 }
 		return cmd
@@ -125,6 +126,53 @@ func TestSetupCheckOnlyFailsWhenMissingRequired(t *testing.T) {
 	}
 }
 
+func TestSetupBuiltinProxyDoesNotNeedCaddy(t *testing.T) {
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	logger := logging.New(stdout, stderr, false)
+	console := ui.New(stdout, stderr, false)
+	generated := false
+	cmd := defaultSetupCommand()
+	cmd.runner = &setupRunner{lookPath: map[string]error{"caddy": errors.New("missing")}}
+	cmd.initConfig = func() (bool, error) { return false, nil }
+	cmd.loadSettings = func() (config.Settings, error) {
+		return config.Settings{Proxy: config.ProxySettings{Backend: config.ProxyBackendBuiltin}}, nil
+	}
+	cmd.detectSrv = func() (bool, error) { return false, nil }
+	var prompts []string
+	cmd.confirm = func(prompt string) (bool, error) {
+		prompts = append(prompts, prompt)
+		return false, nil
+	}
+	cmd.spin = func(string, func() error) error { return nil }
+	cmd.projectsFile = func() (string, error) { return "/tmp/projects.json", nil }
+	cmd.caddyfilePath = func() (string, error) { return "/tmp/Caddyfile", nil }
+	cmd.plistPath = func() (string, error) { return "/tmp/proxy.plist", nil }
+	cmd.logPath = func() (string, error) { return "/tmp/proxy.log", nil }
+	cmd.errPath = func() (string, error) { return "/tmp/proxy.err", nil }
+	cmd.generateCaddy = func(context.Context, execx.Runner, string, string) (bool, error) {
+		generated = true
+		return false, nil
+	}
+	cmd.createPlist = func(context.Context, execx.Runner, string, string, string, string) error { return nil }
+	cmd.installProxy = func(context.Context, execx.Runner, string) error { return nil }
+	cmd.trustCA = func(context.Context, execx.Runner, *logging.Logger) error {
+		t.Fatalf("expected CA trust to be declined")
+		return nil
+	}
+
+	code := cmd.run(context.Background(), []string{}, console, logger, false)
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	if generated || strings.Contains(stdout.String(), "caddy installed") {
+		t.Fatalf("expected setup to skip Caddy, got %q", stdout.String())
+	}
+	if len(prompts) == 0 || prompts[0] != "Trust local CA? (requires sudo)" {
+		t.Fatalf("expected CA trust prompt, got %v", prompts)
+	}
+}
+
 func TestSetupRunsMigrationWhenConfirmed(t *testing.T) {
 	stdout := &strings.Builder{}
 	stderr := &strings.Builder{}
@@ -148,10 +196,10 @@ func TestSetupRunsMigrationWhenConfirmed(t *testing.T) {
 	cmd.plistPath = func() (string, error) { return "/tmp/proxy.plist", nil }
 	cmd.logPath = func() (string, error) { return "/tmp/proxy.log", nil }
 	cmd.errPath = func() (string, error) { return "/tmp/proxy.err", nil }
-	cmd.generateCaddy = func(context.Context, execx.Runner, string, string) error { return nil }
+	cmd.generateCaddy = func(context.Context, execx.Runner, string, string) (bool, error) { return false, nil }
 	cmd.createPlist = func(context.Context, execx.Runner, string, string, string, string) error { return nil }
 	cmd.installProxy = func(context.Context, execx.Runner, string) error { return nil }
-	cmd.trustCA = func(context.Context, execx.Runner) error { return nil }
+	cmd.trustCA = func(context.Context, execx.Runner, *logging.Logger) error { return nil }
 	cmd.migrateSrv = func(context.Context, execx.Runner, string, *logging.Logger) error {
 		migrated = true
 		return nil
@@ -187,10 +235,10 @@ func TestOfferOfficialTemplatesSkipsInstalled(t *testing.T) {
 	cmd.plistPath = func() (string, error) { return "/tmp/proxy.plist", nil }
 	cmd.logPath = func() (string, error) { return "/tmp/proxy.log", nil }
 	cmd.errPath = func() (string, error) { return "/tmp/proxy.err", nil }
-	cmd.generateCaddy = func(context.Context, execx.Runner, string, string) error { return nil }
+	cmd.generateCaddy = func(context.Context, execx.Runner, string, string) (bool, error) { return false, nil }
 	cmd.createPlist = func(context.Context, execx.Runner, string, string, string, string) error { return nil }
 	cmd.installProxy = func(context.Context, execx.Runner, string) error { return nil }
-	cmd.trustCA = func(context.Context, execx.Runner) error { return nil }
+	cmd.trustCA = func(context.Context, execx.Runner, *logging.Logger) error { return nil }
 	cmd.migrateSrv = func(context.Context, execx.Runner, string, *logging.Logger) error { return nil }
 
 	code := cmd.run(context.Background(), []string{}, console, logger, false)
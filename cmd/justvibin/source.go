@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	execx "github.com/alexcabrera/justvibin/internal/exec"
+)
+
+// sourceFileName records where an installed template came from.
+const sourceFileName = ".source"
+
+var commitPattern = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// templateSource is the contents of a template's .source file. Older
+// installs wrote only the URL as plain text, which reads as a source
+// without a ref or commit.
+type templateSource struct {
+	URL    string `json:"url"`
+	Ref    string `json:"ref,omitempty"`
+	Commit string `json:"commit,omitempty"`
+}
+
+func parseTemplateSource(data []byte) templateSource {
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "{") {
+		var source templateSource
+		if err := json.Unmarshal([]byte(trimmed), &source); err == nil {
+			return source
+		}
+	}
+	return templateSource{URL: trimmed}
+}
+
+func (s templateSource) encode() []byte {
+	data, _ := json.MarshalIndent(s, "", "  ")
+	return append(data, '\n')
+}
+
+// String is the URL with its ref, in the url@ref form install accepts.
+func (s templateSource) String() string {
+	if s.Ref == "" {
+		return s.URL
+	}
+	return s.URL + "@" + s.Ref
+}
+
+// shortCommit abbreviates a commit SHA for display.
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+// splitSourceRef splits "url@ref" into its URL and ref. The @ of a user in
+// the URL, as in git@github.com:acme/tpl.git, is not mistaken for a ref:
+// the part before the last @ must be a full repository address and the ref
+// cannot contain a colon.
+func splitSourceRef(arg string) (string, string) {
+	i := strings.LastIndex(arg, "@")
+	if i <= 0 || i == len(arg)-1 {
+		return arg, ""
+	}
+	url, ref := arg[:i], arg[i+1:]
+	if strings.Contains(ref, ":") {
+		return arg, ""
+	}
+	if _, rest, ok := strings.Cut(url, "://"); ok {
+		url = rest
+	}
+	if !strings.ContainsAny(url, "/:") {
+		return arg, ""
+	}
+	return arg[:i], ref
+}
+
+// cloneTemplate clones source at its ref into dir and returns the commit it
+// checked out. Branches and tags are cloned shallow; a commit SHA needs the
+// full history to check out.
+func cloneTemplate(ctx context.Context, runner execx.Runner, source templateSource, dir string) (string, error) {
+	switch {
+	case source.Ref == "":
+		if err := runner.Run(ctx, "git", "clone", "--depth", "1", source.URL, dir); err != nil {
+			return "", err
+		}
+	case commitPattern.MatchString(source.Ref):
+		if err := runner.Run(ctx, "git", "clone", source.URL, dir); err != nil {
+			return "", err
+		}
+		if err := runner.Run(ctx, "git", "-C", dir, "checkout", "--quiet", source.Ref); err != nil {
+			return "", err
+		}
+	default:
+		if err := runner.Run(ctx, "git", "clone", "--depth", "1", "--branch", source.Ref, source.URL, dir); err != nil {
+			return "", err
+		}
+	}
+	commit, err := runner.Output(ctx, "git", "-C", dir, "rev-parse", "HEAD")
+	if err != nil {
+		// The clone succeeded; the commit is only recorded for display.
+		return "", nil
+	}
+	return strings.TrimSpace(commit), nil
+}
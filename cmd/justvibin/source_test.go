@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// gitRunner records git invocations and answers rev-parse with commit.
+type gitRunner struct {
+	calls  *[]string
+	commit string
+}
+
+func (r gitRunner) Run(_ context.Context, name string, args ...string) error {
+	*r.calls = append(*r.calls, strings.Join(append([]string{name}, args...), " "))
+	return nil
+}
+
+func (r gitRunner) Output(_ context.Context, name string, args ...string) (string, error) {
+	*r.calls = append(*r.calls, strings.Join(append([]string{name}, args...), " "))
+	return r.commit + "\n", nil
+}
+
+func (r gitRunner) LookPath(_ string) (string, error) {
+	return "/usr/bin/git", nil
+}
+
+func TestSplitSourceRef(t *testing.T) {
+	tests := []struct {
+		arg, url, ref string
+	}{
+		{"https://github.com/acme/tpl.git", "https://github.com/acme/tpl.git", ""},
+		{"https://github.com/acme/tpl.git@v1.2.0", "https://github.com/acme/tpl.git", "v1.2.0"},
+		{"https://github.com/acme/tpl.git@feature/login", "https://github.com/acme/tpl.git", "feature/login"},
+		{"git@github.com:acme/tpl.git", "git@github.com:acme/tpl.git", ""},
+		{"git@github.com:acme/tpl.git@3f9a1c2", "git@github.com:acme/tpl.git", "3f9a1c2"},
+		{"https://user@example.com/tpl.git", "https://user@example.com/tpl.git", ""},
+		{"/srv/templates/tpl@main", "/srv/templates/tpl", "main"},
+		{"https://github.com/acme/tpl.git@", "https://github.com/acme/tpl.git@", ""},
+	}
+	for _, tt := range tests {
+		url, ref := splitSourceRef(tt.arg)
+		if url != tt.url || ref != tt.ref {
+			t.Fatalf("splitSourceRef(%q) = %q, %q; want %q, %q", tt.arg, url, ref, tt.url, tt.ref)
+		}
+	}
+}
+
+func TestParseTemplateSource(t *testing.T) {
+	legacy := parseTemplateSource([]byte("https://example.com/repo.git\n"))
+	if legacy != (templateSource{URL: "https://example.com/repo.git"}) {
+		t.Fatalf("unexpected legacy source %#v", legacy)
+	}
+	source := templateSource{URL: "https://example.com/repo.git", Ref: "v1", Commit: "3f9a1c2b"}
+	if parsed := parseTemplateSource(source.encode()); parsed != source {
+		t.Fatalf("expected %#v, got %#v", source, parsed)
+	}
+}
+
+func TestCloneTemplateChecksOutRef(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want []string
+	}{
+		{"", []string{"git clone --depth 1 https://example.com/repo.git dir"}},
+		{"v1.0", []string{"git clone --depth 1 --branch v1.0 https://example.com/repo.git dir"}},
+		{"3f9a1c2", []string{"git clone https://example.com/repo.git dir", "git -C dir checkout --quiet 3f9a1c2"}},
+	}
+	for _, tt := range tests {
+		var calls []string
+		runner := gitRunner{calls: &calls, commit: "3f9a1c2b4d"}
+		commit, err := cloneTemplate(context.Background(), runner, templateSource{URL: "https://example.com/repo.git", Ref: tt.ref}, "dir")
+		if err != nil || commit != "3f9a1c2b4d" {
+			t.Fatalf("clone %q: %q, %v", tt.ref, commit, err)
+		}
+		want := append(tt.want, "git -C dir rev-parse HEAD")
+		if strings.Join(calls, "\n") != strings.Join(want, "\n") {
+			t.Fatalf("ref %q: expected %v, got %v", tt.ref, want, calls)
+		}
+	}
+}
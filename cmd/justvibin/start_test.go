@@ -2,17 +2,34 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"net"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/alexcabrera/justvibin/internal/logfile"
 	"github.com/alexcabrera/justvibin/internal/logging"
 	"github.com/alexcabrera/justvibin/internal/registry"
 	"github.com/alexcabrera/justvibin/internal/serve"
 	"github.com/alexcabrera/justvibin/internal/ui"
 )
 
+// TestMain lets the test binary stand in for justvibin when start re-runs
+// it as the log capture helper.
+func TestMain(m *testing.M) {
+	if len(os.Args) == 3 && os.Args[1] == logCaptureCommand {
+		if err := logfile.Capture(os.Args[2], os.Stdin); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
 func TestStartCmdNotJustvibin(t *testing.T) {
 	cwd, _ := os.Getwd()
 	defer func() { _ = os.Chdir(cwd) }()
@@ -27,7 +44,7 @@ func TestStartCmdNotJustvibin(t *testing.T) {
 	stderr := &strings.Builder{}
 	logger := logging.New(stdout, stderr, false)
 	cmd := defaultStartCommand()
-	code := cmd.run(context.Background(), []string{}, ui.New(stdout, stderr, false), logger, false)
+	code := cmd.run(context.Background(), []string{}, ui.New(stdout, stderr, false), logger, startOptions{})
 	if code != 1 {
 		t.Fatalf("expected exit 1")
 	}
@@ -43,7 +60,7 @@ func TestStartCmdProjectNotFound(t *testing.T) {
 	stderr := &strings.Builder{}
 	logger := logging.New(stdout, stderr, false)
 	cmd := defaultStartCommand()
-	code := cmd.run(context.Background(), []string{"nonexistent"}, ui.New(stdout, stderr, false), logger, false)
+	code := cmd.run(context.Background(), []string{"nonexistent"}, ui.New(stdout, stderr, false), logger, startOptions{})
 	if code != 1 {
 		t.Fatalf("expected exit 1")
 	}
@@ -79,7 +96,7 @@ func TestStartCmdAlreadyRunning(t *testing.T) {
 	logger := logging.New(stdout, stderr, false)
 	cmd := defaultStartCommand()
 	cmd.isPortInUse = func(int) bool { return true }
-	code := cmd.run(context.Background(), []string{"myapp"}, ui.New(stdout, stderr, false), logger, false)
+	code := cmd.run(context.Background(), []string{"myapp"}, ui.New(stdout, stderr, false), logger, startOptions{})
 	if code != 0 {
 		t.Fatalf("expected exit 0")
 	}
@@ -130,11 +147,11 @@ type = "static"
 	logger := logging.New(stdout, stderr, false)
 	cmd := defaultStartCommand()
 	cmd.isPortInUse = func(int) bool { return false }
-	cmd.startStatic = func(ctx context.Context, runner serve.CommandRunner, port int, root string) (int, error) {
+	cmd.startStatic = func(ctx context.Context, runner serve.CommandRunner, dir string, port int, root string, opts serve.StaticOptions, probe serve.Probe) (int, error) {
 		staticStarted = true
 		return 1234, nil
 	}
-	code := cmd.run(context.Background(), []string{"myapp"}, ui.New(stdout, stderr, false), logger, false)
+	code := cmd.run(context.Background(), []string{"myapp"}, ui.New(stdout, stderr, false), logger, startOptions{})
 	if code != 0 {
 		t.Fatalf("expected exit 0, got %d", code)
 	}
@@ -145,3 +162,472 @@ type = "static"
 		t.Fatalf("expected success message")
 	}
 }
+
+func TestStartCmdSupervisesProcesses(t *testing.T) {
+	baseDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", baseDir)
+
+	projectDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectDir, ".justvibin"), []byte(`{"name":"myapp","template":"django","port":59990}`), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	templateDir := filepath.Join(baseDir, "justvibin", "templates", "django")
+	if err := os.MkdirAll(templateDir, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	manifestData := `[template]
+name = "django"
+description = "Test"
+
+[processes.web]
+command = "./manage.py runserver 0.0.0.0:$PORT"
+port_env = "PORT"
+route = true
+
+[processes.tailwind]
+command = "npx tailwindcss --watch"
+env = { NODE_ENV = "development" }
+
+[processes.worker]
+command = "./manage.py worker"
+port_env = "WORKER_PORT"
+
+[routes]
+"/jobs/*" = "worker"
+`
+	if err := os.WriteFile(filepath.Join(templateDir, "justvibin.toml"), []byte(manifestData), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	projectsPath := filepath.Join(baseDir, "justvibin", "projects.json")
+	projects := map[string]registry.Project{
+		"myapp": {Port: 59990, Path: projectDir, Template: "django"},
+	}
+	if err := registry.Save(projectsPath, projects); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	var supervised []serve.Process
+	pidFileSeen := false
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	logger := logging.New(stdout, stderr, false)
+	cmd := defaultStartCommand()
+	cmd.isPortInUse = func(int) bool { return false }
+	cmd.supervise = func(_ context.Context, sup serve.Supervisor) error {
+		supervised = sup.Processes
+		_, err := os.Stat(filepath.Join(projectDir, serve.DefaultPIDFile))
+		pidFileSeen = err == nil
+		return nil
+	}
+	code := cmd.run(context.Background(), []string{"myapp"}, ui.New(stdout, stderr, false), logger, startOptions{})
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	if len(supervised) != 3 {
+		t.Fatalf("expected 3 processes, got %#v", supervised)
+	}
+	byName := map[string]serve.Process{}
+	for _, process := range supervised {
+		byName[process.Name] = process
+	}
+	if !containsString(byName["web"].Env, "PORT=59990") {
+		t.Fatalf("expected routed process on project port, got %#v", byName["web"].Env)
+	}
+	if !containsString(byName["tailwind"].Env, "NODE_ENV=development") {
+		t.Fatalf("expected process env, got %#v", byName["tailwind"].Env)
+	}
+	project, _, _ := registry.Get(projectsPath, "myapp")
+	workerPort := project.Ports["worker"]
+	if workerPort == 0 || workerPort == 59990 {
+		t.Fatalf("expected worker to get its own port, got %d", workerPort)
+	}
+	if !containsString(byName["worker"].Env, fmt.Sprintf("WORKER_PORT=%d", workerPort)) {
+		t.Fatalf("expected worker port env, got %#v", byName["worker"].Env)
+	}
+	if project.Routes["/jobs/*"] != "worker" || project.ProcessPort("worker") != workerPort {
+		t.Fatalf("expected routes recorded in the registry, got %#v", project.Routes)
+	}
+	if !pidFileSeen {
+		t.Fatalf("expected pid file while supervising")
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, serve.DefaultPIDFile)); !os.IsNotExist(err) {
+		t.Fatalf("expected pid file removed after supervisor exits")
+	}
+}
+
+const routelessProcessesManifest = `[template]
+name = "app"
+description = "Test"
+
+[processes.worker]
+command = "./worker"
+
+[processes.tailwind]
+command = "npx tailwindcss --watch"
+`
+
+func TestStartCmdDetectsRunningRoutelessProcesses(t *testing.T) {
+	_, projectDir := writeStartFixture(t, routelessProcessesManifest)
+	// This test process stands in for the supervisor that is already running.
+	if err := serve.WritePIDFile(filepath.Join(projectDir, serve.DefaultPIDFile), os.Getpid()); err != nil {
+		t.Fatalf("write pid file: %v", err)
+	}
+
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	logger := logging.New(stdout, stderr, false)
+	cmd := defaultStartCommand()
+	cmd.isPortInUse = func(int) bool { return false }
+	cmd.supervise = func(context.Context, serve.Supervisor) error {
+		t.Fatalf("expected the running group not to be started again")
+		return nil
+	}
+	if code := cmd.run(context.Background(), []string{"myapp"}, ui.New(stdout, stderr, false), logger, startOptions{}); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "already running") {
+		t.Fatalf("expected already running message, got %q", stdout.String())
+	}
+}
+
+func TestStartCmdDetachRoutelessProcessesSkipsPortProbe(t *testing.T) {
+	_, projectDir := writeStartFixture(t, routelessProcessesManifest)
+
+	var sleeper *exec.Cmd
+	runner := &recordingStartRunner{}
+	runner.start = func() (*exec.Cmd, error) {
+		sleeper = exec.Command("sleep", "5")
+		if err := sleeper.Start(); err != nil {
+			return nil, err
+		}
+		return sleeper, nil
+	}
+	defer func() {
+		if sleeper != nil && sleeper.Process != nil {
+			_ = sleeper.Process.Kill()
+		}
+	}()
+
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	logger := logging.New(stdout, stderr, false)
+	cmd := defaultStartCommand()
+	cmd.isPortInUse = func(int) bool { return false }
+	cmd.executable = func() (string, error) { return "/usr/local/bin/justvibin", nil }
+	cmd.commandRunner = func(r serve.SystemRunner) serve.CommandRunner { return runner }
+	// Nothing listens on the project port; start must not wait for it.
+	if code := cmd.run(context.Background(), []string{"myapp"}, ui.New(stdout, stderr, false), logger, startOptions{Detach: true}); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	record, err := serve.ReadPIDFile(filepath.Join(projectDir, serve.DefaultPIDFile))
+	if err != nil || record.PID != sleeper.Process.Pid {
+		t.Fatalf("expected the detached group to keep running, got %+v (%v)", record, err)
+	}
+}
+
+func containsString(items []string, want string) bool {
+	for _, item := range items {
+		if item == want {
+			return true
+		}
+	}
+	return false
+}
+
+type recordingStartRunner struct {
+	config serve.SystemRunner
+	name   string
+	args   []string
+	start  func() (*exec.Cmd, error)
+}
+
+func (r *recordingStartRunner) Start(name string, args ...string) (*exec.Cmd, error) {
+	r.name = name
+	r.args = args
+	return r.start()
+}
+
+func writeStartFixture(t *testing.T, manifestData string) (string, string) {
+	t.Helper()
+	baseDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", baseDir)
+
+	projectDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectDir, ".justvibin"), []byte(`{"name":"myapp","template":"app","port":59991}`), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	templateDir := filepath.Join(baseDir, "justvibin", "templates", "app")
+	if err := os.MkdirAll(templateDir, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(templateDir, "justvibin.toml"), []byte(manifestData), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	projectsPath := filepath.Join(baseDir, "justvibin", "projects.json")
+	projects := map[string]registry.Project{
+		"myapp": {Port: 59991, Path: projectDir, Template: "app"},
+	}
+	if err := registry.Save(projectsPath, projects); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	return baseDir, projectDir
+}
+
+const startCommandManifest = `[template]
+name = "app"
+description = "Test"
+
+[serve]
+type = "command"
+dev = "./serve --dev"
+port_env = "APP_PORT"
+`
+
+func TestStartCmdForegroundRunsServerInProcessGroup(t *testing.T) {
+	baseDir, projectDir := writeStartFixture(t, startCommandManifest)
+
+	var got serve.Foreground
+	var runnerConfig serve.SystemRunner
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	logger := logging.New(stdout, stderr, false)
+	cmd := defaultStartCommand()
+	cmd.stdout = stdout
+	cmd.isPortInUse = func(int) bool { return false }
+	cmd.commandRunner = func(r serve.SystemRunner) serve.CommandRunner {
+		runnerConfig = r
+		return r
+	}
+	cmd.foreground = func(_ context.Context, fg serve.Foreground) error {
+		got = fg
+		fmt.Fprintln(runnerConfig.Stdout, "listening")
+		return nil
+	}
+	code := cmd.run(context.Background(), []string{"myapp"}, ui.New(stdout, stderr, false), logger, startOptions{Foreground: true})
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	if got.Name != "bash" || len(got.Args) != 2 || got.Args[1] != "./serve --dev" {
+		t.Fatalf("unexpected server command %q %q", got.Name, got.Args)
+	}
+	if got.Signals == nil {
+		t.Fatalf("expected signals to be forwarded")
+	}
+	if !runnerConfig.Setpgid || runnerConfig.Dir != projectDir || !containsString(runnerConfig.Env, "APP_PORT=59991") {
+		t.Fatalf("unexpected runner config %#v", runnerConfig)
+	}
+	if !strings.Contains(stdout.String(), "listening") {
+		t.Fatalf("expected server output on stdout, got %q", stdout.String())
+	}
+	data, err := os.ReadFile(filepath.Join(baseDir, "justvibin", "logs", "myapp.log"))
+	if err != nil || !strings.Contains(string(data), " listening\n") {
+		t.Fatalf("expected server output in project log, got %q (%v)", data, err)
+	}
+}
+
+func TestStartCmdDetachReexecsInNewSession(t *testing.T) {
+	baseDir, projectDir := writeStartFixture(t, startCommandManifest)
+
+	var sleeper *exec.Cmd
+	runner := &recordingStartRunner{}
+	runner.start = func() (*exec.Cmd, error) {
+		sleeper = exec.Command("sleep", "5")
+		if err := sleeper.Start(); err != nil {
+			return nil, err
+		}
+		return sleeper, nil
+	}
+	defer func() {
+		if sleeper != nil && sleeper.Process != nil {
+			_ = sleeper.Process.Kill()
+		}
+	}()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:59991")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	logger := logging.New(stdout, stderr, false)
+	cmd := defaultStartCommand()
+	cmd.isPortInUse = func(int) bool { return false }
+	cmd.executable = func() (string, error) { return "/usr/local/bin/justvibin", nil }
+	cmd.commandRunner = func(r serve.SystemRunner) serve.CommandRunner {
+		runner.config = r
+		return runner
+	}
+	code := cmd.run(context.Background(), []string{"myapp"}, ui.New(stdout, stderr, false), logger, startOptions{Detach: true, Prod: true})
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	if runner.name != "/usr/local/bin/justvibin" || strings.Join(runner.args, " ") != "start --foreground --detached --prod" {
+		t.Fatalf("unexpected re-exec %q %q", runner.name, runner.args)
+	}
+	if !runner.config.Setsid || runner.config.Dir != projectDir || runner.config.Stderr == nil {
+		t.Fatalf("unexpected runner config %#v", runner.config)
+	}
+	record, err := serve.ReadPIDFile(filepath.Join(projectDir, serve.DefaultPIDFile))
+	if err != nil || record.PID != sleeper.Process.Pid || record.Verify() != nil {
+		t.Fatalf("expected verified pid file with detached pid, got %+v (%v)", record, err)
+	}
+	logPath := filepath.Join(baseDir, "justvibin", "logs", "myapp.log")
+	if _, err := os.Stat(logPath); err != nil {
+		t.Fatalf("expected log file at %s", logPath)
+	}
+	if !strings.Contains(stdout.String(), logPath) {
+		t.Fatalf("expected log path in output, got %q", stdout.String())
+	}
+}
+
+func TestStartCmdWatchedServerRunsDetached(t *testing.T) {
+	_, projectDir := writeStartFixture(t, startCommandManifest+`
+[serve.watch]
+include = ["*.py"]
+`)
+
+	var sleeper *exec.Cmd
+	runner := &recordingStartRunner{}
+	runner.start = func() (*exec.Cmd, error) {
+		sleeper = exec.Command("sleep", "5")
+		if err := sleeper.Start(); err != nil {
+			return nil, err
+		}
+		return sleeper, nil
+	}
+	defer func() {
+		if sleeper != nil && sleeper.Process != nil {
+			_ = sleeper.Process.Kill()
+		}
+	}()
+	listener, err := net.Listen("tcp", "127.0.0.1:59991")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	logger := logging.New(stdout, stderr, false)
+	cmd := defaultStartCommand()
+	cmd.isPortInUse = func(int) bool { return false }
+	cmd.executable = func() (string, error) { return "/usr/local/bin/justvibin", nil }
+	cmd.commandRunner = func(r serve.SystemRunner) serve.CommandRunner {
+		runner.config = r
+		return runner
+	}
+	if code := cmd.run(context.Background(), []string{"myapp"}, ui.New(stdout, stderr, false), logger, startOptions{}); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	if strings.Join(runner.args, " ") != "start --foreground --detached" || runner.config.Dir != projectDir {
+		t.Fatalf("expected watched server to run detached, got %q", runner.args)
+	}
+
+	var got serve.Foreground
+	cmd.isRunning = func(string, int) bool { return false }
+	cmd.commandRunner = func(r serve.SystemRunner) serve.CommandRunner { return r }
+	cmd.foreground = func(_ context.Context, fg serve.Foreground) error {
+		got = fg
+		return nil
+	}
+	if code := cmd.run(context.Background(), []string{"myapp"}, ui.New(stdout, stderr, false), logger, startOptions{Foreground: true}); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	if got.Restart == nil || !strings.Contains(stdout.String(), "Watching *.py for changes") {
+		t.Fatalf("expected foreground server to restart on changes, got %q", stdout.String())
+	}
+}
+
+func TestStartCmdReportsServerThatDiesDuringStartup(t *testing.T) {
+	writeStartFixture(t, `[template]
+name = "app"
+description = "Test"
+
+[serve]
+type = "command"
+dev = "echo ImportError: no module named django; exit 1"
+`)
+
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	logger := logging.New(stdout, stderr, false)
+	cmd := defaultStartCommand()
+	cmd.stderr = stderr
+	cmd.isPortInUse = func(int) bool { return false }
+	code := cmd.run(context.Background(), []string{"myapp"}, ui.New(stdout, stderr, false), logger, startOptions{})
+	if code != 1 {
+		t.Fatalf("expected exit 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "server exited during startup") || !strings.Contains(stderr.String(), "ImportError: no module named django") {
+		t.Fatalf("expected failure and server output, got %q", stderr.String())
+	}
+	if strings.Contains(stdout.String(), "Started:") {
+		t.Fatalf("expected no success message, got %q", stdout.String())
+	}
+}
+
+func TestStartCmdServerOutputShowsInLogsSince(t *testing.T) {
+	_, projectDir := writeStartFixture(t, `[template]
+name = "app"
+description = "Test"
+
+[serve]
+type = "command"
+dev = "echo hello from server; exec sleep 5"
+`)
+	listener, err := net.Listen("tcp", "127.0.0.1:59991")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	logger := logging.New(stdout, stderr, false)
+	cmd := defaultStartCommand()
+	cmd.isPortInUse = func(int) bool { return false }
+	if code := cmd.run(context.Background(), []string{"myapp"}, ui.New(stdout, stderr, false), logger, startOptions{}); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	defer func() {
+		if record, err := serve.ReadPIDFile(filepath.Join(projectDir, serve.DefaultPIDFile)); err == nil {
+			_, _ = serve.Stop(record.PID, time.Second, nil)
+		}
+	}()
+
+	// The capture helper writes asynchronously.
+	var out strings.Builder
+	for deadline := time.Now().Add(3 * time.Second); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		out.Reset()
+		logs := defaultLogsCommand()
+		logs.stdout = &out
+		if code := logs.run(context.Background(), []string{"myapp"}, logging.New(&strings.Builder{}, &strings.Builder{}, false), logsOptions{Since: "10m"}); code != 0 {
+			t.Fatalf("logs: exit %d", code)
+		}
+		if strings.Contains(out.String(), "hello from server") {
+			break
+		}
+	}
+	line := strings.TrimSpace(out.String())
+	if !strings.HasSuffix(line, " hello from server") {
+		t.Fatalf("expected server output in logs --since, got %q", out.String())
+	}
+	if _, err := time.Parse(time.RFC3339, strings.Fields(line)[0]); err != nil {
+		t.Fatalf("expected a timestamped line, got %q", line)
+	}
+}
+
+func TestStartCmdRejectsForegroundWithDetach(t *testing.T) {
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	logger := logging.New(stdout, stderr, false)
+	cmd := defaultStartCommand()
+	code := cmd.run(context.Background(), []string{"myapp"}, ui.New(stdout, stderr, false), logger, startOptions{Foreground: true, Detach: true})
+	if code != 1 {
+		t.Fatalf("expected exit 1")
+	}
+}
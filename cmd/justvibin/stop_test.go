@@ -1,10 +1,15 @@
 package main
 
 import (
+	"fmt"
+	"net"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/alexcabrera/justvibin/internal/registry"
 	"github.com/alexcabrera/justvibin/internal/serve"
@@ -121,3 +126,161 @@ func TestStopCmdRemovesPIDFile(t *testing.T) {
 		t.Fatalf("expected PID file to be removed")
 	}
 }
+
+func TestStopCmdSkipsRecycledPID(t *testing.T) {
+	baseDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", baseDir)
+
+	unrelated := exec.Command("sleep", "5")
+	if err := unrelated.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer func() {
+		_ = unrelated.Process.Kill()
+		_ = unrelated.Wait()
+	}()
+
+	projectDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectDir, ".justvibin"), []byte(`{"name":"myapp","template":"hypertext","port":59999}`), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	pidFile := filepath.Join(projectDir, serve.DefaultPIDFile)
+	record := fmt.Sprintf(`{"pid":%d,"start_time":"from-before-reboot","cmdline":"bash -c ./serve"}`, unrelated.Process.Pid)
+	if err := os.WriteFile(pidFile, []byte(record), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	projectsPath := filepath.Join(baseDir, "justvibin", "projects.json")
+	if err := os.MkdirAll(filepath.Dir(projectsPath), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	projects := map[string]registry.Project{
+		"myapp": {Port: 59999, Path: projectDir, Template: "hypertext"},
+	}
+	if err := registry.Save(projectsPath, projects); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	resetRootFlags(t)
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(stderr)
+	rootCmd.SetArgs([]string{"stop", "myapp"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("expected exit 0, got %v", err)
+	}
+	if err := unrelated.Process.Signal(syscall.Signal(0)); err != nil {
+		t.Fatalf("expected unrelated process to survive: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "no longer belongs") {
+		t.Fatalf("expected stale PID warning, got %q", stdout.String())
+	}
+	if _, err := os.Stat(pidFile); !os.IsNotExist(err) {
+		t.Fatalf("expected stale PID file to be removed")
+	}
+}
+
+func TestStopCmdStopsServerFromLegacyPIDFile(t *testing.T) {
+	// The server inherits the listener, so it holds the project port.
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	socket, err := listener.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatalf("listener file: %v", err)
+	}
+	server := exec.Command("sleep", "5")
+	server.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	server.ExtraFiles = []*os.File{socket}
+	if err := server.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	_ = socket.Close()
+	_ = listener.Close()
+	exited := make(chan struct{})
+	go func() {
+		_ = server.Wait()
+		close(exited)
+	}()
+	defer func() { _ = server.Process.Kill() }()
+
+	stdout := runStopWithLegacyPIDFile(t, server.Process.Pid, port)
+	select {
+	case <-exited:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected the server from the legacy pid file to be stopped, got %q", stdout)
+	}
+	if !strings.Contains(stdout, "Stopped: myapp") {
+		t.Fatalf("expected stopped message, got %q", stdout)
+	}
+}
+
+func TestStopCmdSkipsLegacyPIDFileWithoutPort(t *testing.T) {
+	// A reused PID may lead its own process group, as shells do.
+	other := exec.Command("sleep", "5")
+	other.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := other.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	exited := make(chan struct{})
+	go func() {
+		_ = other.Wait()
+		close(exited)
+	}()
+	defer func() { _ = other.Process.Kill() }()
+
+	stdout := runStopWithLegacyPIDFile(t, other.Process.Pid, 59998)
+	select {
+	case <-exited:
+		t.Fatalf("expected an unrelated process to be left running")
+	case <-time.After(200 * time.Millisecond):
+	}
+	if !strings.Contains(stdout, "stale PID file removed") {
+		t.Fatalf("expected stale message, got %q", stdout)
+	}
+}
+
+// runStopWithLegacyPIDFile runs `stop myapp` for a project on port whose pid
+// file holds only pid, as versions before pid files recorded an identity
+// wrote, and returns stdout.
+func runStopWithLegacyPIDFile(t *testing.T, pid, port int) string {
+	t.Helper()
+	baseDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", baseDir)
+
+	projectDir := t.TempDir()
+	marker := fmt.Sprintf(`{"name":"myapp","template":"hypertext","port":%d}`, port)
+	if err := os.WriteFile(filepath.Join(projectDir, ".justvibin"), []byte(marker), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	pidFile := filepath.Join(projectDir, serve.DefaultPIDFile)
+	if err := os.WriteFile(pidFile, []byte(fmt.Sprint(pid)), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	projectsPath := filepath.Join(baseDir, "justvibin", "projects.json")
+	if err := os.MkdirAll(filepath.Dir(projectsPath), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	projects := map[string]registry.Project{
+		"myapp": {Port: port, Path: projectDir, Template: "hypertext"},
+	}
+	if err := registry.Save(projectsPath, projects); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	resetRootFlags(t)
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(stderr)
+	rootCmd.SetArgs([]string{"stop", "myapp"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("expected exit 0, got %v: %s", err, stderr.String())
+	}
+	if _, err := os.Stat(pidFile); !os.IsNotExist(err) {
+		t.Fatalf("expected pid file removed, got %v", err)
+	}
+	return stdout.String()
+}
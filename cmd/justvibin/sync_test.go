@@ -95,7 +95,7 @@ func TestSyncCmdEmptyDirectory(t *testing.T) {
 func newTestSyncCommand(t *testing.T) syncCommand {
 	t.Helper()
 	cmd := defaultSyncCommand()
-	cmd.generateCaddy = func(context.Context, execx.Runner, string, string) error { return nil }
+	cmd.generateCaddy = func(context.Context, execx.Runner, string, string) (bool, error) { return false, nil }
 	cmd.reloadProxy = func(context.Context, execx.Runner, string) error { return nil }
 	return cmd
 }
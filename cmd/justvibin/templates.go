@@ -18,6 +18,8 @@ type installedTemplate struct {
 	Description string `json:"description"`
 	ServeType   string `json:"type"`
 	Source      string `json:"source"`
+	Ref         string `json:"ref,omitempty"`
+	Commit      string `json:"commit,omitempty"`
 }
 
 func loadInstalledTemplates(templatesDir string) ([]installedTemplate, error) {
@@ -31,13 +33,13 @@ func loadInstalledTemplates(templatesDir string) ([]installedTemplate, error) {
 
 	templates := make([]installedTemplate, 0, len(entries))
 	for _, entry := range entries {
-		if !entry.IsDir() {
+		if !isTemplateDir(entry) {
 			continue
 		}
 		name := entry.Name()
 		templatePath := filepath.Join(templatesDir, name)
 		manifestPath := filepath.Join(templatePath, "justvibin.toml")
-		sourcePath := filepath.Join(templatePath, ".source")
+		sourcePath := filepath.Join(templatePath, sourceFileName)
 
 		description := "unknown"
 		serveType := "unknown"
@@ -53,11 +55,10 @@ func loadInstalledTemplates(templatesDir string) ([]installedTemplate, error) {
 			}
 		}
 
-		source := "unknown"
+		source := templateSource{URL: "unknown"}
 		if data, err := os.ReadFile(sourcePath); err == nil {
-			trimmed := strings.TrimSpace(string(data))
-			if trimmed != "" {
-				source = trimmed
+			if parsed := parseTemplateSource(data); parsed.URL != "" {
+				source = parsed
 			}
 		}
 
@@ -65,7 +66,9 @@ func loadInstalledTemplates(templatesDir string) ([]installedTemplate, error) {
 			Name:        name,
 			Description: description,
 			ServeType:   serveType,
-			Source:      source,
+			Source:      source.URL,
+			Ref:         source.Ref,
+			Commit:      source.Commit,
 		})
 	}
 
@@ -90,7 +93,8 @@ func templatesText(templates []installedTemplate, styled bool) string {
 				nameStyle.Render(fmt.Sprintf("  %s", tpl.Name)),
 				descStyle.Render(fmt.Sprintf("    %s", tpl.Description)),
 				metaStyle.Render(fmt.Sprintf("    Type: %s", tpl.ServeType)),
-				metaStyle.Render(fmt.Sprintf("    Source: %s", tpl.Source)),
+				metaStyle.Render(fmt.Sprintf("    Source: %s", tpl.source())),
+				metaStyle.Render(fmt.Sprintf("    Commit: %s", tpl.commit())),
 				"",
 			)
 		}
@@ -110,7 +114,8 @@ func templatesText(templates []installedTemplate, styled bool) string {
 			fmt.Sprintf("  %s", tpl.Name),
 			fmt.Sprintf("    %s", tpl.Description),
 			fmt.Sprintf("    Type: %s", tpl.ServeType),
-			fmt.Sprintf("    Source: %s", tpl.Source),
+			fmt.Sprintf("    Source: %s", tpl.source()),
+			fmt.Sprintf("    Commit: %s", tpl.commit()),
 			"",
 		)
 	}
@@ -124,6 +129,17 @@ func templatesText(templates []installedTemplate, styled bool) string {
 	return strings.Join(lines, "\n")
 }
 
+func (t installedTemplate) source() string {
+	return templateSource{URL: t.Source, Ref: t.Ref}.String()
+}
+
+func (t installedTemplate) commit() string {
+	if t.Commit == "" {
+		return "unknown"
+	}
+	return shortCommit(t.Commit)
+}
+
 func templatesJSON(templates []installedTemplate) (string, error) {
 	payload := make([]installedTemplate, 0, len(templates))
 	for _, tpl := range templates {
@@ -78,6 +78,33 @@ func TestTemplatesEmptyState(t *testing.T) {
 	}
 }
 
+func TestTemplatesTextShowsRefAndCommit(t *testing.T) {
+	baseDir := t.TempDir()
+	writeInstalledTemplate(t, baseDir, "hypertext", "desc", "static", string(templateSource{
+		URL:    "https://example.com/hypertext.git",
+		Ref:    "v1.2.0",
+		Commit: "3f9a1c2b4d5e",
+	}.encode()))
+	writeInstalledTemplate(t, baseDir, "legacy", "desc", "static", "https://example.com/legacy.git\n")
+
+	templates, err := loadInstalledTemplates(filepath.Join(baseDir, "justvibin", "templates"))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	output := templatesText(templates, false)
+	for _, want := range []string{
+		"Source: https://example.com/hypertext.git@v1.2.0\n    Commit: 3f9a1c2",
+		"Source: https://example.com/legacy.git\n    Commit: unknown",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in %s", want, output)
+		}
+	}
+	if templates[0].Commit != "3f9a1c2b4d5e" || templates[0].Ref != "v1.2.0" {
+		t.Fatalf("expected ref and commit in JSON fields, got %#v", templates[0])
+	}
+}
+
 func writeInstalledTemplate(t *testing.T, baseDir, name, description, serveType, source string) {
 	t.Helper()
 	templateDir := filepath.Join(baseDir, "justvibin", "templates", name)
@@ -132,7 +132,7 @@ func (c uninstallCommand) run(_ context.Context, args []string, console *ui.UI,
 	}
 	projects, err := c.loadProjects(projectsPath)
 	if err != nil {
-		logger.Error("Failed to load project registry")
+		logRegistryError(logger, err, "Failed to load project registry")
 		return 1
 	}
 
@@ -156,6 +156,12 @@ func (c uninstallCommand) run(_ context.Context, args []string, console *ui.UI,
 		logger.Error("Failed to remove template")
 		return 1
 	}
+	if _, err := os.Stat(previousTemplateDir(templatesDir, name)); err == nil {
+		if err := c.removeAll(previousTemplateDir(templatesDir, name)); err != nil {
+			logger.Error("Failed to remove template")
+			return 1
+		}
+	}
 	logger.Success(fmt.Sprintf("Removed template: %s", name))
 	return 0
 }
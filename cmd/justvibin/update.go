@@ -2,13 +2,16 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/alexcabrera/justvibin/internal/config"
 	execx "github.com/alexcabrera/justvibin/internal/exec"
 	"github.com/alexcabrera/justvibin/internal/logging"
+	"github.com/alexcabrera/justvibin/internal/manifest"
 	"github.com/alexcabrera/justvibin/internal/ui"
 )
 
@@ -46,14 +49,47 @@ func (c updateCommand) run(ctx context.Context, args []string, console *ui.UI, l
 	}
 
 	updateAll := false
+	rollback := false
 	name := ""
-	for _, arg := range args {
-		if arg == "--all" {
+	ref := ""
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--all":
 			updateAll = true
-		} else {
-			name = arg
+		case "--rollback":
+			rollback = true
+		case "--to":
+			if i+1 >= len(args) {
+				logger.Error("Missing value for --to")
+				return 1
+			}
+			ref = args[i+1]
+			i++
+		default:
+			name = args[i]
 		}
 	}
+	if updateAll && ref != "" {
+		logger.Error("--to cannot be combined with --all")
+		return 1
+	}
+	if rollback && (updateAll || ref != "") {
+		logger.Error("--rollback cannot be combined with --all or --to")
+		return 1
+	}
+
+	if rollback {
+		if name == "" {
+			logger.Error("Missing template name")
+			return 1
+		}
+		templatesDir, err := c.templatesDir()
+		if err != nil {
+			logger.Error("Failed to resolve templates directory")
+			return 1
+		}
+		return c.rollback(templatesDir, name, logger)
+	}
 
 	if !execx.CommandAvailable(c.runner, "git") {
 		logger.Error("git is required to update templates")
@@ -75,7 +111,7 @@ func (c updateCommand) run(ctx context.Context, args []string, console *ui.UI, l
 		return 1
 	}
 
-	return c.updateTemplate(ctx, templatesDir, name, logger, styled)
+	return c.updateTemplate(ctx, templatesDir, name, ref, logger, styled)
 }
 
 func (c updateCommand) updateAll(ctx context.Context, templatesDir string, logger *logging.Logger, styled bool) int {
@@ -92,11 +128,11 @@ func (c updateCommand) updateAll(ctx context.Context, templatesDir string, logge
 	updated := 0
 	failed := 0
 	for _, entry := range entries {
-		if !entry.IsDir() {
+		if !isTemplateDir(entry) {
 			continue
 		}
 		name := entry.Name()
-		if code := c.updateTemplate(ctx, templatesDir, name, logger, styled); code == 0 {
+		if code := c.updateTemplate(ctx, templatesDir, name, "", logger, styled); code == 0 {
 			updated++
 		} else {
 			failed++
@@ -116,7 +152,9 @@ func (c updateCommand) updateAll(ctx context.Context, templatesDir string, logge
 	return 0
 }
 
-func (c updateCommand) updateTemplate(ctx context.Context, templatesDir, name string, logger *logging.Logger, styled bool) int {
+// updateTemplate re-fetches a template at ref, or at the ref it was
+// installed from when ref is empty.
+func (c updateCommand) updateTemplate(ctx context.Context, templatesDir, name, ref string, logger *logging.Logger, styled bool) int {
 	templateDir := filepath.Join(templatesDir, name)
 
 	if _, err := os.Stat(templateDir); os.IsNotExist(err) {
@@ -124,19 +162,25 @@ func (c updateCommand) updateTemplate(ctx context.Context, templatesDir, name st
 		return 1
 	}
 
-	sourcePath := filepath.Join(templateDir, ".source")
+	sourcePath := filepath.Join(templateDir, sourceFileName)
 	sourceData, err := c.readFile(sourcePath)
 	if err != nil {
 		logger.Warn(fmt.Sprintf("No source URL for '%s' - cannot update", name))
 		return 1
 	}
-	sourceURL := string(sourceData)
-	if sourceURL == "" {
+	source := parseTemplateSource(sourceData)
+	if source.URL == "" {
 		logger.Warn(fmt.Sprintf("Empty source URL for '%s' - cannot update", name))
 		return 1
 	}
+	previous := source.Commit
+	if ref != "" {
+		source.Ref = ref
+	}
 
-	tmpDir, err := c.tempDir("", "justvibin-update-*")
+	// Clone next to the template so the swap below is a rename within one
+	// filesystem.
+	tmpDir, err := c.tempDir(templatesDir, "."+name+".update-*")
 	if err != nil {
 		logger.Error("Failed to create temp directory")
 		return 1
@@ -153,28 +197,153 @@ func (c updateCommand) updateTemplate(ctx context.Context, templatesDir, name st
 	}
 
 	if err := spin(fmt.Sprintf("Fetching %s", name), func() error {
-		return c.runner.Run(ctx, "git", "clone", "--depth", "1", sourceURL, tmpDir)
+		commit, err := cloneTemplate(ctx, c.runner, source, tmpDir)
+		source.Commit = commit
+		return err
 	}); err != nil {
 		logger.Error(fmt.Sprintf("Failed to fetch %s: %v", name, err))
 		return 1
 	}
 
-	if err := c.writeFile(filepath.Join(tmpDir, ".source"), sourceData, 0644); err != nil {
-		logger.Error("Failed to preserve source URL")
+	data, err := c.readFile(filepath.Join(tmpDir, "justvibin.toml"))
+	if err != nil {
+		logger.Error(fmt.Sprintf("Updated %s is missing justvibin.toml manifest", name))
+		return 1
+	}
+	parsed, warnings, err := manifest.ParseStrict(data)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Invalid justvibin.toml in %s: %v", name, err))
+		return 1
+	}
+	for _, warning := range warnings {
+		logger.Warn(fmt.Sprintf("%s: justvibin.toml: %s", name, warning))
+	}
+	if err := manifest.Validate(parsed); err != nil {
+		logger.Error(fmt.Sprintf("Invalid justvibin.toml in %s: %v", name, err))
 		return 1
 	}
 
-	if err := c.removeAll(templateDir); err != nil {
-		logger.Error(fmt.Sprintf("Failed to remove old template: %v", err))
+	if err := c.writeFile(filepath.Join(tmpDir, sourceFileName), source.encode(), 0644); err != nil {
+		logger.Error("Failed to preserve source URL")
 		return 1
 	}
 
-	if err := c.rename(tmpDir, templateDir); err != nil {
+	if err := c.swap(templatesDir, name, tmpDir); err != nil {
 		logger.Error(fmt.Sprintf("Failed to install updated template: %v", err))
 		return 1
 	}
 	tmpDir = ""
 
-	logger.Success(fmt.Sprintf("Updated: %s", name))
+	switch {
+	case previous != "" && source.Commit != "" && previous != source.Commit:
+		logger.Success(fmt.Sprintf("Updated: %s (%s → %s)", name, shortCommit(previous), shortCommit(source.Commit)))
+	case source.Commit != "":
+		logger.Success(fmt.Sprintf("Updated: %s (%s)", name, shortCommit(source.Commit)))
+	default:
+		logger.Success(fmt.Sprintf("Updated: %s", name))
+	}
+	return 0
+}
+
+// previousTemplateDir is where update keeps the version it replaced.
+func previousTemplateDir(templatesDir, name string) string {
+	return filepath.Join(templatesDir, "."+name+".previous")
+}
+
+// isTemplateDir reports whether entry is an installed template rather than
+// one of update's hidden staging or previous-version directories.
+func isTemplateDir(entry os.DirEntry) bool {
+	return entry.IsDir() && !strings.HasPrefix(entry.Name(), ".")
+}
+
+// swap installs newDir as the template name and keeps the current version
+// as its previous one. The older previous version is moved aside and only
+// deleted once both renames succeed; if either fails, everything is put
+// back, so neither the template nor its rollback target is lost.
+func (c updateCommand) swap(templatesDir, name, newDir string) error {
+	templateDir := filepath.Join(templatesDir, name)
+	previous := previousTemplateDir(templatesDir, name)
+	aside := filepath.Join(templatesDir, "."+name+".previous-old")
+	if err := c.removeAll(aside); err != nil {
+		return err
+	}
+	keptPrevious := true
+	if err := c.rename(previous, aside); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		keptPrevious = false
+	}
+	restorePrevious := func(err error) error {
+		if !keptPrevious {
+			return err
+		}
+		if restoreErr := c.rename(aside, previous); restoreErr != nil {
+			return fmt.Errorf("%w (restoring the previous version also failed: %v)", err, restoreErr)
+		}
+		return err
+	}
+	if err := c.rename(templateDir, previous); err != nil {
+		return restorePrevious(err)
+	}
+	if err := c.rename(newDir, templateDir); err != nil {
+		if restoreErr := c.rename(previous, templateDir); restoreErr != nil {
+			return fmt.Errorf("%w (restoring the current version also failed: %v)", err, restoreErr)
+		}
+		return restorePrevious(err)
+	}
+	if keptPrevious {
+		_ = c.removeAll(aside)
+	}
+	return nil
+}
+
+// rollback swaps a template with the version its last update replaced, so
+// running it again undoes the rollback.
+func (c updateCommand) rollback(templatesDir, name string, logger *logging.Logger) int {
+	templateDir := filepath.Join(templatesDir, name)
+	previous := previousTemplateDir(templatesDir, name)
+	if _, err := os.Stat(previous); err != nil {
+		logger.Error(fmt.Sprintf("No previous version of '%s' to roll back to", name))
+		return 1
+	}
+	if _, err := os.Stat(templateDir); err != nil {
+		logger.Error(fmt.Sprintf("Template '%s' not found", name))
+		return 1
+	}
+	current := c.commit(templateDir)
+
+	staging := filepath.Join(templatesDir, "."+name+".rollback")
+	if err := c.removeAll(staging); err != nil {
+		logger.Error(fmt.Sprintf("Failed to roll back %s: %v", name, err))
+		return 1
+	}
+	if err := c.rename(templateDir, staging); err != nil {
+		logger.Error(fmt.Sprintf("Failed to roll back %s: %v", name, err))
+		return 1
+	}
+	if err := c.rename(previous, templateDir); err != nil {
+		_ = c.rename(staging, templateDir)
+		logger.Error(fmt.Sprintf("Failed to roll back %s: %v", name, err))
+		return 1
+	}
+	if err := c.rename(staging, previous); err != nil {
+		logger.Warn(fmt.Sprintf("Rolled back, but could not keep the replaced version: %v", err))
+	}
+
+	restored := c.commit(templateDir)
+	if current != "" && restored != "" {
+		logger.Success(fmt.Sprintf("Rolled back: %s (%s → %s)", name, shortCommit(current), shortCommit(restored)))
+		return 0
+	}
+	logger.Success(fmt.Sprintf("Rolled back: %s", name))
 	return 0
 }
+
+func (c updateCommand) commit(templateDir string) string {
+	data, err := c.readFile(filepath.Join(templateDir, sourceFileName))
+	if err != nil {
+		return ""
+	}
+	return parseTemplateSource(data).Commit
+}
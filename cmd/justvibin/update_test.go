@@ -30,6 +30,16 @@ func (r updateRunner) LookPath(_ string) (string, error) {
 	return "/usr/bin/git", nil
 }
 
+// stageClone stands in for a fresh clone by writing a valid manifest to dir.
+func stageClone(t *testing.T, dir string) string {
+	t.Helper()
+	manifest := "[template]\nname = \"mytemplate\"\ndescription = \"desc\"\n\n[serve]\ntype = \"static\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "justvibin.toml"), []byte(manifest), 0644); err != nil {
+		t.Fatalf("write manifest: %v", err)
+	}
+	return dir
+}
+
 func TestUpdateCommandMissingTemplate(t *testing.T) {
 	logger := logging.New(&strings.Builder{}, &strings.Builder{}, false)
 	cmd := defaultUpdateCommand()
@@ -75,7 +85,7 @@ func TestUpdateCommandSuccess(t *testing.T) {
 	cmd := defaultUpdateCommand()
 	cmd.runner = updateRunner{}
 	cmd.templatesDir = func() (string, error) { return templatesDir, nil }
-	cmd.tempDir = func(_, _ string) (string, error) { return t.TempDir(), nil }
+	cmd.tempDir = func(_, _ string) (string, error) { return stageClone(t, t.TempDir()), nil }
 	cmd.rename = func(_, newPath string) error {
 		return os.MkdirAll(newPath, 0755)
 	}
@@ -125,7 +135,7 @@ func TestUpdateCommandAllWithTemplates(t *testing.T) {
 	cmd := defaultUpdateCommand()
 	cmd.runner = updateRunner{}
 	cmd.templatesDir = func() (string, error) { return templatesDir, nil }
-	cmd.tempDir = func(_, _ string) (string, error) { return t.TempDir(), nil }
+	cmd.tempDir = func(_, _ string) (string, error) { return stageClone(t, t.TempDir()), nil }
 	cmd.rename = func(_, newPath string) error {
 		return os.MkdirAll(newPath, 0755)
 	}
@@ -140,6 +150,202 @@ func TestUpdateCommandAllWithTemplates(t *testing.T) {
 	}
 }
 
+func TestUpdateCommandMovesToRef(t *testing.T) {
+	templatesDir := t.TempDir()
+	templateDir := filepath.Join(templatesDir, "mytemplate")
+	if err := os.MkdirAll(templateDir, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	old := templateSource{URL: "https://example.com/repo.git", Ref: "v1", Commit: "aaaaaaaaaa"}
+	if err := os.WriteFile(filepath.Join(templateDir, ".source"), old.encode(), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	stdout := &strings.Builder{}
+	logger := logging.New(stdout, &strings.Builder{}, false)
+	var calls []string
+	cmd := defaultUpdateCommand()
+	cmd.runner = gitRunner{calls: &calls, commit: "bbbbbbbbbb"}
+	cmd.templatesDir = func() (string, error) { return templatesDir, nil }
+	cmd.tempDir = func(_, _ string) (string, error) { return stageClone(t, t.TempDir()), nil }
+
+	code := cmd.run(context.Background(), []string{"mytemplate", "--to", "v2"}, ui.New(&strings.Builder{}, &strings.Builder{}, false), logger, false)
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d", code)
+	}
+	if !strings.Contains(calls[0], "clone --depth 1 --branch v2 https://example.com/repo.git") {
+		t.Fatalf("expected clone of v2, got %v", calls)
+	}
+	data, err := os.ReadFile(filepath.Join(templateDir, ".source"))
+	if err != nil {
+		t.Fatalf("read source: %v", err)
+	}
+	want := templateSource{URL: "https://example.com/repo.git", Ref: "v2", Commit: "bbbbbbbbbb"}
+	if got := parseTemplateSource(data); got != want {
+		t.Fatalf("expected %#v, got %#v", want, got)
+	}
+	if !strings.Contains(stdout.String(), "Updated: mytemplate (aaaaaaa → bbbbbbb)") {
+		t.Fatalf("expected commit change, got %q", stdout.String())
+	}
+}
+
+func writeUpdatableTemplate(t *testing.T, templatesDir, commit string) string {
+	t.Helper()
+	templateDir := filepath.Join(templatesDir, "mytemplate")
+	if err := os.MkdirAll(templateDir, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	source := templateSource{URL: "https://example.com/repo.git", Commit: commit}
+	if err := os.WriteFile(filepath.Join(templateDir, ".source"), source.encode(), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(templateDir, "old.txt"), []byte("old"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	return templateDir
+}
+
+func TestUpdateCommandRejectsInvalidManifest(t *testing.T) {
+	templatesDir := t.TempDir()
+	templateDir := writeUpdatableTemplate(t, templatesDir, "aaaaaaaaaa")
+
+	stderr := &strings.Builder{}
+	logger := logging.New(&strings.Builder{}, stderr, false)
+	var calls []string
+	cmd := defaultUpdateCommand()
+	cmd.runner = gitRunner{calls: &calls, commit: "bbbbbbbbbb"}
+	cmd.templatesDir = func() (string, error) { return templatesDir, nil }
+	cmd.tempDir = func(dir, pattern string) (string, error) {
+		staged, err := os.MkdirTemp(dir, pattern)
+		if err == nil {
+			err = os.WriteFile(filepath.Join(staged, "justvibin.toml"), []byte("[template]\nname = \"Bad Name\"\n"), 0644)
+		}
+		return staged, err
+	}
+
+	code := cmd.run(context.Background(), []string{"mytemplate"}, ui.New(&strings.Builder{}, stderr, false), logger, false)
+	if code != 1 {
+		t.Fatalf("expected exit 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "Invalid justvibin.toml in mytemplate") {
+		t.Fatalf("expected validation error, got %q", stderr.String())
+	}
+	if _, err := os.Stat(filepath.Join(templateDir, "old.txt")); err != nil {
+		t.Fatalf("expected installed template untouched: %v", err)
+	}
+	entries, _ := os.ReadDir(templatesDir)
+	if len(entries) != 1 {
+		t.Fatalf("expected staging directory removed, got %d entries", len(entries))
+	}
+}
+
+func TestUpdateCommandRestoresTemplateWhenSwapFails(t *testing.T) {
+	templatesDir := t.TempDir()
+	templateDir := writeUpdatableTemplate(t, templatesDir, "aaaaaaaaaa")
+	previous := filepath.Join(templatesDir, ".mytemplate.previous")
+	if err := os.MkdirAll(previous, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(previous, "older.txt"), []byte("older"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	logger := logging.New(&strings.Builder{}, &strings.Builder{}, false)
+	var calls []string
+	cmd := defaultUpdateCommand()
+	cmd.runner = gitRunner{calls: &calls, commit: "bbbbbbbbbb"}
+	cmd.templatesDir = func() (string, error) { return templatesDir, nil }
+	cmd.tempDir = func(dir, pattern string) (string, error) {
+		staged, err := os.MkdirTemp(dir, pattern)
+		if err != nil {
+			return "", err
+		}
+		return stageClone(t, staged), nil
+	}
+	cmd.rename = func(oldPath, newPath string) error {
+		if strings.Contains(oldPath, ".update-") {
+			return &os.LinkError{Op: "rename", Old: oldPath, New: newPath, Err: os.ErrPermission}
+		}
+		return os.Rename(oldPath, newPath)
+	}
+
+	code := cmd.run(context.Background(), []string{"mytemplate"}, ui.New(&strings.Builder{}, &strings.Builder{}, false), logger, false)
+	if code != 1 {
+		t.Fatalf("expected exit 1, got %d", code)
+	}
+	if data, err := os.ReadFile(filepath.Join(templateDir, "old.txt")); err != nil || string(data) != "old" {
+		t.Fatalf("expected installed template restored: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(previous, "older.txt")); err != nil || string(data) != "older" {
+		t.Fatalf("expected previous version restored: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(templatesDir, ".mytemplate.previous-old")); !os.IsNotExist(err) {
+		t.Fatalf("expected no leftover directory, got %v", err)
+	}
+}
+
+func TestUpdateCommandRollback(t *testing.T) {
+	templatesDir := t.TempDir()
+	templateDir := writeUpdatableTemplate(t, templatesDir, "aaaaaaaaaa")
+
+	stdout := &strings.Builder{}
+	logger := logging.New(stdout, &strings.Builder{}, false)
+	var calls []string
+	cmd := defaultUpdateCommand()
+	cmd.runner = gitRunner{calls: &calls, commit: "bbbbbbbbbb"}
+	cmd.templatesDir = func() (string, error) { return templatesDir, nil }
+	cmd.tempDir = func(dir, pattern string) (string, error) {
+		staged, err := os.MkdirTemp(dir, pattern)
+		if err != nil {
+			return "", err
+		}
+		return stageClone(t, staged), nil
+	}
+
+	code := cmd.run(context.Background(), []string{"--rollback", "mytemplate"}, ui.New(&strings.Builder{}, &strings.Builder{}, false), logger, false)
+	if code != 1 {
+		t.Fatalf("expected rollback without a previous version to fail")
+	}
+
+	if code := cmd.run(context.Background(), []string{"mytemplate"}, ui.New(&strings.Builder{}, &strings.Builder{}, false), logger, false); code != 0 {
+		t.Fatalf("expected exit 0, got %d", code)
+	}
+	if _, err := os.Stat(filepath.Join(templateDir, "old.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected updated template installed")
+	}
+	previous := filepath.Join(templatesDir, ".mytemplate.previous")
+	if _, err := os.Stat(filepath.Join(previous, "old.txt")); err != nil {
+		t.Fatalf("expected previous version kept: %v", err)
+	}
+	installed, err := loadInstalledTemplates(templatesDir)
+	if err != nil || len(installed) != 1 || installed[0].Name != "mytemplate" {
+		t.Fatalf("expected only the installed template listed, got %#v (%v)", installed, err)
+	}
+
+	if code := cmd.run(context.Background(), []string{"--rollback", "mytemplate"}, ui.New(&strings.Builder{}, &strings.Builder{}, false), logger, false); code != 0 {
+		t.Fatalf("expected exit 0, got %d", code)
+	}
+	if _, err := os.Stat(filepath.Join(templateDir, "old.txt")); err != nil {
+		t.Fatalf("expected previous version restored: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(previous, "justvibin.toml")); err != nil {
+		t.Fatalf("expected rolled back version kept for undo: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "Rolled back: mytemplate (bbbbbbb → aaaaaaa)") {
+		t.Fatalf("expected rollback message, got %q", stdout.String())
+	}
+
+	if code := cmd.run(context.Background(), []string{"mytemplate"}, ui.New(&strings.Builder{}, &strings.Builder{}, false), logger, false); code != 0 {
+		t.Fatalf("expected exit 0, got %d", code)
+	}
+	if _, err := os.Stat(filepath.Join(previous, "old.txt")); err != nil {
+		t.Fatalf("expected replaced version kept as previous: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(templatesDir, ".mytemplate.previous-old")); !os.IsNotExist(err) {
+		t.Fatalf("expected older previous version removed, got %v", err)
+	}
+}
+
 func resetUpdateFlags(t *testing.T) {
 	t.Helper()
 	resetRootFlags(t)
@@ -147,6 +353,14 @@ func resetUpdateFlags(t *testing.T) {
 		_ = f.Value.Set("false")
 		f.Changed = false
 	}
+	if f := updateCmd.Flags().Lookup("to"); f != nil {
+		_ = f.Value.Set("")
+		f.Changed = false
+	}
+	if f := updateCmd.Flags().Lookup("rollback"); f != nil {
+		_ = f.Value.Set("false")
+		f.Changed = false
+	}
 }
 
 func TestUpdateCmdMissingName(t *testing.T) {
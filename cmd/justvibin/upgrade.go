@@ -0,0 +1,334 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	osexec "os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alexcabrera/justvibin/internal/config"
+	execx "github.com/alexcabrera/justvibin/internal/exec"
+	"github.com/alexcabrera/justvibin/internal/logging"
+	"github.com/alexcabrera/justvibin/internal/manifest"
+	"github.com/alexcabrera/justvibin/internal/registry"
+)
+
+type upgradeCommand struct {
+	runner         execx.Runner
+	templatesDir   func() (string, error)
+	readMarker     func(string) (registry.Marker, error)
+	recordTemplate func(projectDir, version, commit string, variables map[string]string) (registry.Marker, error)
+	fetchTemplate  func(ctx context.Context, runner execx.Runner, source templateSource, dir string) (string, error)
+	mergeFile      func(ctx context.Context, ours, base, theirs string) ([]byte, bool, error)
+	tempDir        func(string, string) (string, error)
+	removeAll      func(string) error
+	spin           func(message string, work func() error) error
+}
+
+var upgradeCommandFactory = defaultUpgradeCommand
+
+func defaultUpgradeCommand() upgradeCommand {
+	return upgradeCommand{
+		runner:         execx.NewSystemRunner(),
+		templatesDir:   config.TemplatesDir,
+		readMarker:     registry.ReadMarker,
+		recordTemplate: registry.UpdateMarkerTemplate,
+		fetchTemplate:  cloneTemplate,
+		mergeFile:      gitMergeFile,
+		tempDir:        os.MkdirTemp,
+		removeAll:      os.RemoveAll,
+	}
+}
+
+var upgradeVerbs = map[string]string{"Add": "Added", "Update": "Updated", "Merge": "Merged", "Remove": "Removed"}
+
+// upgradeChange is what upgrade does, or would do, to one project file.
+type upgradeChange struct {
+	path     string
+	action   string
+	conflict string
+	content  []byte
+	mode     os.FileMode
+}
+
+func (c upgradeCommand) run(ctx context.Context, projectDir string, dryRun bool, logger *logging.Logger) int {
+	if !registry.MarkerExists(projectDir) {
+		logger.Error("Not a justvibin project directory")
+		logger.Info("Run 'justvibin new' or 'justvibin register' first")
+		return 1
+	}
+	marker, err := c.readMarker(projectDir)
+	if err != nil {
+		logger.Error("Failed to read project marker")
+		return 1
+	}
+	if marker.Template == "" || marker.TemplateCommit == "" {
+		logger.Error("Project has no recorded template revision")
+		logger.Info("Only projects created with a template installed from git can be upgraded")
+		return 1
+	}
+
+	templatesDir, err := c.templatesDir()
+	if err != nil {
+		logger.Error("Failed to resolve templates directory")
+		return 1
+	}
+	templateDir := filepath.Join(templatesDir, marker.Template)
+	sourceData, err := os.ReadFile(filepath.Join(templateDir, sourceFileName))
+	if err != nil {
+		logger.Error(fmt.Sprintf("Template '%s' is not installed", marker.Template))
+		return 1
+	}
+	source := parseTemplateSource(sourceData)
+	if source.Commit == "" {
+		logger.Error(fmt.Sprintf("Template '%s' has no recorded commit", marker.Template))
+		logger.Info(fmt.Sprintf("Run: justvibin update %s", marker.Template))
+		return 1
+	}
+	if source.Commit == marker.TemplateCommit {
+		logger.Info(fmt.Sprintf("Already up to date with %s (%s)", marker.Template, shortCommit(source.Commit)))
+		return 0
+	}
+	parsed := manifest.Manifest{}
+	if data, err := os.ReadFile(filepath.Join(templateDir, "justvibin.toml")); err == nil {
+		if parsed, err = manifest.Parse(data); err != nil {
+			logger.Error(fmt.Sprintf("Invalid justvibin.toml in %s: %v", marker.Template, err))
+			return 1
+		}
+	}
+	if !execx.CommandAvailable(c.runner, "git") {
+		logger.Error("git is required to upgrade projects")
+		return 1
+	}
+
+	tmpDir, err := c.tempDir("", "justvibin-upgrade-*")
+	if err != nil {
+		logger.Error("Failed to create temp directory")
+		return 1
+	}
+	defer func() { _ = c.removeAll(tmpDir) }()
+
+	spin := c.spin
+	if spin == nil {
+		spin = func(_ string, work func() error) error { return work() }
+	}
+	clone := filepath.Join(tmpDir, "clone")
+	if err := spin(fmt.Sprintf("Fetching %s at %s", marker.Template, shortCommit(marker.TemplateCommit)), func() error {
+		_, err := c.fetchTemplate(ctx, c.runner, templateSource{URL: source.URL, Ref: marker.TemplateCommit}, clone)
+		return err
+	}); err != nil {
+		logger.Error(fmt.Sprintf("Failed to fetch %s at %s: %v", marker.Template, shortCommit(marker.TemplateCommit), err))
+		return 1
+	}
+
+	// Render both revisions the way new rendered the project, so unchanged
+	// lines compare equal.
+	values := maps.Clone(marker.TemplateVariables)
+	if values == nil {
+		values = map[string]string{}
+	}
+	values["project_name"] = marker.Name
+	values["port"] = strconv.Itoa(marker.Port)
+	excludes := normalizeExcludes(parsed.Scaffold.Exclude)
+	base := filepath.Join(tmpDir, "base")
+	theirs := filepath.Join(tmpDir, "theirs")
+	for _, rev := range []struct{ src, dst string }{{clone, base}, {templateDir, theirs}} {
+		if err := copyTemplate(rev.src, rev.dst, excludes); err != nil {
+			logger.Error(fmt.Sprintf("Failed to prepare template: %v", err))
+			return 1
+		}
+		if err := renderProject(rev.dst, values); err != nil {
+			logger.Error(fmt.Sprintf("Failed to render template variables: %v", err))
+			return 1
+		}
+	}
+
+	changes, err := c.plan(ctx, projectDir, base, theirs)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to merge template changes: %v", err))
+		return 1
+	}
+
+	conflicts := 0
+	for _, change := range changes {
+		if !dryRun {
+			if err := applyUpgradeChange(projectDir, change); err != nil {
+				logger.Error(fmt.Sprintf("Failed to upgrade %s: %v", change.path, err))
+				return 1
+			}
+		}
+		label := upgradeVerbs[change.action]
+		if dryRun {
+			label = "Would " + strings.ToLower(change.action)
+		}
+		if change.conflict != "" {
+			conflicts++
+			logger.Warn(fmt.Sprintf("Conflict: %s (%s)", change.path, change.conflict))
+			continue
+		}
+		logger.Info(fmt.Sprintf("%s: %s", label, change.path))
+	}
+
+	target := fmt.Sprintf("%s %s", marker.Template, shortCommit(source.Commit))
+	if dryRun {
+		logger.Info(fmt.Sprintf("Dry run: %d change(s), %d conflict(s) upgrading to %s", len(changes)-conflicts, conflicts, target))
+		if conflicts > 0 {
+			return 1
+		}
+		return 0
+	}
+	if _, err := c.recordTemplate(projectDir, parsed.Template.Version, source.Commit, marker.TemplateVariables); err != nil {
+		logger.Error("Failed to record template revision in .justvibin marker")
+		return 1
+	}
+	if conflicts > 0 {
+		logger.Warn(fmt.Sprintf("Upgraded to %s with %d conflict(s); resolve them and review the changes", target, conflicts))
+		return 1
+	}
+	logger.Success(fmt.Sprintf("Upgraded to %s (%d change(s))", target, len(changes)))
+	return 0
+}
+
+// plan compares every file of the old (base) and new (theirs) template
+// revisions against the project and decides how to bring each change in.
+func (c upgradeCommand) plan(ctx context.Context, projectDir, base, theirs string) ([]upgradeChange, error) {
+	paths := map[string]bool{}
+	for _, root := range []string{base, theirs} {
+		files, err := listFiles(root)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range files {
+			paths[path] = true
+		}
+	}
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	var changes []upgradeChange
+	for _, rel := range sorted {
+		baseData, inBase := readIfExists(filepath.Join(base, rel))
+		theirData, inTheirs := readIfExists(filepath.Join(theirs, rel))
+		ourData, inOurs := readIfExists(filepath.Join(projectDir, rel))
+		if inBase == inTheirs && bytes.Equal(baseData, theirData) {
+			continue
+		}
+		if inOurs == inTheirs && bytes.Equal(ourData, theirData) {
+			continue
+		}
+		change := upgradeChange{path: rel}
+		if info, err := os.Stat(filepath.Join(theirs, rel)); err == nil {
+			change.mode = info.Mode().Perm()
+		}
+		switch {
+		case !inTheirs && inOurs && bytes.Equal(ourData, baseData):
+			change.action = "Remove"
+		case !inTheirs:
+			if inOurs {
+				change.conflict = "removed from the template but changed in the project; kept"
+			} else {
+				continue
+			}
+		case !inOurs && inBase:
+			change.conflict = "changed in the template but removed from the project"
+		case !inOurs:
+			change.action = "Add"
+			change.content = theirData
+		case !inBase:
+			change.conflict = "added in both the template and the project; kept the project's version"
+		case inBase && bytes.Equal(ourData, baseData):
+			change.action = "Update"
+			change.content = theirData
+		case isBinary(ourData) || isBinary(theirData) || isBinary(baseData):
+			change.conflict = "binary file changed in both; kept the project's version"
+		default:
+			merged, conflicted, err := c.mergeFile(ctx, filepath.Join(projectDir, rel), filepath.Join(base, rel), filepath.Join(theirs, rel))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", rel, err)
+			}
+			change.action = "Merge"
+			change.content = merged
+			if conflicted {
+				change.conflict = "resolve the conflict markers"
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+func applyUpgradeChange(projectDir string, change upgradeChange) error {
+	path := filepath.Join(projectDir, change.path)
+	switch change.action {
+	case "":
+		return nil
+	case "Remove":
+		return os.Remove(path)
+	}
+	mode := change.mode
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, change.content, mode)
+}
+
+func listFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return files, err
+}
+
+func readIfExists(path string) ([]byte, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// gitMergeFile merges the template's change from base to theirs into ours
+// with `git merge-file`, which exits with the number of conflicts.
+func gitMergeFile(ctx context.Context, ours, base, theirs string) ([]byte, bool, error) {
+	cmd := osexec.CommandContext(ctx, "git", "merge-file", "-p", "-L", "project", "-L", "old template", "-L", "new template", ours, base, theirs)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	var exitErr *osexec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 && exitErr.ExitCode() < 128 {
+		return out, true, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("git merge-file: %v %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return out, false, nil
+}
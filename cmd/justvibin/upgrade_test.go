@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	execx "github.com/alexcabrera/justvibin/internal/exec"
+	"github.com/alexcabrera/justvibin/internal/logging"
+	"github.com/alexcabrera/justvibin/internal/registry"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+}
+
+func TestUpgradeCommandMergesTemplateChanges(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	templatesDir := t.TempDir()
+	templateDir := filepath.Join(templatesDir, "tpl")
+	writeFiles(t, templateDir, map[string]string{
+		"justvibin.toml":       "[template]\nname = \"tpl\"\ndescription = \"d\"\nversion = \"2.0.0\"\n\n[serve]\ntype = \"static\"\n",
+		"{{project_name}}.txt": "bye {{project_name}}\n",
+		"merged.txt":           "1\n2-new\n3\n4\n5\n",
+		"conflict.txt":         "new\n",
+		"added.txt":            "added\n",
+		"same.txt":             "same\n",
+	})
+	source := templateSource{URL: "https://example.com/tpl.git", Ref: "main", Commit: "2222222222"}
+	writeFiles(t, templateDir, map[string]string{".source": string(source.encode())})
+
+	projectDir := t.TempDir()
+	project := map[string]string{
+		"shop.txt":     "hello shop\n",
+		"merged.txt":   "1\n2\n3\n4\n5-ours\n",
+		"conflict.txt": "ours\n",
+		"removed.txt":  "gone\n",
+		"same.txt":     "same, edited\n",
+	}
+	writeFiles(t, projectDir, project)
+	if _, err := registry.WriteMarker(projectDir, "shop", "tpl", 4000); err != nil {
+		t.Fatalf("marker: %v", err)
+	}
+	if _, err := registry.UpdateMarkerTemplate(projectDir, "1.0.0", "1111111111", nil); err != nil {
+		t.Fatalf("marker: %v", err)
+	}
+
+	var fetched templateSource
+	var calls []string
+	cmd := defaultUpgradeCommand()
+	cmd.runner = gitRunner{calls: &calls}
+	cmd.templatesDir = func() (string, error) { return templatesDir, nil }
+	cmd.fetchTemplate = func(_ context.Context, _ execx.Runner, source templateSource, dir string) (string, error) {
+		fetched = source
+		writeFiles(t, dir, map[string]string{
+			"{{project_name}}.txt": "hello {{project_name}}\n",
+			"merged.txt":           "1\n2\n3\n4\n5\n",
+			"conflict.txt":         "old\n",
+			"removed.txt":          "gone\n",
+			"same.txt":             "same\n",
+		})
+		return source.Ref, nil
+	}
+
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	logger := logging.New(stdout, stderr, false)
+	if code := cmd.run(context.Background(), projectDir, true, logger); code != 1 {
+		t.Fatalf("expected dry run to report the conflict, got %d", code)
+	}
+	if fetched.URL != source.URL || fetched.Ref != "1111111111" {
+		t.Fatalf("expected the old revision fetched, got %#v", fetched)
+	}
+	for _, want := range []string{"Would add: added.txt", "Would merge: merged.txt", "Would remove: removed.txt", "Would update: shop.txt"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Fatalf("expected %q in %s", want, stdout.String())
+		}
+	}
+	if !strings.Contains(stdout.String(), "Conflict: conflict.txt") {
+		t.Fatalf("expected conflict reported, got %s", stdout.String())
+	}
+	for name, content := range project {
+		if data, _ := os.ReadFile(filepath.Join(projectDir, name)); string(data) != content {
+			t.Fatalf("dry run changed %s to %q", name, data)
+		}
+	}
+
+	if code := cmd.run(context.Background(), projectDir, false, logger); code != 1 {
+		t.Fatalf("expected exit 1 for conflicts, got %d", code)
+	}
+	want := map[string]string{
+		"shop.txt":   "bye shop\n",
+		"merged.txt": "1\n2-new\n3\n4\n5-ours\n",
+		"added.txt":  "added\n",
+		"same.txt":   "same, edited\n",
+	}
+	for name, content := range want {
+		if data, _ := os.ReadFile(filepath.Join(projectDir, name)); string(data) != content {
+			t.Fatalf("expected %s to be %q, got %q", name, content, data)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, "removed.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected removed.txt deleted")
+	}
+	if data, _ := os.ReadFile(filepath.Join(projectDir, "conflict.txt")); !strings.Contains(string(data), "<<<<<<< project\nours\n=======\nnew\n>>>>>>> new template") {
+		t.Fatalf("expected conflict markers, got %q", data)
+	}
+	marker, err := registry.ReadMarker(projectDir)
+	if err != nil {
+		t.Fatalf("read marker: %v", err)
+	}
+	if marker.TemplateCommit != "2222222222" || marker.TemplateVersion != "2.0.0" {
+		t.Fatalf("expected marker moved to the new revision, got %#v", marker)
+	}
+}
+
+func TestUpgradeCommandNeedsRecordedRevision(t *testing.T) {
+	projectDir := t.TempDir()
+	if _, err := registry.WriteMarker(projectDir, "shop", "tpl", 4000); err != nil {
+		t.Fatalf("marker: %v", err)
+	}
+	stderr := &strings.Builder{}
+	logger := logging.New(&strings.Builder{}, stderr, false)
+	if code := defaultUpgradeCommand().run(context.Background(), projectDir, false, logger); code != 1 {
+		t.Fatalf("expected exit 1")
+	}
+	if !strings.Contains(stderr.String(), "no recorded template revision") {
+		t.Fatalf("unexpected output %q", stderr.String())
+	}
+}
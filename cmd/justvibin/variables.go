@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/alexcabrera/justvibin/internal/manifest"
+	"github.com/charmbracelet/huh"
+)
+
+// binarySniffLen is how much of a file is checked for NUL bytes, the same
+// heuristic git uses to tell binary files apart.
+const binarySniffLen = 8000
+
+type variablePrompt func(name string, variable manifest.Variable, value string) (string, error)
+
+// parseVarFlag splits a --var key=value argument.
+func parseVarFlag(arg string) (string, string, error) {
+	key, value, ok := strings.Cut(arg, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return "", "", fmt.Errorf("invalid --var %q, expected key=value", arg)
+	}
+	return key, value, nil
+}
+
+// resolveVariables picks a value for every variable a template declares:
+// from --var, else from the prompt when interactive, else its default.
+// Defaults may use the builtin placeholders, e.g. "{{project_name}}_db".
+func resolveVariables(variables map[string]manifest.Variable, given, builtins map[string]string, interactive bool, prompt variablePrompt) (map[string]string, error) {
+	for key := range given {
+		if _, ok := variables[key]; !ok {
+			return nil, fmt.Errorf("unknown variable %q", key)
+		}
+	}
+	values := map[string]string{}
+	for _, name := range manifest.VariableNames(manifest.Manifest{Variables: variables}) {
+		variable := variables[name]
+		value, ok := given[name]
+		if !ok {
+			value = renderPlaceholders(variable.Default, builtins)
+			if interactive {
+				answer, err := prompt(name, variable, value)
+				if err != nil {
+					return nil, err
+				}
+				value = answer
+			} else if variable.Default == "" {
+				return nil, fmt.Errorf("variable %q is required, pass --var %s=<value>", name, name)
+			}
+		}
+		if err := checkVariable(name, variable, value); err != nil {
+			return nil, err
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+func checkVariable(name string, variable manifest.Variable, value string) error {
+	if variable.Pattern == "" {
+		return nil
+	}
+	pattern, err := regexp.Compile(variable.Pattern)
+	if err != nil {
+		return fmt.Errorf("variable %q: %w", name, err)
+	}
+	if !pattern.MatchString(value) {
+		return fmt.Errorf("variable %q must match %s", name, variable.Pattern)
+	}
+	return nil
+}
+
+func promptVariable(name string, variable manifest.Variable, value string) (string, error) {
+	title := variable.Prompt
+	if title == "" {
+		title = name
+	}
+	input := huh.NewInput().Title(title).Value(&value).Validate(func(answer string) error {
+		return checkVariable(name, variable, answer)
+	})
+	if err := input.Run(); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// renderPlaceholders replaces {{name}} for every name in values. Other
+// double-brace expressions, such as Django or Jinja tags, are left alone.
+func renderPlaceholders(text string, values map[string]string) string {
+	if !strings.Contains(text, "{{") {
+		return text
+	}
+	pairs := make([]string, 0, len(values)*2)
+	for name, value := range values {
+		pairs = append(pairs, "{{"+name+"}}", value)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// renderProject renders placeholders in the contents of every text file of
+// a freshly copied project and in its file and directory names.
+func renderProject(root string, values map[string]string) error {
+	var renames []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return fs.SkipDir
+		}
+		if path != root && renderPlaceholders(d.Name(), values) != d.Name() {
+			renames = append(renames, path)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		return renderFile(path, values)
+	})
+	if err != nil {
+		return err
+	}
+	// Rename the deepest paths first so parent directories still match.
+	sort.Slice(renames, func(i, j int) bool { return len(renames[i]) > len(renames[j]) })
+	for _, path := range renames {
+		target := filepath.Join(filepath.Dir(path), renderPlaceholders(filepath.Base(path), values))
+		if _, err := os.Lstat(target); err == nil {
+			return fmt.Errorf("cannot rename %s: %s already exists", path, target)
+		}
+		if err := os.Rename(path, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isBinary(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), binarySniffLen)], 0) >= 0
+}
+
+func renderFile(path string, values map[string]string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if isBinary(data) {
+		return nil
+	}
+	rendered := renderPlaceholders(string(data), values)
+	if rendered == string(data) {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(rendered), info.Mode().Perm())
+}
@@ -0,0 +1,99 @@
+package certs
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// ErrNoCA is returned when the proxy has not created its CA yet.
+var ErrNoCA = errors.New("local CA has not been created yet")
+
+// Root is a CA root certificate and where it is stored.
+type Root struct {
+	Path string
+	Cert *x509.Certificate
+	PEM  []byte
+}
+
+// ReadRoot loads the PEM certificate at path.
+func ReadRoot(path string) (Root, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Root{}, fmt.Errorf("%w: %s", ErrNoCA, path)
+		}
+		return Root{}, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return Root{}, fmt.Errorf("%s is not a PEM certificate", path)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return Root{}, err
+	}
+	return Root{Path: path, Cert: cert, PEM: pem.EncodeToMemory(block)}, nil
+}
+
+// Name is the CA's common name, also used as its nickname in NSS databases.
+func (r Root) Name() string {
+	if r.Cert.Subject.CommonName != "" {
+		return r.Cert.Subject.CommonName
+	}
+	return "justvibin Local CA"
+}
+
+// Fingerprint is the SHA-256 fingerprint in colon-separated hex.
+func (r Root) Fingerprint() string {
+	sum := sha256.Sum256(r.Cert.Raw)
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+// ExpiresIn is how long until the root expires.
+func (r Root) ExpiresIn(now time.Time) time.Duration {
+	return r.Cert.NotAfter.Sub(now)
+}
+
+// SystemTrusted reports whether the operating system trusts the root.
+func (r Root) SystemTrusted() bool {
+	_, err := r.Cert.Verify(x509.VerifyOptions{})
+	return err == nil
+}
+
+// CaddyRootPath is where Caddy keeps the root of its local CA.
+func CaddyRootPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	data := filepath.Join(home, ".local", "share")
+	if runtime.GOOS == "darwin" {
+		data = filepath.Join(home, "Library", "Application Support")
+	} else if xdg := os.Getenv("XDG_DATA_HOME"); xdg != "" {
+		data = xdg
+	}
+	dir := "caddy"
+	if runtime.GOOS == "darwin" {
+		dir = "Caddy"
+	}
+	return filepath.Join(data, dir, "pki", "authorities", "local", "root.crt"), nil
+}
+
+// CaddySiteCertsDir is where Caddy stores the site certificates its local CA
+// issued, given the root path from CaddyRootPath.
+func CaddySiteCertsDir(rootPath string) string {
+	data := filepath.Dir(filepath.Dir(filepath.Dir(filepath.Dir(rootPath))))
+	return filepath.Join(data, "certificates", "local")
+}
@@ -0,0 +1,197 @@
+package certs
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeRunner struct {
+	calls   []string
+	missing map[string]bool
+	failing map[string]bool
+}
+
+func (f *fakeRunner) Run(_ context.Context, name string, args ...string) error {
+	call := strings.Join(append([]string{name}, args...), " ")
+	f.calls = append(f.calls, call)
+	if f.failing[args[0]] {
+		return errors.New("failed")
+	}
+	return nil
+}
+
+func (f *fakeRunner) Output(_ context.Context, _ string, _ ...string) (string, error) {
+	return "", nil
+}
+
+func (f *fakeRunner) LookPath(name string) (string, error) {
+	if f.missing[name] {
+		return "", errors.New("not found")
+	}
+	return "/usr/bin/" + name, nil
+}
+
+func writeRoot(t *testing.T, dir string, notAfter time.Time) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Local CA"},
+		NotBefore:             notAfter.Add(-time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("certificate: %v", err)
+	}
+	path := filepath.Join(dir, "root.crt")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	return path
+}
+
+func TestReadRoot(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := ReadRoot(filepath.Join(dir, "root.crt")); !errors.Is(err, ErrNoCA) {
+		t.Fatalf("expected ErrNoCA, got %v", err)
+	}
+
+	notAfter := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+	root, err := ReadRoot(writeRoot(t, dir, notAfter))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if root.Name() != "Test Local CA" {
+		t.Fatalf("unexpected name %q", root.Name())
+	}
+	if fp := root.Fingerprint(); len(fp) != 95 || strings.Count(fp, ":") != 31 {
+		t.Fatalf("unexpected fingerprint %q", fp)
+	}
+	if left := root.ExpiresIn(notAfter.Add(-time.Hour)); left != time.Hour {
+		t.Fatalf("expected 1h left, got %s", left)
+	}
+	if root.SystemTrusted() {
+		t.Fatalf("expected a fresh root not to be system trusted")
+	}
+
+	bad := filepath.Join(dir, "bad.crt")
+	_ = os.WriteFile(bad, []byte("nope"), 0644)
+	if _, err := ReadRoot(bad); err == nil {
+		t.Fatalf("expected error for non-PEM file")
+	}
+}
+
+func TestLinuxStoreCommands(t *testing.T) {
+	root, err := ReadRoot(writeRoot(t, t.TempDir(), time.Now().Add(time.Hour)))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	ctx := context.Background()
+
+	runner := &fakeRunner{}
+	store := linuxStore{runner: runner}
+	if err := store.Trust(ctx, root); err != nil {
+		t.Fatalf("trust: %v", err)
+	}
+	if err := store.Untrust(ctx, root); err != nil {
+		t.Fatalf("untrust: %v", err)
+	}
+	anchor := anchorPath(root)
+	want := []string{
+		"sudo install -m 0644 " + root.Path + " " + anchor,
+		"sudo update-ca-certificates",
+		"sudo rm -f " + anchor,
+		"sudo update-ca-certificates --fresh",
+	}
+	if strings.Join(runner.calls, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected calls %q", runner.calls)
+	}
+
+	runner = &fakeRunner{missing: map[string]bool{"update-ca-certificates": true}}
+	store = linuxStore{runner: runner}
+	_ = store.Trust(ctx, root)
+	_ = store.Untrust(ctx, root)
+	want = []string{
+		"sudo trust anchor --store " + root.Path,
+		"sudo trust anchor --remove " + root.Path,
+	}
+	if strings.Join(runner.calls, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected calls %q", runner.calls)
+	}
+
+	runner = &fakeRunner{missing: map[string]bool{"update-ca-certificates": true, "trust": true}}
+	if err := (linuxStore{runner: runner}).Trust(ctx, root); err == nil {
+		t.Fatalf("expected error without trust tools")
+	}
+}
+
+func TestStoresFindNSSDatabases(t *testing.T) {
+	home := t.TempDir()
+	for _, dir := range []string{
+		filepath.Join(home, ".pki", "nssdb"),
+		filepath.Join(home, ".mozilla", "firefox", "abc.default"),
+		filepath.Join(home, ".mozilla", "firefox", "no-db"),
+	} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if filepath.Base(dir) != "no-db" {
+			_ = os.WriteFile(filepath.Join(dir, "cert9.db"), nil, 0644)
+		}
+	}
+
+	var names []string
+	for _, store := range storesFor("linux", home, &fakeRunner{}) {
+		names = append(names, store.Name())
+	}
+	if got := strings.Join(names, ", "); got != "System, Chrome, Firefox (abc.default)" {
+		t.Fatalf("unexpected stores %q", got)
+	}
+	if stores := storesFor("darwin", home, &fakeRunner{}); len(stores) != 1 || stores[0].Name() != "System keychain" {
+		t.Fatalf("expected only the keychain on macOS, got %v", stores)
+	}
+}
+
+func TestNSSStoreCommands(t *testing.T) {
+	root, err := ReadRoot(writeRoot(t, t.TempDir(), time.Now().Add(time.Hour)))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	ctx := context.Background()
+	runner := &fakeRunner{failing: map[string]bool{"-L": true}}
+	store := nssStore{runner: runner, name: "Chrome", dir: "/home/me/.pki/nssdb"}
+	if store.Trusted(ctx, root) {
+		t.Fatalf("expected listing failure to mean untrusted")
+	}
+	if err := store.Trust(ctx, root); err != nil {
+		t.Fatalf("trust: %v", err)
+	}
+	want := "certutil -A -d sql:/home/me/.pki/nssdb -t C,, -n Test Local CA -i " + root.Path
+	if runner.calls[1] != want {
+		t.Fatalf("expected %q, got %q", want, runner.calls[1])
+	}
+
+	missing := nssStore{runner: &fakeRunner{missing: map[string]bool{"certutil": true}}, name: "Chrome"}
+	if err := missing.Trust(ctx, root); err == nil || !strings.Contains(err.Error(), "libnss3-tools") {
+		t.Fatalf("expected install hint, got %v", err)
+	}
+}
@@ -0,0 +1,179 @@
+package certs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	execx "github.com/alexcabrera/justvibin/internal/exec"
+)
+
+// Store is a trust store a CA root can be installed into.
+type Store interface {
+	Name() string
+	Trusted(ctx context.Context, root Root) bool
+	Trust(ctx context.Context, root Root) error
+	Untrust(ctx context.Context, root Root) error
+}
+
+const (
+	linuxAnchorDir = "/usr/local/share/ca-certificates"
+	macKeychain    = "/Library/Keychains/System.keychain"
+)
+
+// Stores returns the system trust store, always first, and every NSS
+// database (Firefox profiles, and Chrome on Linux) found for the current
+// user.
+func Stores(runner execx.Runner) ([]Store, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	return storesFor(runtime.GOOS, home, runner), nil
+}
+
+func storesFor(goos, home string, runner execx.Runner) []Store {
+	var stores []Store
+	if goos == "darwin" {
+		stores = append(stores, macStore{runner: runner})
+	} else {
+		stores = append(stores, linuxStore{runner: runner})
+	}
+	for _, db := range nssDatabases(goos, home) {
+		stores = append(stores, nssStore{runner: runner, name: db.name, dir: db.dir})
+	}
+	return stores
+}
+
+type nssDatabase struct {
+	name string
+	dir  string
+}
+
+func nssDatabases(goos, home string) []nssDatabase {
+	var dbs []nssDatabase
+	profiles := []string{filepath.Join(home, "Library", "Application Support", "Firefox", "Profiles")}
+	if goos != "darwin" {
+		if _, err := os.Stat(filepath.Join(home, ".pki", "nssdb", "cert9.db")); err == nil {
+			dbs = append(dbs, nssDatabase{name: "Chrome", dir: filepath.Join(home, ".pki", "nssdb")})
+		}
+		profiles = []string{
+			filepath.Join(home, ".mozilla", "firefox"),
+			filepath.Join(home, "snap", "firefox", "common", ".mozilla", "firefox"),
+		}
+	}
+	for _, dir := range profiles {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*", "cert9.db"))
+		for _, match := range matches {
+			profile := filepath.Dir(match)
+			dbs = append(dbs, nssDatabase{name: "Firefox (" + filepath.Base(profile) + ")", dir: profile})
+		}
+	}
+	return dbs
+}
+
+// linuxStore installs roots with update-ca-certificates (Debian, Ubuntu) or
+// p11-kit's trust anchor (Fedora, Arch).
+type linuxStore struct {
+	runner execx.Runner
+}
+
+func (linuxStore) Name() string { return "System" }
+
+func (linuxStore) Trusted(_ context.Context, root Root) bool {
+	return root.SystemTrusted()
+}
+
+func (s linuxStore) Trust(ctx context.Context, root Root) error {
+	switch {
+	case execx.CommandAvailable(s.runner, "update-ca-certificates"):
+		if err := s.runner.Run(ctx, "sudo", "install", "-m", "0644", root.Path, anchorPath(root)); err != nil {
+			return err
+		}
+		return s.runner.Run(ctx, "sudo", "update-ca-certificates")
+	case execx.CommandAvailable(s.runner, "trust"):
+		return s.runner.Run(ctx, "sudo", "trust", "anchor", "--store", root.Path)
+	default:
+		return errors.New("neither update-ca-certificates nor trust is installed")
+	}
+}
+
+func (s linuxStore) Untrust(ctx context.Context, root Root) error {
+	switch {
+	case execx.CommandAvailable(s.runner, "update-ca-certificates"):
+		if err := s.runner.Run(ctx, "sudo", "rm", "-f", anchorPath(root)); err != nil {
+			return err
+		}
+		return s.runner.Run(ctx, "sudo", "update-ca-certificates", "--fresh")
+	case execx.CommandAvailable(s.runner, "trust"):
+		return s.runner.Run(ctx, "sudo", "trust", "anchor", "--remove", root.Path)
+	default:
+		return errors.New("neither update-ca-certificates nor trust is installed")
+	}
+}
+
+// anchorPath names the installed copy after the root's fingerprint so a
+// rotated CA can still be removed.
+func anchorPath(root Root) string {
+	id := strings.ToLower(strings.ReplaceAll(root.Fingerprint(), ":", ""))[:16]
+	return filepath.Join(linuxAnchorDir, "justvibin-"+id+".crt")
+}
+
+type macStore struct {
+	runner execx.Runner
+}
+
+func (macStore) Name() string { return "System keychain" }
+
+func (macStore) Trusted(_ context.Context, root Root) bool {
+	return root.SystemTrusted()
+}
+
+func (s macStore) Trust(ctx context.Context, root Root) error {
+	return s.runner.Run(ctx, "sudo", "security", "add-trusted-cert", "-d", "-r", "trustRoot", "-k", macKeychain, root.Path)
+}
+
+func (s macStore) Untrust(ctx context.Context, root Root) error {
+	return s.runner.Run(ctx, "sudo", "security", "remove-trusted-cert", "-d", root.Path)
+}
+
+// nssStore is a Firefox or Chrome certificate database, managed with
+// certutil from the NSS tools.
+type nssStore struct {
+	runner execx.Runner
+	name   string
+	dir    string
+}
+
+func (s nssStore) Name() string { return s.name }
+
+func (s nssStore) Trusted(ctx context.Context, root Root) bool {
+	if !execx.CommandAvailable(s.runner, "certutil") {
+		return false
+	}
+	return s.runner.Run(ctx, "certutil", "-L", "-d", "sql:"+s.dir, "-n", root.Name()) == nil
+}
+
+func (s nssStore) Trust(ctx context.Context, root Root) error {
+	if err := s.requireCertutil(); err != nil {
+		return err
+	}
+	return s.runner.Run(ctx, "certutil", "-A", "-d", "sql:"+s.dir, "-t", "C,,", "-n", root.Name(), "-i", root.Path)
+}
+
+func (s nssStore) Untrust(ctx context.Context, root Root) error {
+	if err := s.requireCertutil(); err != nil {
+		return err
+	}
+	return s.runner.Run(ctx, "certutil", "-D", "-d", "sql:"+s.dir, "-n", root.Name())
+}
+
+func (s nssStore) requireCertutil() error {
+	if execx.CommandAvailable(s.runner, "certutil") {
+		return nil
+	}
+	return errors.New("certutil not found; install libnss3-tools (Debian/Ubuntu), nss-tools (Fedora) or nss (Homebrew)")
+}
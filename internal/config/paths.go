@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"runtime"
 )
 
 const (
@@ -11,15 +12,25 @@ const (
 	TemplatesDirName     = "templates"
 	TemplatesFileName    = "templates.toml"
 	CaddyfileName        = "Caddyfile"
+	CaddySnippetName     = "caddy.snippet"
+	ProjectSnippetName   = "Caddyfile.justvibin"
 	ConfigFileName       = "config.toml"
 	ProxyLogName         = "proxy.log"
 	ProxyErrName         = "proxy.err"
+	LogsDirName          = "logs"
+	CADirName            = "ca"
+	CacheDirName         = "cache"
+	IndexCacheName       = "index.json"
 	ProxyLabel           = "land.charm.justvibin.proxy"
+	ProxyUnitName        = "justvibin-proxy.service"
 	BasePort             = 3000
 	DefaultTemplatesPath = "~/.config/justvibin/templates.toml"
 )
 
-const launchAgentsDir = "Library/LaunchAgents"
+const (
+	launchAgentsDir = "Library/LaunchAgents"
+	systemdUserDir  = "systemd/user"
+)
 
 func ConfigDir() (string, error) {
 	base, err := baseConfigDir()
@@ -61,6 +72,15 @@ func CaddyfilePath() (string, error) {
 	return filepath.Join(dir, CaddyfileName), nil
 }
 
+// IndexCachePath is where the last fetched template index is kept.
+func IndexCachePath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, CacheDirName, IndexCacheName), nil
+}
+
 func ConfigFilePath() (string, error) {
 	dir, err := ConfigDir()
 	if err != nil {
@@ -85,6 +105,32 @@ func ProxyErrPath() (string, error) {
 	return filepath.Join(dir, ProxyErrName), nil
 }
 
+func LogsDir() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, LogsDirName), nil
+}
+
+// ProjectLogPath returns the log file a detached project writes to.
+func ProjectLogPath(name string) (string, error) {
+	dir, err := LogsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+".log"), nil
+}
+
+// CADir holds the local certificate authority used by the builtin proxy.
+func CADir() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, CADirName), nil
+}
+
 func ProxyPlistPath() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -93,6 +139,23 @@ func ProxyPlistPath() (string, error) {
 	return filepath.Join(home, launchAgentsDir, ProxyLabel+".plist"), nil
 }
 
+func ProxyUnitPath() (string, error) {
+	base, err := baseConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, systemdUserDir, ProxyUnitName), nil
+}
+
+// ProxyServicePath returns the service definition path for the current
+// platform: a launchd plist on macOS or a systemd user unit on Linux.
+func ProxyServicePath() (string, error) {
+	if runtime.GOOS == "linux" {
+		return ProxyUnitPath()
+	}
+	return ProxyPlistPath()
+}
+
 func baseConfigDir() (string, error) {
 	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
 		return xdg, nil
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	ProxyBackendCaddy   = "caddy"
+	ProxyBackendBuiltin = "builtin"
+
+	DefaultProxyHTTPSAddr = ":443"
+	DefaultProxyHTTPAddr  = ":80"
+)
+
+// Settings is the user configuration in config.toml.
+type Settings struct {
+	Proxy     ProxySettings    `toml:"proxy"`
+	Templates TemplateSettings `toml:"templates"`
+}
+
+type ProxySettings struct {
+	// Backend is "caddy" (the default) or "builtin" for justvibin's own
+	// reverse proxy, which needs no Caddy install.
+	Backend string `toml:"backend"`
+	// HTTPSAddr and HTTPAddr are where the builtin proxy listens. Plain HTTP
+	// requests are redirected to HTTPS.
+	HTTPSAddr string `toml:"https_addr"`
+	HTTPAddr  string `toml:"http_addr"`
+}
+
+type TemplateSettings struct {
+	// Index is the URL or path of a template index that `search` and
+	// `install <name>` look templates up in, besides the official ones.
+	Index string `toml:"index"`
+}
+
+// LoadSettings reads config.toml, filling in defaults. A missing file is not
+// an error.
+func LoadSettings() (Settings, error) {
+	path, err := ConfigFilePath()
+	if err != nil {
+		return Settings{}, err
+	}
+	return ReadSettings(path)
+}
+
+func ReadSettings(path string) (Settings, error) {
+	var settings Settings
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Settings{}, err
+	}
+	if err == nil {
+		if _, err := toml.Decode(string(data), &settings); err != nil {
+			return Settings{}, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	proxy := &settings.Proxy
+	switch proxy.Backend {
+	case "":
+		proxy.Backend = ProxyBackendCaddy
+	case ProxyBackendCaddy, ProxyBackendBuiltin:
+	default:
+		return Settings{}, fmt.Errorf("%s: proxy.backend must be %q or %q", path, ProxyBackendCaddy, ProxyBackendBuiltin)
+	}
+	if proxy.HTTPSAddr == "" {
+		proxy.HTTPSAddr = DefaultProxyHTTPSAddr
+	}
+	if proxy.HTTPAddr == "" {
+		proxy.HTTPAddr = DefaultProxyHTTPAddr
+	}
+	return settings, nil
+}
+
+// BuiltinProxy reports whether projects are served by the builtin proxy
+// instead of Caddy.
+func (s Settings) BuiltinProxy() bool {
+	return s.Proxy.Backend == ProxyBackendBuiltin
+}
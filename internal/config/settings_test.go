@@ -0,0 +1,40 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadSettingsDefaults(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("load settings: %v", err)
+	}
+	if settings.BuiltinProxy() || settings.Proxy.HTTPSAddr != DefaultProxyHTTPSAddr || settings.Proxy.HTTPAddr != DefaultProxyHTTPAddr {
+		t.Fatalf("unexpected defaults %+v", settings)
+	}
+}
+
+func TestReadSettingsBuiltinProxy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ConfigFileName)
+	if err := os.WriteFile(path, []byte("[proxy]\nbackend = \"builtin\"\nhttps_addr = \":8443\"\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	settings, err := ReadSettings(path)
+	if err != nil {
+		t.Fatalf("read settings: %v", err)
+	}
+	if !settings.BuiltinProxy() || settings.Proxy.HTTPSAddr != ":8443" || settings.Proxy.HTTPAddr != DefaultProxyHTTPAddr {
+		t.Fatalf("unexpected settings %+v", settings)
+	}
+
+	if err := os.WriteFile(path, []byte("[proxy]\nbackend = \"nginx\"\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := ReadSettings(path); err == nil || !strings.Contains(err.Error(), "proxy.backend") {
+		t.Fatalf("expected backend error, got %v", err)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"io"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 )
 
 func CopyDir(src, dst string) error {
@@ -73,3 +75,48 @@ func IsExecutable(path string) (bool, error) {
 	}
 	return info.Mode().Perm()&0111 != 0, nil
 }
+
+// Match reports whether the relative path rel matches one of patterns, as
+// used for scaffold excludes and watch globs: an exact path, a parent
+// directory, a glob on the base name, or a slash-separated glob on the whole
+// path, where a "**" segment matches any number of directories.
+func Match(rel string, patterns []string) bool {
+	rel = filepath.Clean(rel)
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+	for _, pattern := range patterns {
+		if rel == pattern {
+			return true
+		}
+		if strings.HasPrefix(rel, pattern+string(os.PathSeparator)) {
+			return true
+		}
+		if match, _ := filepath.Match(pattern, filepath.Base(rel)); match {
+			return true
+		}
+		if strings.Contains(pattern, "/") && matchSegments(strings.Split(pattern, "/"), segments) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if match, _ := path.Match(pattern[0], segments[0]); !match {
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:])
+}
@@ -58,3 +58,27 @@ func TestIsExecutable(t *testing.T) {
 		t.Fatalf("expected executable")
 	}
 }
+
+func TestMatchDirectoryGlobs(t *testing.T) {
+	tests := []struct {
+		rel     string
+		pattern string
+		want    bool
+	}{
+		{"main.py", "*.py", true},
+		{filepath.Join("src", "main.py"), "*.py", true},
+		{filepath.Join("src", "main.py"), "src/*.py", true},
+		{filepath.Join("lib", "main.py"), "src/*.py", false},
+		{filepath.Join("src", "pkg", "main.py"), "src/*.py", false},
+		{filepath.Join("app", "main.py"), "app/**/*.py", true},
+		{filepath.Join("app", "a", "b", "main.py"), "app/**/*.py", true},
+		{filepath.Join("app", "a", "main.go"), "app/**/*.py", false},
+		{filepath.Join("a", "b", "main.py"), "**/*.py", true},
+		{filepath.Join("templates", "base.html"), "templates", true},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.rel, []string{tt.pattern}); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.rel, tt.pattern, got, tt.want)
+		}
+	}
+}
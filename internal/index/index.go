@@ -0,0 +1,225 @@
+package index
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// DefaultMaxAge is how long a fetched index is used before it is fetched
+// again.
+const DefaultMaxAge = 24 * time.Hour
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// Entry is one template in an index.
+type Entry struct {
+	Name        string   `json:"name" toml:"name"`
+	Description string   `json:"description" toml:"description"`
+	Tags        []string `json:"tags,omitempty" toml:"tags"`
+	URL         string   `json:"url" toml:"url"`
+	// MinVersion is the oldest justvibin release the template works with.
+	MinVersion string `json:"min_version,omitempty" toml:"min_version"`
+}
+
+type Index struct {
+	Templates []Entry `json:"templates" toml:"templates"`
+}
+
+// cache is the on-disk copy of a fetched index, tagged with where it came
+// from so changing the configured source invalidates it.
+type cache struct {
+	Source  string    `json:"source"`
+	Fetched time.Time `json:"fetched"`
+	Index   Index     `json:"index"`
+}
+
+// Parse reads an index in JSON or, when it does not start with '{', TOML:
+//
+//	[[templates]]
+//	name = "django"
+//	url = "https://github.com/acme/django.git"
+func Parse(data []byte) (Index, error) {
+	var idx Index
+	if trimmed := bytes.TrimSpace(data); bytes.HasPrefix(trimmed, []byte("{")) {
+		if err := json.Unmarshal(trimmed, &idx); err != nil {
+			return Index{}, err
+		}
+	} else if _, err := toml.Decode(string(data), &idx); err != nil {
+		return Index{}, err
+	}
+	for i, entry := range idx.Templates {
+		if entry.Name == "" || entry.URL == "" {
+			return Index{}, fmt.Errorf("templates[%d]: name and url are required", i)
+		}
+	}
+	return idx, nil
+}
+
+// Load reads the index at source, an http(s) or file URL or a local path.
+// Remote indexes are cached at cachePath for maxAge; when fetching fails, a
+// stale cache is used rather than failing offline.
+func Load(ctx context.Context, source, cachePath string, maxAge time.Duration) (Index, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		data, err := os.ReadFile(localPath(source))
+		if err != nil {
+			return Index{}, err
+		}
+		return Parse(data)
+	}
+
+	cached, cacheErr := readCache(cachePath, source)
+	if cacheErr == nil && time.Since(cached.Fetched) < maxAge {
+		return cached.Index, nil
+	}
+	idx, err := fetch(ctx, source)
+	if err != nil {
+		if cacheErr == nil {
+			return cached.Index, nil
+		}
+		return Index{}, err
+	}
+	_ = writeCache(cachePath, cache{Source: source, Fetched: time.Now(), Index: idx})
+	return idx, nil
+}
+
+func localPath(source string) string {
+	if u, err := url.Parse(source); err == nil && u.Scheme == "file" {
+		return filepath.FromSlash(u.Path)
+	}
+	if rest, ok := strings.CutPrefix(source, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return source
+}
+
+func fetch(ctx context.Context, source string) (Index, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return Index{}, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return Index{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Index{}, fmt.Errorf("fetch %s: %s", source, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Index{}, err
+	}
+	idx, err := Parse(data)
+	if err != nil {
+		return Index{}, fmt.Errorf("%s: %w", source, err)
+	}
+	return idx, nil
+}
+
+func readCache(path, source string) (cache, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache{}, err
+	}
+	var cached cache
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return cache{}, err
+	}
+	if cached.Source != source {
+		return cache{}, errors.New("cached index is for another source")
+	}
+	return cached, nil
+}
+
+func writeCache(path string, cached cache) error {
+	data, err := json.MarshalIndent(cached, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Find returns the template called name.
+func (idx Index) Find(name string) (Entry, bool) {
+	for _, entry := range idx.Templates {
+		if entry.Name == name {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Search returns the templates whose name, description or tags contain
+// every word of query, ignoring case. An empty query matches everything.
+func (idx Index) Search(query string) []Entry {
+	words := strings.Fields(strings.ToLower(query))
+	var matches []Entry
+	for _, entry := range idx.Templates {
+		text := strings.ToLower(entry.Name + " " + entry.Description + " " + strings.Join(entry.Tags, " "))
+		matched := true
+		for _, word := range words {
+			if !strings.Contains(text, word) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			matches = append(matches, entry)
+		}
+	}
+	return matches
+}
+
+// Supports reports whether justvibin version satisfies the entry's
+// MinVersion. Versions compare numerically by dot-separated component; a
+// leading "v" and any pre-release suffix are ignored.
+func (e Entry) Supports(version string) bool {
+	if e.MinVersion == "" {
+		return true
+	}
+	have, want := versionParts(version), versionParts(e.MinVersion)
+	for i := 0; i < max(len(have), len(want)); i++ {
+		var h, w int
+		if i < len(have) {
+			h = have[i]
+		}
+		if i < len(want) {
+			w = want[i]
+		}
+		if h != w {
+			return h > w
+		}
+	}
+	return true
+}
+
+func versionParts(version string) []int {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	var parts []int
+	for _, field := range strings.Split(version, ".") {
+		n, _ := strconv.Atoi(field)
+		parts = append(parts, n)
+	}
+	return parts
+}
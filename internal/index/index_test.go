@@ -0,0 +1,123 @@
+package index
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testIndexJSON = `{"templates": [
+  {"name": "django-hypermedia", "description": "Django with htmx", "tags": ["python", "htmx"], "url": "https://example.com/django.git"},
+  {"name": "htmx-go", "description": "Go server", "tags": ["go"], "url": "https://example.com/go.git", "min_version": "1.2"}
+]}`
+
+func TestParseTOML(t *testing.T) {
+	idx, err := Parse([]byte("[[templates]]\nname = \"htmx-go\"\nurl = \"https://example.com/go.git\"\ntags = [\"go\"]\nmin_version = \"1.2\"\n"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(idx.Templates) != 1 || idx.Templates[0].MinVersion != "1.2" || idx.Templates[0].Tags[0] != "go" {
+		t.Fatalf("unexpected index %+v", idx)
+	}
+}
+
+func TestParseRequiresNameAndURL(t *testing.T) {
+	if _, err := Parse([]byte(`{"templates": [{"name": "x"}]}`)); err == nil {
+		t.Fatal("expected error for entry without url")
+	}
+}
+
+func TestLoadFileURL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.json")
+	if err := os.WriteFile(path, []byte(testIndexJSON), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	idx, err := Load(context.Background(), "file://"+filepath.ToSlash(path), filepath.Join(t.TempDir(), "cache.json"), DefaultMaxAge)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(idx.Templates) != 2 {
+		t.Fatalf("expected 2 templates, got %+v", idx)
+	}
+}
+
+func TestLoadCachesRemoteIndex(t *testing.T) {
+	requests := 0
+	up := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		if !up {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(testIndexJSON))
+	}))
+	defer server.Close()
+	cachePath := filepath.Join(t.TempDir(), "cache", "index.json")
+
+	for i := 0; i < 2; i++ {
+		idx, err := Load(context.Background(), server.URL, cachePath, time.Hour)
+		if err != nil {
+			t.Fatalf("load: %v", err)
+		}
+		if len(idx.Templates) != 2 {
+			t.Fatalf("expected 2 templates, got %+v", idx)
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("expected the second load to use the cache, got %d requests", requests)
+	}
+
+	// An expired cache is refetched, and used anyway when the fetch fails.
+	up = false
+	idx, err := Load(context.Background(), server.URL, cachePath, 0)
+	if err != nil {
+		t.Fatalf("expected stale cache, got %v", err)
+	}
+	if requests != 2 || len(idx.Templates) != 2 {
+		t.Fatalf("expected refetch then stale cache, got %d requests, %+v", requests, idx)
+	}
+
+	// A cache for another source is not used.
+	if _, err := Load(context.Background(), server.URL+"/other.json", cachePath, time.Hour); err == nil {
+		t.Fatal("expected error without a cache for this source")
+	}
+}
+
+func TestSearch(t *testing.T) {
+	idx, err := Parse([]byte(testIndexJSON))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got := idx.Search("HTMX python"); len(got) != 1 || got[0].Name != "django-hypermedia" {
+		t.Fatalf("unexpected matches %+v", got)
+	}
+	if got := idx.Search(""); len(got) != 2 {
+		t.Fatalf("expected every template for an empty query, got %+v", got)
+	}
+	if got := idx.Search("rails"); len(got) != 0 {
+		t.Fatalf("expected no matches, got %+v", got)
+	}
+}
+
+func TestEntrySupports(t *testing.T) {
+	cases := []struct {
+		min, version string
+		want         bool
+	}{
+		{"", "1.0.0", true},
+		{"1.2", "1.2.0", true},
+		{"1.2", "1.10.0", true},
+		{"v1.2.1", "1.2.0", false},
+		{"2.0", "1.9.9-dev", false},
+	}
+	for _, tc := range cases {
+		if got := (Entry{MinVersion: tc.min}).Supports(tc.version); got != tc.want {
+			t.Fatalf("Supports(%q) with min %q = %v, want %v", tc.version, tc.min, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package logfile
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultMaxSize    = 10 << 20
+	DefaultMaxBackups = 3
+	maxLineLength     = 1 << 20
+)
+
+// Writer appends timestamped lines to a log file and rotates it to
+// path.1 … path.N once it grows past MaxSize. It is safe for concurrent use.
+type Writer struct {
+	Path       string
+	MaxSize    int64
+	MaxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+	buf  []byte
+	now  func() time.Time
+}
+
+// Open opens path for appending with the default rotation settings.
+func Open(path string) (*Writer, error) {
+	w := &Writer{Path: path, MaxSize: DefaultMaxSize, MaxBackups: DefaultMaxBackups, now: time.Now}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if err := w.writeLine(w.buf[:i]); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Close writes any trailing partial line and closes the file.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		_ = w.writeLine(w.buf)
+		w.buf = nil
+	}
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func (w *Writer) writeLine(line []byte) error {
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	entry := fmt.Sprintf("%s %s\n", w.now().UTC().Format(time.RFC3339), line)
+	if w.MaxSize > 0 && w.size > 0 && w.size+int64(len(entry)) > w.MaxSize {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := w.file.WriteString(entry)
+	w.size += int64(n)
+	return err
+}
+
+func (w *Writer) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.Path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(w.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+func (w *Writer) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.file = nil
+	if err := Rotate(w.Path, w.MaxBackups); err != nil {
+		return err
+	}
+	return w.open()
+}
+
+// Rotate shifts path to path.1, path.1 to path.2 and so on, dropping the
+// oldest backup beyond maxBackups.
+func Rotate(path string, maxBackups int) error {
+	if maxBackups < 1 {
+		return os.Remove(path)
+	}
+	_ = os.Remove(backupPath(path, maxBackups))
+	for i := maxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(backupPath(path, i), backupPath(path, i+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	if err := os.Rename(path, backupPath(path, 1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// RotateIfLarger rotates path when it is already bigger than maxSize. It is
+// used before handing the raw file to a process that appends to it directly.
+func RotateIfLarger(path string, maxSize int64, maxBackups int) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if info.Size() <= maxSize {
+		return nil
+	}
+	return Rotate(path, maxBackups)
+}
+
+func backupPath(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+// Tail returns up to n of the most recent lines across path and its backups,
+// oldest first. Lines logged before since are skipped; n <= 0 returns every
+// matching line. Lines without a timestamp inherit the previous line's time;
+// before a file's first timestamp they count as written when the file was
+// last modified.
+func Tail(path string, since time.Time, n int) ([]string, error) {
+	files := []string{}
+	for i := DefaultMaxBackups; i >= 1; i-- {
+		files = append(files, backupPath(path, i))
+	}
+	files = append(files, path)
+
+	var lines []string
+	found := false
+	for _, name := range files {
+		file, err := os.Open(name)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		found = true
+		var stamp time.Time
+		if info, err := file.Stat(); err == nil {
+			stamp = info.ModTime()
+		}
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxLineLength)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if t, ok := lineTime(line); ok {
+				stamp = t
+			}
+			if !since.IsZero() && stamp.Before(since) {
+				continue
+			}
+			lines = append(lines, line)
+			if n > 0 && len(lines) > n {
+				lines = lines[1:]
+			}
+		}
+		err = scanner.Err()
+		_ = file.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !found {
+		return nil, os.ErrNotExist
+	}
+	return lines, nil
+}
+
+// Follow copies lines appended to path to out until ctx is cancelled,
+// starting from the current end of the file and reopening it after rotation.
+func Follow(ctx context.Context, path string, out io.Writer, interval time.Duration) error {
+	var file *os.File
+	defer func() {
+		if file != nil {
+			_ = file.Close()
+		}
+	}()
+	openAt := func(whence int) error {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Seek(0, whence); err != nil {
+			_ = f.Close()
+			return err
+		}
+		if file != nil {
+			_ = file.Close()
+		}
+		file = f
+		return nil
+	}
+	if err := openAt(io.SeekEnd); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	var partial []byte
+	buf := make([]byte, 32*1024)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if file != nil {
+			for {
+				n, err := file.Read(buf)
+				if n > 0 {
+					partial = append(partial, buf[:n]...)
+					if i := bytes.LastIndexByte(partial, '\n'); i >= 0 {
+						if _, err := out.Write(partial[:i+1]); err != nil {
+							return err
+						}
+						partial = append([]byte(nil), partial[i+1:]...)
+					}
+				}
+				if err != nil {
+					break
+				}
+			}
+			if rotated(file, path) {
+				if err := openAt(io.SeekStart); err == nil {
+					continue
+				}
+			}
+		} else if err := openAt(io.SeekStart); err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// rotated reports whether path now names a different file than the one open.
+func rotated(file *os.File, path string) bool {
+	current, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	open, err := file.Stat()
+	if err != nil {
+		return true
+	}
+	return !os.SameFile(open, current) || current.Size() < offset(file)
+}
+
+func offset(file *os.File) int64 {
+	pos, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0
+	}
+	return pos
+}
+
+func lineTime(line string) (time.Time, bool) {
+	field, _, ok := strings.Cut(line, " ")
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, field)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// Capture writes everything read from r to the log at path, timestamped and
+// rotated like a Writer, until r reaches EOF.
+func Capture(path string, r io.Reader) error {
+	w, err := Open(path)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// OpenAppend opens path for a process that appends to it directly, rotating
+// it first when it has outgrown DefaultMaxSize. Lines written this way carry
+// no timestamps.
+func OpenAppend(path string) (*os.File, error) {
+	if err := RotateIfLarger(path, DefaultMaxSize, DefaultMaxBackups); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+}
+
+// LinesAfter returns up to n of the last lines written to path beyond offset,
+// such as the output of a process started once the file was offset bytes long.
+func LinesAfter(path string, offset int64, n int) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if info, err := file.Stat(); err == nil && info.Size() < offset {
+		// Rotated in the meantime; everything in the new file is recent.
+		offset = 0
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineLength)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if n > 0 && len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,187 @@
+package logfile
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWriterTimestampsAndRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+	w, err := Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	w.MaxSize = 64
+	w.MaxBackups = 2
+	w.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	for i := 0; i < 6; i++ {
+		fmt.Fprintf(w, "line %d\n", i)
+	}
+	fmt.Fprint(w, "partial")
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !strings.HasSuffix(string(data), "2024-01-02T03:04:05Z partial\n") {
+		t.Fatalf("expected timestamped partial line, got %q", data)
+	}
+	if _, err := os.Stat(path + ".2"); err != nil {
+		t.Fatalf("expected second backup: %v", err)
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("expected backups capped at 2")
+	}
+}
+
+func TestTailFiltersBySinceAndLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	backup := "2024-01-01T00:00:00Z old\n2024-01-01T01:00:00Z older-but-kept\n"
+	current := "2024-01-01T02:00:00Z new\nraw continuation\n2024-01-01T03:00:00Z newest\n"
+	if err := os.WriteFile(path+".1", []byte(backup), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(current), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	since := time.Date(2024, 1, 1, 0, 30, 0, 0, time.UTC)
+	lines, err := Tail(path, since, 0)
+	if err != nil {
+		t.Fatalf("tail: %v", err)
+	}
+	want := []string{"2024-01-01T01:00:00Z older-but-kept", "2024-01-01T02:00:00Z new", "raw continuation", "2024-01-01T03:00:00Z newest"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Fatalf("unexpected lines %q", lines)
+	}
+
+	lines, err = Tail(path, time.Time{}, 2)
+	if err != nil {
+		t.Fatalf("tail: %v", err)
+	}
+	if len(lines) != 2 || lines[1] != "2024-01-01T03:00:00Z newest" {
+		t.Fatalf("unexpected last lines %q", lines)
+	}
+
+	if _, err := Tail(filepath.Join(t.TempDir(), "missing.log"), time.Time{}, 10); !os.IsNotExist(err) {
+		t.Fatalf("expected not exist, got %v", err)
+	}
+}
+
+func TestTailUntimestampedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte("Serving . on :3000\nGET /\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	lines, err := Tail(path, time.Now().Add(-10*time.Minute), 0)
+	if err != nil {
+		t.Fatalf("tail: %v", err)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("expected raw lines of a fresh file, got %q", lines)
+	}
+
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	lines, err = Tail(path, time.Now().Add(-10*time.Minute), 0)
+	if err != nil {
+		t.Fatalf("tail: %v", err)
+	}
+	if len(lines) != 0 {
+		t.Fatalf("expected raw lines of a file untouched since to be skipped, got %q", lines)
+	}
+}
+
+func TestCaptureTimestampsLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+	if err := Capture(path, strings.NewReader("one\ntwo")); err != nil {
+		t.Fatalf("capture: %v", err)
+	}
+	lines, err := Tail(path, time.Now().Add(-time.Minute), 0)
+	if err != nil {
+		t.Fatalf("tail: %v", err)
+	}
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], " one") || !strings.HasSuffix(lines[1], " two") {
+		t.Fatalf("unexpected lines %q", lines)
+	}
+	if _, ok := lineTime(lines[0]); !ok {
+		t.Fatalf("expected timestamped line, got %q", lines[0])
+	}
+}
+
+func TestFollowPrintsAppendedLinesAcrossRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte("before\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := &lockedBuffer{}
+	done := make(chan error, 1)
+	go func() { done <- Follow(ctx, path, out, 5*time.Millisecond) }()
+
+	appendLine := func(line string) {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		fmt.Fprintln(f, line)
+		_ = f.Close()
+	}
+	waitFor := func(text string) {
+		deadline := time.Now().Add(2 * time.Second)
+		for !strings.Contains(out.String(), text) {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %q, got %q", text, out.String())
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	appendLine("first")
+	waitFor("first\n")
+	if err := Rotate(path, 1); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	appendLine("after rotate")
+	waitFor("after rotate\n")
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("follow: %v", err)
+	}
+	if strings.Contains(out.String(), "before") {
+		t.Fatalf("expected follow to start at end of file, got %q", out.String())
+	}
+}
+
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
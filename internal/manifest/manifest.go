@@ -4,96 +4,189 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
 )
 
 type Template struct {
-	Name        string
-	Description string
-	Version     string
-	Author      string
-	URL         string
+	Name        string `toml:"name"`
+	Description string `toml:"description"`
+	Version     string `toml:"version"`
+	Author      string `toml:"author"`
+	URL         string `toml:"url"`
 }
 
 type Scaffold struct {
-	Exclude          []string
-	Setup            string
-	SetupInteractive bool
+	Exclude          []string `toml:"exclude"`
+	Setup            string   `toml:"setup"`
+	SetupInteractive bool     `toml:"setup_interactive"`
 }
 
 type Serve struct {
-	Type        string
-	Dev         string
-	Prod        string
-	PortEnv     string
-	DefaultPort int
-	Static      ServeStatic
+	Type        string `toml:"type"`
+	Dev         string `toml:"dev"`
+	Prod        string `toml:"prod"`
+	PortEnv     string `toml:"port_env"`
+	DefaultPort int    `toml:"default_port"`
+	// HealthPath, when set, must answer an HTTP GET before start reports
+	// the server as ready; otherwise accepting a TCP connection is enough.
+	HealthPath   string      `toml:"health_path"`
+	ReadyTimeout string      `toml:"ready_timeout"`
+	Static       ServeStatic `toml:"static"`
+	Watch        ServeWatch  `toml:"watch"`
 }
 
 type ServeStatic struct {
-	Root       string
-	Extensions []string
+	Root       string   `toml:"root"`
+	Extensions []string `toml:"extensions"`
+	// SPA serves index.html for unknown extensionless paths.
+	SPA        bool `toml:"spa"`
+	LiveReload bool `toml:"live_reload"`
+}
+
+// ServeWatch restarts a command server when files matching Include change.
+// Watching is off while Include is empty.
+type ServeWatch struct {
+	Include  []string `toml:"include"`
+	Exclude  []string `toml:"exclude"`
+	Debounce string   `toml:"debounce"`
 }
 
 type Project struct {
-	MarkerFields []string
+	MarkerFields []string `toml:"marker_fields"`
+}
+
+// Process is one entry of the [processes.<name>] table. Every process of a
+// project is started and supervised together by `justvibin start`.
+type Process struct {
+	Command string            `toml:"command"`
+	Env     map[string]string `toml:"env"`
+	PortEnv string            `toml:"port_env"`
+	Route   bool              `toml:"route"`
+}
+
+// Variable is one entry of the [variables.<name>] table. `justvibin new`
+// asks for its value and renders {{name}} in the template's files.
+type Variable struct {
+	Prompt  string `toml:"prompt"`
+	Default string `toml:"default"`
+	Pattern string `toml:"pattern"`
 }
 
+// BuiltinVariables are always available to templates and cannot be
+// redeclared under [variables].
+var BuiltinVariables = []string{"project_name", "port"}
+
 type Manifest struct {
-	Template Template
-	Scaffold Scaffold
-	Serve    Serve
-	Project  Project
+	Template  Template           `toml:"template"`
+	Scaffold  Scaffold           `toml:"scaffold"`
+	Serve     Serve              `toml:"serve"`
+	Project   Project            `toml:"project"`
+	Processes map[string]Process `toml:"processes"`
+	// Routes maps a path pattern such as "/api/*" to the process that serves
+	// it behind the project's single origin.
+	Routes map[string]string `toml:"routes"`
+	// Variables are asked for by `justvibin new` and rendered into the
+	// scaffolded files.
+	Variables map[string]Variable `toml:"variables"`
+}
+
+// ParseError is a syntax or type error in a manifest, with the 1-based line
+// and column it was found at.
+type ParseError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
 }
 
 var namePattern = regexp.MustCompile(`^[a-z0-9-]+$`)
 
+var variableNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// decodeErrorPattern matches the errors BurntSushi/toml returns for values
+// of the wrong type, which carry the line and key but are not ParseErrors.
+var decodeErrorPattern = regexp.MustCompile(`^toml: line (\d+) \(last key "([^"]*)"\): (.*)$`)
+
 func Parse(data []byte) (Manifest, error) {
-	manifest := Manifest{}
-	section := ""
-	lines := strings.Split(string(data), "\n")
-	for i, raw := range lines {
-		line := strings.TrimSpace(raw)
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-		if strings.HasPrefix(line, "[") {
-			if !strings.HasSuffix(line, "]") {
-				return Manifest{}, fmt.Errorf("invalid section header at line %d", i+1)
-			}
-			section = strings.TrimSuffix(strings.TrimPrefix(line, "["), "]")
-			if section == "" {
-				return Manifest{}, fmt.Errorf("invalid section header at line %d", i+1)
-			}
+	manifest, _, err := decode(data)
+	return manifest, err
+}
+
+// ParseStrict parses like Parse and also returns a warning for every key the
+// Manifest struct does not know about, such as a misspelled setting.
+func ParseStrict(data []byte) (Manifest, []string, error) {
+	manifest, meta, err := decode(data)
+	if err != nil {
+		return Manifest{}, nil, err
+	}
+	var warnings []string
+	var reported []string
+	for _, key := range meta.Undecoded() {
+		name := key.String()
+		if hasReportedParent(reported, name) {
 			continue
 		}
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
-			return Manifest{}, fmt.Errorf("invalid assignment at line %d", i+1)
+		reported = append(reported, name)
+		warnings = append(warnings, fmt.Sprintf("unknown key %q", name))
+	}
+	return manifest, warnings, nil
+}
+
+func hasReportedParent(reported []string, key string) bool {
+	for _, parent := range reported {
+		if strings.HasPrefix(key, parent+".") {
+			return true
 		}
-		key := strings.TrimSpace(parts[0])
-		if key == "" {
-			return Manifest{}, fmt.Errorf("invalid assignment at line %d", i+1)
+	}
+	return false
+}
+
+func decode(data []byte) (Manifest, toml.MetaData, error) {
+	var manifest Manifest
+	meta, err := toml.Decode(string(data), &manifest)
+	if err != nil {
+		var parseErr toml.ParseError
+		if errors.As(err, &parseErr) {
+			return Manifest{}, meta, &ParseError{
+				Line:    parseErr.Position.Line,
+				Column:  parseErr.Position.Col,
+				Message: parseErr.Message,
+			}
 		}
-		value := strings.TrimSpace(parts[1])
-		if value == "" {
-			return Manifest{}, fmt.Errorf("invalid assignment at line %d", i+1)
+		if match := decodeErrorPattern.FindStringSubmatch(err.Error()); match != nil {
+			line, _ := strconv.Atoi(match[1])
+			return Manifest{}, meta, &ParseError{
+				Line:    line,
+				Column:  keyColumn(data, line, match[2]),
+				Message: fmt.Sprintf("%s: %s", match[2], match[3]),
+			}
 		}
+		return Manifest{}, meta, err
+	}
+	return manifest, meta, nil
+}
 
-		switch section {
-		case "template":
-			assignTemplate(&manifest.Template, key, value)
-		case "scaffold":
-			assignScaffold(&manifest.Scaffold, key, value)
-		case "serve":
-			assignServe(&manifest.Serve, key, value)
-		case "serve.static":
-			assignServeStatic(&manifest.Serve.Static, key, value)
-		case "project":
-			assignProject(&manifest.Project, key, value)
-		}
+// keyColumn finds where key's last part starts on line, falling back to the
+// line's first non-blank column.
+func keyColumn(data []byte, line int, key string) int {
+	lines := strings.Split(string(data), "\n")
+	if line < 1 || line > len(lines) {
+		return 1
 	}
-	return manifest, nil
+	text := lines[line-1]
+	last := key[strings.LastIndex(key, ".")+1:]
+	if i := strings.Index(text, last); i >= 0 {
+		return i + 1
+	}
+	return len(text) - len(strings.TrimLeft(text, " \t")) + 1
 }
 
 func Validate(manifest Manifest) error {
@@ -106,16 +199,86 @@ func Validate(manifest Manifest) error {
 	if manifest.Template.Description == "" {
 		errs = append(errs, "template.description is required")
 	}
+	hasProcesses := len(manifest.Processes) > 0
 	if manifest.Serve.Type == "" {
-		errs = append(errs, "serve.type is required")
+		if !hasProcesses {
+			errs = append(errs, "serve.type is required")
+		}
 	} else if manifest.Serve.Type != "static" && manifest.Serve.Type != "command" {
 		errs = append(errs, "serve.type must be static or command")
 	}
-	if manifest.Serve.Type == "command" {
+	if manifest.Serve.Type == "command" && !hasProcesses {
 		if manifest.Serve.Dev == "" && manifest.Serve.Prod == "" {
 			errs = append(errs, "serve.dev or serve.prod is required for command templates")
 		}
 	}
+	if manifest.Serve.HealthPath != "" && !strings.HasPrefix(manifest.Serve.HealthPath, "/") {
+		errs = append(errs, "serve.health_path must start with /")
+	}
+	if manifest.Serve.ReadyTimeout != "" {
+		if d, err := time.ParseDuration(manifest.Serve.ReadyTimeout); err != nil || d <= 0 {
+			errs = append(errs, "serve.ready_timeout must be a positive duration like 30s")
+		}
+	}
+	watch := manifest.Serve.Watch
+	if len(watch.Include) == 0 && (len(watch.Exclude) > 0 || watch.Debounce != "") {
+		errs = append(errs, "serve.watch.include is required")
+	}
+	if len(watch.Include) > 0 && manifest.Serve.Type != "command" {
+		errs = append(errs, "serve.watch requires serve.type = command")
+	}
+	if watch.Debounce != "" {
+		if d, err := time.ParseDuration(watch.Debounce); err != nil || d <= 0 {
+			errs = append(errs, "serve.watch.debounce must be a positive duration like 200ms")
+		}
+	}
+	routes := 0
+	for _, name := range ProcessNames(manifest) {
+		process := manifest.Processes[name]
+		if !namePattern.MatchString(name) {
+			errs = append(errs, fmt.Sprintf("processes.%s: name must match [a-z0-9-]+", name))
+		}
+		if process.Command == "" {
+			errs = append(errs, fmt.Sprintf("processes.%s.command is required", name))
+		}
+		if process.Route {
+			routes++
+		}
+	}
+	if routes > 1 {
+		errs = append(errs, "only one process can set route = true")
+	}
+	for _, path := range RoutePaths(manifest) {
+		process, ok := manifest.Processes[manifest.Routes[path]]
+		switch {
+		case !strings.HasPrefix(path, "/") || !strings.HasSuffix(path, "/*") || strings.Count(path, "*") > 1:
+			errs = append(errs, fmt.Sprintf("routes.%q: path must look like /api/* or /*", path))
+		case !ok:
+			errs = append(errs, fmt.Sprintf("routes.%q: unknown process %q", path, manifest.Routes[path]))
+		case process.PortEnv == "":
+			errs = append(errs, fmt.Sprintf("routes.%q: process %q needs port_env to receive a port", path, manifest.Routes[path]))
+		}
+	}
+	for _, name := range VariableNames(manifest) {
+		variable := manifest.Variables[name]
+		if !variableNamePattern.MatchString(name) {
+			errs = append(errs, fmt.Sprintf("variables.%s: name must match [a-z][a-z0-9_]*", name))
+		}
+		for _, builtin := range BuiltinVariables {
+			if name == builtin {
+				errs = append(errs, fmt.Sprintf("variables.%s: %s is set by justvibin", name, name))
+			}
+		}
+		if variable.Pattern == "" {
+			continue
+		}
+		pattern, err := regexp.Compile(variable.Pattern)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("variables.%s.pattern: %v", name, err))
+		} else if variable.Default != "" && !strings.Contains(variable.Default, "{{") && !pattern.MatchString(variable.Default) {
+			errs = append(errs, fmt.Sprintf("variables.%s.default does not match pattern", name))
+		}
+	}
 	if len(errs) > 0 {
 		return errors.New(strings.Join(errs, "; "))
 	}
@@ -130,113 +293,64 @@ func ServeType(manifest Manifest) string {
 	return manifest.Serve.Type
 }
 
-func ServeCommand(manifest Manifest, mode string) string {
-	if mode == "prod" {
-		if manifest.Serve.Prod != "" {
-			return manifest.Serve.Prod
-		}
-		return manifest.Serve.Dev
-	}
-	if manifest.Serve.Dev != "" {
-		return manifest.Serve.Dev
-	}
-	return manifest.Serve.Prod
-}
-
-func assignTemplate(template *Template, key, value string) {
-	switch key {
-	case "name":
-		template.Name = trimString(value)
-	case "description":
-		template.Description = trimString(value)
-	case "version":
-		template.Version = trimString(value)
-	case "author":
-		template.Author = trimString(value)
-	case "url":
-		template.URL = trimString(value)
-	}
-}
-
-func assignScaffold(scaffold *Scaffold, key, value string) {
-	switch key {
-	case "exclude":
-		scaffold.Exclude = trimArray(value)
-	case "setup":
-		scaffold.Setup = trimString(value)
-	case "setup_interactive":
-		scaffold.SetupInteractive = trimBool(value)
+// ProcessNames returns the names of the manifest's processes in sorted order.
+func ProcessNames(manifest Manifest) []string {
+	names := make([]string, 0, len(manifest.Processes))
+	for name := range manifest.Processes {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	return names
 }
 
-func assignServe(serve *Serve, key, value string) {
-	switch key {
-	case "type":
-		serve.Type = trimString(value)
-	case "dev":
-		serve.Dev = trimString(value)
-	case "prod":
-		serve.Prod = trimString(value)
-	case "port_env":
-		serve.PortEnv = trimString(value)
-	case "default_port":
-		serve.DefaultPort = trimInt(value)
+// RoutePaths returns the manifest's route paths in sorted order.
+func RoutePaths(manifest Manifest) []string {
+	paths := make([]string, 0, len(manifest.Routes))
+	for path := range manifest.Routes {
+		paths = append(paths, path)
 	}
+	sort.Strings(paths)
+	return paths
 }
 
-func assignServeStatic(static *ServeStatic, key, value string) {
-	switch key {
-	case "root":
-		static.Root = trimString(value)
-	case "extensions":
-		static.Extensions = trimArray(value)
+// VariableNames returns the names of the manifest's variables in sorted order.
+func VariableNames(manifest Manifest) []string {
+	names := make([]string, 0, len(manifest.Variables))
+	for name := range manifest.Variables {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	return names
 }
 
-func assignProject(project *Project, key, value string) {
-	switch key {
-	case "marker_fields":
-		project.MarkerFields = trimArray(value)
+// ReadyTimeout returns serve.ready_timeout, or 0 when it is unset or invalid.
+func ReadyTimeout(manifest Manifest) time.Duration {
+	d, err := time.ParseDuration(manifest.Serve.ReadyTimeout)
+	if err != nil || d <= 0 {
+		return 0
 	}
+	return d
 }
 
-func trimString(value string) string {
-	value = strings.TrimSpace(value)
-	return strings.Trim(value, "\"")
-}
-
-func trimBool(value string) bool {
-	value = trimString(value)
-	return value == "true"
-}
-
-func trimInt(value string) int {
-	value = trimString(value)
-	if value == "" {
+// WatchDebounce returns serve.watch.debounce, or 0 when it is unset or
+// invalid.
+func WatchDebounce(manifest Manifest) time.Duration {
+	d, err := time.ParseDuration(manifest.Serve.Watch.Debounce)
+	if err != nil || d <= 0 {
 		return 0
 	}
-	var parsed int
-	_, err := fmt.Sscanf(value, "%d", &parsed)
-	if err != nil {
-		return 0
-	}
-	return parsed
+	return d
 }
 
-func trimArray(value string) []string {
-	value = strings.TrimSpace(value)
-	value = strings.TrimPrefix(value, "[")
-	value = strings.TrimSuffix(value, "]")
-	if value == "" {
-		return nil
-	}
-	parts := strings.Split(value, ",")
-	items := make([]string, 0, len(parts))
-	for _, part := range parts {
-		item := trimString(part)
-		if item != "" {
-			items = append(items, item)
+func ServeCommand(manifest Manifest, mode string) string {
+	if mode == "prod" {
+		if manifest.Serve.Prod != "" {
+			return manifest.Serve.Prod
 		}
+		return manifest.Serve.Dev
 	}
-	return items
+	if manifest.Serve.Dev != "" {
+		return manifest.Serve.Dev
+	}
+	return manifest.Serve.Prod
 }
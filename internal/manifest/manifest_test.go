@@ -1,8 +1,10 @@
 package manifest
 
 import (
+	"errors"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseAndValidateManifest(t *testing.T) {
@@ -100,3 +102,322 @@ func TestParseInvalidManifestReturnsError(t *testing.T) {
 		t.Fatalf("expected parse error")
 	}
 }
+
+func TestParseHandlesFullTOMLSyntax(t *testing.T) {
+	input := strings.Join([]string{
+		"[template]",
+		"name = \"hypertext\" # trailing comment",
+		"description = \"Say \\\"hi\\\" = hello\"",
+		"",
+		"[scaffold]",
+		"exclude = [",
+		"  \".git\",",
+		"  \"node_modules\", # deps",
+		"]",
+		"",
+		"[serve]",
+		"type = \"command\"",
+		"dev = 'FOO=bar ./start.sh --dev'",
+		"static = { root = \"public\", extensions = [\".html\", \".htm\"] }",
+	}, "\n")
+
+	manifest, err := Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if manifest.Template.Name != "hypertext" {
+		t.Fatalf("unexpected name %q", manifest.Template.Name)
+	}
+	if manifest.Template.Description != "Say \"hi\" = hello" {
+		t.Fatalf("unexpected description %q", manifest.Template.Description)
+	}
+	if len(manifest.Scaffold.Exclude) != 2 || manifest.Scaffold.Exclude[1] != "node_modules" {
+		t.Fatalf("unexpected exclude list %#v", manifest.Scaffold.Exclude)
+	}
+	if manifest.Serve.Dev != "FOO=bar ./start.sh --dev" {
+		t.Fatalf("unexpected dev command %q", manifest.Serve.Dev)
+	}
+	if manifest.Serve.Static.Root != "public" || len(manifest.Serve.Static.Extensions) != 2 {
+		t.Fatalf("unexpected static settings %#v", manifest.Serve.Static)
+	}
+}
+
+func TestParseReportsLineAndColumn(t *testing.T) {
+	input := "[template]\nname = \"ok\"\ndescription = \"x\" junk\n"
+	_, err := Parse([]byte(input))
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected ParseError, got %v", err)
+	}
+	if parseErr.Line != 3 || parseErr.Column == 0 {
+		t.Fatalf("unexpected position %d:%d", parseErr.Line, parseErr.Column)
+	}
+	if !strings.Contains(err.Error(), "line 3, column") {
+		t.Fatalf("unexpected error text %q", err.Error())
+	}
+}
+
+func TestParseReportsTypeErrorPosition(t *testing.T) {
+	cases := []struct {
+		input        string
+		line, column int
+		key          string
+	}{
+		{"[serve]\ntype = \"command\"\n  default_port = \"3000\"\n", 3, 3, "serve.default_port"},
+		{"[template]\nname = \"ok\"\n\n[processes]\nweb = \"npm run dev\"\n", 5, 1, "processes.web"},
+	}
+	for _, tc := range cases {
+		_, err := Parse([]byte(tc.input))
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Fatalf("expected ParseError, got %v", err)
+		}
+		if parseErr.Line != tc.line || parseErr.Column != tc.column {
+			t.Fatalf("expected %d:%d, got %d:%d (%v)", tc.line, tc.column, parseErr.Line, parseErr.Column, err)
+		}
+		if !strings.Contains(parseErr.Message, tc.key) {
+			t.Fatalf("expected %q in %q", tc.key, parseErr.Message)
+		}
+	}
+}
+
+func TestParseStrictWarnsOnUnknownKeys(t *testing.T) {
+	input := strings.Join([]string{
+		"[template]",
+		"name = \"hypertext\"",
+		"descripton = \"typo\"",
+		"",
+		"[extras]",
+		"a = 1",
+		"b = { c = 2 }",
+	}, "\n")
+
+	_, warnings, err := ParseStrict([]byte(input))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(warnings) != 2 {
+		t.Fatalf("expected 2 warnings, got %#v", warnings)
+	}
+	if !strings.Contains(warnings[0], "template.descripton") || !strings.Contains(warnings[1], "extras") {
+		t.Fatalf("unexpected warnings %#v", warnings)
+	}
+
+	if _, err := Parse([]byte(input)); err != nil {
+		t.Fatalf("non-strict parse should ignore unknown keys: %v", err)
+	}
+}
+
+func TestParseAndValidateProcesses(t *testing.T) {
+	input := strings.Join([]string{
+		"[template]",
+		"name = \"django\"",
+		"description = \"Django\"",
+		"",
+		"[processes.web]",
+		"command = \"./manage.py runserver 0.0.0.0:$PORT\"",
+		"port_env = \"PORT\"",
+		"route = true",
+		"",
+		"[processes.worker]",
+		"command = \"./manage.py worker\"",
+		"env = { QUEUE = \"default\" }",
+	}, "\n")
+
+	manifest, err := Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if err := Validate(manifest); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	names := ProcessNames(manifest)
+	if len(names) != 2 || names[0] != "web" || names[1] != "worker" {
+		t.Fatalf("unexpected process names %#v", names)
+	}
+	if !manifest.Processes["web"].Route || manifest.Processes["web"].PortEnv != "PORT" {
+		t.Fatalf("unexpected web process %#v", manifest.Processes["web"])
+	}
+	if manifest.Processes["worker"].Env["QUEUE"] != "default" {
+		t.Fatalf("unexpected worker env %#v", manifest.Processes["worker"].Env)
+	}
+}
+
+func TestValidateProcessErrors(t *testing.T) {
+	manifest := Manifest{
+		Template: Template{Name: "django", Description: "Django"},
+		Processes: map[string]Process{
+			"web":    {Command: "a", Route: true},
+			"api":    {Command: "b", Route: true},
+			"worker": {},
+		},
+	}
+	err := Validate(manifest)
+	if err == nil {
+		t.Fatalf("expected validation error")
+	}
+	if !strings.Contains(err.Error(), "processes.worker.command is required") || !strings.Contains(err.Error(), "only one process") {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestParseAndValidateRoutes(t *testing.T) {
+	input := strings.Join([]string{
+		"[template]",
+		"name = \"fullstack\"",
+		"description = \"API and frontend\"",
+		"",
+		"[processes.api]",
+		"command = \"./api\"",
+		"port_env = \"API_PORT\"",
+		"",
+		"[processes.web]",
+		"command = \"npm run dev\"",
+		"port_env = \"PORT\"",
+		"",
+		"[routes]",
+		"\"/api/*\" = \"api\"",
+		"\"/*\" = \"web\"",
+	}, "\n")
+
+	manifest, err := Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if err := Validate(manifest); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if paths := RoutePaths(manifest); len(paths) != 2 || manifest.Routes[paths[0]] != "web" || manifest.Routes[paths[1]] != "api" {
+		t.Fatalf("unexpected routes %#v", manifest.Routes)
+	}
+
+	manifest.Processes["worker"] = Process{Command: "./worker"}
+	manifest.Routes = map[string]string{"/api": "api", "/jobs/*": "worker", "/x/*": "missing"}
+	err = Validate(manifest)
+	if err == nil {
+		t.Fatalf("expected validation error")
+	}
+	for _, want := range []string{`routes."/api": path`, `process "worker" needs port_env`, `unknown process "missing"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q in %v", want, err)
+		}
+	}
+}
+
+func TestParseReadinessSettings(t *testing.T) {
+	input := `[template]
+name = "django"
+description = "Django"
+
+[serve]
+type = "command"
+dev = "python manage.py runserver $PORT"
+health_path = "/healthz"
+ready_timeout = "45s"
+`
+	manifest, err := Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if manifest.Serve.HealthPath != "/healthz" || ReadyTimeout(manifest) != 45*time.Second {
+		t.Fatalf("unexpected readiness settings %+v", manifest.Serve)
+	}
+
+	manifest.Serve.HealthPath = "healthz"
+	manifest.Serve.ReadyTimeout = "soon"
+	err = Validate(manifest)
+	if err == nil || !strings.Contains(err.Error(), "health_path") || !strings.Contains(err.Error(), "ready_timeout") {
+		t.Fatalf("expected readiness validation errors, got %v", err)
+	}
+}
+
+func TestParseWatchSettings(t *testing.T) {
+	input := `[template]
+name = "flask"
+description = "Flask"
+
+[serve]
+type = "command"
+dev = "python app.py"
+
+[serve.watch]
+include = ["*.py", "templates"]
+exclude = ["venv"]
+debounce = "250ms"
+`
+	manifest, warnings, err := ParseStrict([]byte(input))
+	if err != nil || len(warnings) != 0 {
+		t.Fatalf("parse: %v %v", err, warnings)
+	}
+	if len(manifest.Serve.Watch.Include) != 2 || manifest.Serve.Watch.Exclude[0] != "venv" || WatchDebounce(manifest) != 250*time.Millisecond {
+		t.Fatalf("unexpected watch settings %+v", manifest.Serve.Watch)
+	}
+
+	manifest.Serve.Type = "static"
+	manifest.Serve.Watch.Debounce = "-1s"
+	err = Validate(manifest)
+	if err == nil || !strings.Contains(err.Error(), "serve.watch requires") || !strings.Contains(err.Error(), "debounce") {
+		t.Fatalf("expected watch validation errors, got %v", err)
+	}
+}
+
+func TestParseAndValidateVariables(t *testing.T) {
+	input := strings.Join([]string{
+		"[template]",
+		"name = \"django\"",
+		"description = \"Django\"",
+		"",
+		"[serve]",
+		"type = \"static\"",
+		"",
+		"[variables.db_name]",
+		"prompt = \"Database name\"",
+		"default = \"{{project_name}}_db\"",
+		"pattern = \"^[a-z_]+$\"",
+		"",
+		"[variables.author]",
+		"prompt = \"Author\"",
+	}, "\n")
+
+	manifest, err := Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if err := Validate(manifest); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	names := VariableNames(manifest)
+	if len(names) != 2 || names[0] != "author" || names[1] != "db_name" {
+		t.Fatalf("unexpected variable names %#v", names)
+	}
+	if manifest.Variables["db_name"].Prompt != "Database name" || manifest.Variables["db_name"].Pattern != "^[a-z_]+$" {
+		t.Fatalf("unexpected db_name variable %#v", manifest.Variables["db_name"])
+	}
+}
+
+func TestValidateVariableErrors(t *testing.T) {
+	manifest := Manifest{
+		Template: Template{Name: "django", Description: "Django"},
+		Serve:    Serve{Type: "static"},
+		Variables: map[string]Variable{
+			"Bad-Name":      {},
+			"port":          {},
+			"broken":        {Pattern: "("},
+			"wrong_default": {Default: "ABC", Pattern: "^[a-z]+$"},
+		},
+	}
+	err := Validate(manifest)
+	if err == nil {
+		t.Fatalf("expected validation error")
+	}
+	for _, want := range []string{
+		"variables.Bad-Name: name must match",
+		"variables.port: port is set by justvibin",
+		"variables.broken.pattern",
+		"variables.wrong_default.default does not match pattern",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q in %v", want, err)
+		}
+	}
+}
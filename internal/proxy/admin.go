@@ -0,0 +1,275 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultAdminURL is where Caddy serves its admin API unless configured
+// otherwise.
+const DefaultAdminURL = "http://localhost:2019"
+
+// adminServer is the name of the HTTP server justvibin owns in Caddy's
+// config. The Caddyfile names its server this way, which marks a running
+// Caddy as justvibin's proxy. Each project is one route in it, tagged with an
+// @id once it has been pushed through the admin API.
+const adminServer = "justvibin"
+
+const adminRoutesPath = "/config/apps/http/servers/" + adminServer + "/routes"
+
+// errForeignConfig means the Caddy answering on the admin address is not
+// running justvibin's proxy, so its config must be left alone.
+var errForeignConfig = errors.New("caddy is not running justvibin's proxy config")
+
+// adminURL is the admin API GenerateCaddyfile pushes routes to. An empty URL
+// always uses the Caddyfile.
+var adminURL = DefaultAdminURL
+
+var adminClient = &http.Client{Timeout: 2 * time.Second}
+
+// Admin updates a running Caddy through its admin API.
+type Admin struct {
+	URL    string
+	Client *http.Client
+}
+
+// Sync makes justvibin's running proxy serve routes. It only patches, adds
+// and deletes the routes that changed. Caddy tries routes in order, so new
+// routes are inserted at their sorted position. Routes from the Caddyfile,
+// which carry no @id, or whose order changed are replaced as a whole. It
+// fails without writing anything when Caddy runs some other config.
+func (a Admin) Sync(ctx context.Context, routes []Route) error {
+	routes = sortRoutes(routes)
+	desired := map[string][]byte{}
+	order := make([]string, 0, len(routes))
+	all := make([]json.RawMessage, 0, len(routes))
+	for _, route := range routes {
+		data, err := json.Marshal(caddyRoute(route))
+		if err != nil {
+			return err
+		}
+		id := routeID(route.Name)
+		desired[id] = data
+		order = append(order, id)
+		all = append(all, data)
+	}
+
+	currentOrder, current, tagged, err := a.currentRoutes(ctx)
+	if err != nil {
+		return err
+	}
+	if !tagged || !sameOrder(currentOrder, order, desired) {
+		data, err := json.Marshal(all)
+		if err != nil {
+			return err
+		}
+		return a.do(ctx, http.MethodPatch, adminRoutesPath, data)
+	}
+	ids := make([]string, 0, len(current))
+	for id := range current {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	count := len(current)
+	for _, id := range ids {
+		data := current[id]
+		want, keep := desired[id]
+		switch {
+		case !keep:
+			err = a.do(ctx, http.MethodDelete, "/id/"+url.PathEscape(id), nil)
+			count--
+		case !bytes.Equal(data, want):
+			err = a.do(ctx, http.MethodPatch, "/id/"+url.PathEscape(id), want)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for i, id := range order {
+		if _, exists := current[id]; exists {
+			continue
+		}
+		if i < count {
+			err = a.do(ctx, http.MethodPut, fmt.Sprintf("%s/%d", adminRoutesPath, i), desired[id])
+		} else {
+			err = a.do(ctx, http.MethodPost, adminRoutesPath, desired[id])
+		}
+		if err != nil {
+			return err
+		}
+		count++
+	}
+	return nil
+}
+
+// sameOrder reports whether the routes Caddy keeps appear in the same
+// relative order as in the desired ones.
+func sameOrder(current, order []string, desired map[string][]byte) bool {
+	kept := make([]string, 0, len(current))
+	for _, id := range current {
+		if _, ok := desired[id]; ok {
+			kept = append(kept, id)
+		}
+	}
+	i := 0
+	for _, id := range order {
+		if i < len(kept) && kept[i] == id {
+			i++
+		}
+	}
+	return i == len(kept)
+}
+
+// currentRoutes returns the routes of justvibin's server in order, and keyed
+// by @id and re-encoded for comparison. tagged is false when some route has
+// no justvibin @id, as after starting from the Caddyfile. It returns
+// errForeignConfig when Caddy has no justvibin server.
+func (a Admin) currentRoutes(ctx context.Context) ([]string, map[string][]byte, bool, error) {
+	body, status, err := a.request(ctx, http.MethodGet, adminRoutesPath, nil)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if status != http.StatusOK {
+		return nil, nil, false, errForeignConfig
+	}
+	var raw []map[string]any
+	if err := json.Unmarshal(body, &raw); err != nil || raw == nil {
+		return nil, nil, false, errForeignConfig
+	}
+	order := make([]string, 0, len(raw))
+	routes := map[string][]byte{}
+	for _, route := range raw {
+		id, _ := route["@id"].(string)
+		if !strings.HasPrefix(id, adminServer+"-") {
+			return nil, nil, false, nil
+		}
+		data, err := json.Marshal(route)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		order = append(order, id)
+		routes[id] = data
+	}
+	return order, routes, true, nil
+}
+
+func (a Admin) do(ctx context.Context, method, path string, body []byte) error {
+	response, status, err := a.request(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("caddy admin %s %s: %d %s", method, path, status, strings.TrimSpace(string(response)))
+	}
+	return nil
+}
+
+func (a Admin) request(ctx context.Context, method, path string, body []byte) ([]byte, int, error) {
+	if a.URL == "" {
+		return nil, 0, errors.New("caddy admin API is disabled")
+	}
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(a.URL, "/")+path, reader)
+	if err != nil {
+		return nil, 0, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	client := a.Client
+	if client == nil {
+		client = adminClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	return data, resp.StatusCode, err
+}
+
+func routeID(name string) string {
+	return adminServer + "-" + name
+}
+
+// sortRoutes orders routes the way Caddy's Caddyfile adapter orders sites:
+// routes with only exact hosts first, then wildcard routes from the most
+// specific wildcard to the least. Otherwise registry order is kept.
+func sortRoutes(routes []Route) []Route {
+	sorted := append([]Route(nil), routes...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := wildcardLabels(sorted[i]), wildcardLabels(sorted[j])
+		if (a == 0) != (b == 0) {
+			return a == 0
+		}
+		return a > b
+	})
+	return sorted
+}
+
+// wildcardLabels counts the labels of the route's broadest wildcard host, so
+// *.app.localhost has 3. It is 0 when the route has no wildcard hosts.
+func wildcardLabels(route Route) int {
+	labels := 0
+	for _, host := range route.Hosts {
+		if !strings.HasPrefix(host, "*.") {
+			continue
+		}
+		if n := strings.Count(host, ".") + 1; labels == 0 || n < labels {
+			labels = n
+		}
+	}
+	return labels
+}
+
+// caddyRoute matches a project's hosts and proxies to its port, with a
+// subroute per path pattern that strips the prefix like handle_path.
+func caddyRoute(route Route) map[string]any {
+	handle := []any{reverseProxy(route.Port)}
+	if len(route.Paths) > 0 {
+		subroutes := make([]any, 0, len(route.Paths)+1)
+		catchAll := false
+		for _, path := range route.Paths {
+			handlers := []any{reverseProxy(path.Port)}
+			if prefix := path.Prefix(); prefix != "" {
+				handlers = append([]any{map[string]any{"handler": "rewrite", "strip_path_prefix": prefix}}, handlers...)
+			}
+			subroutes = append(subroutes, map[string]any{
+				"match":    []any{map[string]any{"path": []string{path.Path}}},
+				"handle":   handlers,
+				"terminal": true,
+			})
+			catchAll = catchAll || path.Path == "/*"
+		}
+		if !catchAll {
+			subroutes = append(subroutes, map[string]any{"handle": []any{reverseProxy(route.Port)}})
+		}
+		handle = []any{map[string]any{"handler": "subroute", "routes": subroutes}}
+	}
+	return map[string]any{
+		"@id":      routeID(route.Name),
+		"match":    []any{map[string]any{"host": route.Hosts}},
+		"handle":   handle,
+		"terminal": true,
+	}
+}
+
+func reverseProxy(port int) map[string]any {
+	return map[string]any{
+		"handler":   "reverse_proxy",
+		"upstreams": []any{map[string]any{"dial": fmt.Sprintf("localhost:%d", port)}},
+	}
+}
@@ -0,0 +1,337 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alexcabrera/justvibin/internal/registry"
+)
+
+// fakeAdmin is a stand-in for Caddy's admin API that keeps the routes of the
+// justvibin server and records every request that changes them. Without
+// serving it behaves like a Caddy running some other config.
+type fakeAdmin struct {
+	mu       sync.Mutex
+	serving  bool
+	routes   []map[string]any
+	requests []string
+}
+
+// newCaddyfileAdmin returns a fakeAdmin for a Caddy started from justvibin's
+// Caddyfile, whose routes carry no @id.
+func newCaddyfileAdmin() *fakeAdmin {
+	return &fakeAdmin{serving: true, routes: []map[string]any{{
+		"match":    []any{map[string]any{"host": []string{"alpha.localhost"}}},
+		"terminal": true,
+	}}}
+}
+
+func (f *fakeAdmin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	body, _ := io.ReadAll(r.Body)
+	if r.Method != http.MethodGet {
+		f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+		if r.Header.Get("Content-Type") != "application/json" && r.Method != http.MethodDelete {
+			http.Error(w, "content type must be application/json", http.StatusBadRequest)
+			return
+		}
+	}
+	if !f.serving {
+		http.Error(w, `{"error":"unknown object key"}`, http.StatusBadRequest)
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/id/")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == adminRoutesPath:
+		_ = json.NewEncoder(w).Encode(f.routes)
+	case r.Method == http.MethodPatch && r.URL.Path == adminRoutesPath:
+		var routes []map[string]any
+		if err := json.Unmarshal(body, &routes); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.routes = routes
+	case r.Method == http.MethodPost && r.URL.Path == adminRoutesPath:
+		var route map[string]any
+		_ = json.Unmarshal(body, &route)
+		f.routes = append(f.routes, route)
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, adminRoutesPath+"/"):
+		i, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, adminRoutesPath+"/"))
+		if err != nil || i < 0 || i > len(f.routes) {
+			http.Error(w, "invalid index", http.StatusBadRequest)
+			return
+		}
+		var route map[string]any
+		_ = json.Unmarshal(body, &route)
+		f.routes = append(f.routes[:i], append([]map[string]any{route}, f.routes[i:]...)...)
+	case r.Method == http.MethodPatch || r.Method == http.MethodDelete:
+		for i, route := range f.routes {
+			if route["@id"] != id {
+				continue
+			}
+			if r.Method == http.MethodDelete {
+				f.routes = append(f.routes[:i], f.routes[i+1:]...)
+			} else {
+				var replacement map[string]any
+				_ = json.Unmarshal(body, &replacement)
+				f.routes[i] = replacement
+			}
+			return
+		}
+		http.Error(w, "unknown object ID", http.StatusNotFound)
+	default:
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+	}
+}
+
+func (f *fakeAdmin) takeRequests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	requests := f.requests
+	f.requests = nil
+	return requests
+}
+
+func (f *fakeAdmin) order() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ids := make([]string, 0, len(f.routes))
+	for _, route := range f.routes {
+		ids = append(ids, route["@id"].(string))
+	}
+	return ids
+}
+
+func (f *fakeAdmin) dials() map[string]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	dials := map[string]string{}
+	for _, route := range f.routes {
+		data, _ := json.Marshal(route["handle"])
+		dials[route["@id"].(string)] = string(data)
+	}
+	return dials
+}
+
+func useAdmin(t *testing.T, url string) {
+	t.Helper()
+	previous := adminURL
+	adminURL = url
+	t.Cleanup(func() { adminURL = previous })
+}
+
+func TestAdminSyncPatchesOnlyChangedRoutes(t *testing.T) {
+	admin := newCaddyfileAdmin()
+	server := httptest.NewServer(admin)
+	defer server.Close()
+	client := Admin{URL: server.URL}
+	ctx := context.Background()
+
+	routes := []Route{
+		{Name: "alpha", Hosts: []string{"alpha.localhost"}, Port: 3000},
+		{Name: "beta", Hosts: []string{"beta.localhost"}, Port: 3001},
+	}
+	if err := client.Sync(ctx, routes); err != nil {
+		t.Fatalf("first sync: %v", err)
+	}
+	if got := admin.takeRequests(); len(got) != 1 || got[0] != "PATCH "+adminRoutesPath {
+		t.Fatalf("expected the Caddyfile routes replaced, got %v", got)
+	}
+
+	if err := client.Sync(ctx, routes); err != nil {
+		t.Fatalf("unchanged sync: %v", err)
+	}
+	if got := admin.takeRequests(); len(got) != 0 {
+		t.Fatalf("expected no writes for unchanged routes, got %v", got)
+	}
+
+	routes = []Route{
+		{Name: "beta", Hosts: []string{"beta.localhost"}, Port: 4001},
+		{Name: "gamma", Hosts: []string{"gamma.localhost"}, Port: 3002},
+	}
+	if err := client.Sync(ctx, routes); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	got := strings.Join(admin.takeRequests(), ", ")
+	want := "DELETE /id/justvibin-alpha, PATCH /id/justvibin-beta, POST " + adminRoutesPath
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	dials := admin.dials()
+	if len(dials) != 2 || !strings.Contains(dials["justvibin-beta"], "localhost:4001") || !strings.Contains(dials["justvibin-gamma"], "localhost:3002") {
+		t.Fatalf("unexpected routes %v", dials)
+	}
+}
+
+func TestAdminSyncOrdersExactHostsBeforeWildcards(t *testing.T) {
+	admin := newCaddyfileAdmin()
+	server := httptest.NewServer(admin)
+	defer server.Close()
+	client := Admin{URL: server.URL}
+	ctx := context.Background()
+
+	wildcard := Route{Name: "myapp", Hosts: []string{"myapp.localhost", "*.myapp.localhost"}, Port: 3000}
+	other := Route{Name: "other", Hosts: []string{"other.localhost"}, Port: 3002}
+	if err := client.Sync(ctx, []Route{wildcard}); err != nil {
+		t.Fatalf("first sync: %v", err)
+	}
+	admin.takeRequests()
+
+	api := Route{Name: "api", Hosts: []string{"api.myapp.localhost"}, Port: 3001}
+	if err := client.Sync(ctx, []Route{wildcard, api, other}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	got := strings.Join(admin.takeRequests(), ", ")
+	want := "PUT " + adminRoutesPath + "/0, PUT " + adminRoutesPath + "/1"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if got := strings.Join(admin.order(), ","); got != "justvibin-api,justvibin-other,justvibin-myapp" {
+		t.Fatalf("expected exact hosts before the wildcard, got %s", got)
+	}
+
+	deeper := Route{Name: "deep", Hosts: []string{"*.api.myapp.localhost"}, Port: 3003}
+	if err := client.Sync(ctx, []Route{wildcard, api, other, deeper}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if got := strings.Join(admin.order(), ","); got != "justvibin-api,justvibin-other,justvibin-deep,justvibin-myapp" {
+		t.Fatalf("expected the narrower wildcard first, got %s", got)
+	}
+
+	fresh := newCaddyfileAdmin()
+	freshServer := httptest.NewServer(fresh)
+	defer freshServer.Close()
+	if err := (Admin{URL: freshServer.URL}).Sync(ctx, []Route{wildcard, api}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if got := strings.Join(fresh.order(), ","); got != "justvibin-api,justvibin-myapp" {
+		t.Fatalf("expected replaced routes to list exact hosts first, got %s", got)
+	}
+}
+
+func TestAdminSyncReloadsWhenRouteOrderChanges(t *testing.T) {
+	admin := newCaddyfileAdmin()
+	server := httptest.NewServer(admin)
+	defer server.Close()
+	client := Admin{URL: server.URL}
+	ctx := context.Background()
+
+	routes := []Route{
+		{Name: "alpha", Hosts: []string{"alpha.localhost"}, Port: 3000},
+		{Name: "beta", Hosts: []string{"beta.localhost"}, Port: 3001},
+	}
+	if err := client.Sync(ctx, routes); err != nil {
+		t.Fatalf("first sync: %v", err)
+	}
+	admin.takeRequests()
+
+	routes[0].Hosts = append(routes[0].Hosts, "*.alpha.localhost")
+	if err := client.Sync(ctx, routes); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if got := admin.takeRequests(); len(got) != 1 || got[0] != "PATCH "+adminRoutesPath {
+		t.Fatalf("expected all routes replaced, got %v", got)
+	}
+	if got := strings.Join(admin.order(), ","); got != "justvibin-beta,justvibin-alpha" {
+		t.Fatalf("expected the wildcard route last, got %s", got)
+	}
+}
+
+func TestAdminSyncLeavesForeignConfigAlone(t *testing.T) {
+	admin := &fakeAdmin{}
+	server := httptest.NewServer(admin)
+	defer server.Close()
+
+	routes := []Route{{Name: "alpha", Hosts: []string{"alpha.localhost"}, Port: 3000}}
+	if err := (Admin{URL: server.URL}).Sync(context.Background(), routes); !errors.Is(err, errForeignConfig) {
+		t.Fatalf("expected a foreign config error, got %v", err)
+	}
+	if got := admin.takeRequests(); len(got) != 0 {
+		t.Fatalf("expected no writes to another Caddy, got %v", got)
+	}
+}
+
+func TestCaddyRouteStripsPathPrefixes(t *testing.T) {
+	route := Route{
+		Name:  "app",
+		Hosts: []string{"app.localhost"},
+		Port:  3000,
+		Paths: []PathRoute{{Path: "/api/*", Port: 3001}},
+	}
+	data, _ := json.Marshal(caddyRoute(route))
+	for _, want := range []string{
+		`"@id":"justvibin-app"`,
+		`"path":["/api/*"]`,
+		`"strip_path_prefix":"/api"`,
+		`"dial":"localhost:3001"`,
+		`{"handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"localhost:3000"}]}]}`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected %s in %s", want, data)
+		}
+	}
+}
+
+func TestGenerateCaddyfileUsesAdminAPI(t *testing.T) {
+	admin := newCaddyfileAdmin()
+	server := httptest.NewServer(admin)
+	defer server.Close()
+	useAdmin(t, server.URL)
+
+	ctx := context.Background()
+	root := t.TempDir()
+	projectsPath := filepath.Join(root, "projects.json")
+	caddyfilePath := filepath.Join(root, "Caddyfile")
+	if _, err := registry.Register(projectsPath, "alpha", 3000, "/tmp/alpha", "hypertext"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	runner := &fakeRunner{}
+	live, err := GenerateCaddyfile(ctx, runner, projectsPath, caddyfilePath)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if !live {
+		t.Fatalf("expected routes applied through the admin API")
+	}
+	if len(runner.calls) != 0 {
+		t.Fatalf("expected no caddy processes, got %+v", runner.calls)
+	}
+	if data, err := os.ReadFile(caddyfilePath); err != nil || !strings.Contains(string(data), "https://alpha.localhost") {
+		t.Fatalf("expected Caddyfile kept for restarts, got %q (%v)", data, err)
+	}
+	if _, ok := admin.dials()["justvibin-alpha"]; !ok {
+		t.Fatalf("expected alpha route in Caddy")
+	}
+
+	server.Close()
+	if live, err = GenerateCaddyfile(ctx, runner, projectsPath, caddyfilePath); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if live || len(runner.calls) == 0 || runner.calls[0].name != "caddy" || runner.calls[0].args[0] != "validate" {
+		t.Fatalf("expected Caddyfile fallback with validation, got %+v", runner.calls)
+	}
+
+	// A Caddy running another config is left alone.
+	foreign := httptest.NewServer(&fakeAdmin{})
+	defer foreign.Close()
+	useAdmin(t, foreign.URL)
+	runner.calls = nil
+	if live, err = GenerateCaddyfile(ctx, runner, projectsPath, caddyfilePath); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if live || len(runner.calls) == 0 || runner.calls[0].args[0] != "validate" {
+		t.Fatalf("expected Caddyfile fallback for a foreign Caddy, got %+v", runner.calls)
+	}
+}
@@ -0,0 +1,257 @@
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alexcabrera/justvibin/internal/config"
+	"github.com/alexcabrera/justvibin/internal/registry"
+	"github.com/fsnotify/fsnotify"
+)
+
+// BuiltinProxyCommand is the hidden justvibin command the proxy service runs
+// with the builtin backend.
+const BuiltinProxyCommand = "__proxy"
+
+// builtinBackend reports whether config.toml selects the builtin proxy, in
+// which case there is no Caddyfile to generate or reload.
+var builtinBackend = func() bool {
+	settings, err := config.LoadSettings()
+	return err == nil && settings.BuiltinProxy()
+}
+
+// Builtin is the in-process HTTPS reverse proxy used when config.toml sets
+// [proxy] backend = "builtin". It serves the same routes as the Caddyfile,
+// with certificates from CA, and picks up registry changes without a reload.
+// WebSocket upgrades are passed through.
+type Builtin struct {
+	ProjectsPath string
+	CA           *CA
+	HTTPSAddr    string
+	HTTPAddr     string
+	Log          io.Writer
+
+	mu     sync.RWMutex
+	routes map[string]Route
+}
+
+// Reload rereads the route table from the registry.
+func (b *Builtin) Reload() error {
+	entries, err := registry.List(b.ProjectsPath)
+	if err != nil {
+		return err
+	}
+	routes := map[string]Route{}
+	for _, route := range Routes(entries) {
+		for _, host := range route.Hosts {
+			routes[host] = route
+		}
+	}
+	b.mu.Lock()
+	b.routes = routes
+	b.mu.Unlock()
+	return nil
+}
+
+// lookup finds the route for host, trying an exact match before a wildcard
+// one level up. It returns the matching route host along with the route.
+func (b *Builtin) lookup(host string) (string, Route, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if route, ok := b.routes[host]; ok {
+		return host, route, true
+	}
+	if _, parent, found := strings.Cut(host, "."); found {
+		wildcard := "*." + parent
+		if route, ok := b.routes[wildcard]; ok {
+			return wildcard, route, true
+		}
+	}
+	return "", Route{}, false
+}
+
+// getCertificate issues a certificate for the route that matches the
+// requested host, so wildcard aliases share one wildcard certificate. Other
+// .localhost hosts get their own so the 404 page is served over HTTPS.
+func (b *Builtin) getCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	host := strings.ToLower(strings.TrimSuffix(hello.ServerName, "."))
+	if name, _, ok := b.lookup(host); ok {
+		return b.CA.Certificate(name)
+	}
+	return b.CA.GetCertificate(hello)
+}
+
+func (b *Builtin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	host := requestHost(r)
+	_, route, ok := b.lookup(host)
+	if !ok {
+		http.Error(w, fmt.Sprintf("no justvibin project at %s", host), http.StatusNotFound)
+		return
+	}
+	port, path := route.Port, r.URL.Path
+	for _, candidate := range route.Paths {
+		if stripped, ok := candidate.Match(r.URL.Path); ok {
+			port, path = candidate.Port, stripped
+			break
+		}
+	}
+	target := &url.URL{Scheme: "http", Host: net.JoinHostPort("localhost", strconv.Itoa(port))}
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.Out.URL.Path, pr.Out.URL.RawPath = path, ""
+			pr.SetURL(target)
+			pr.Out.Host = pr.In.Host
+			pr.SetXForwarded()
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			b.logf("%s %s: %v", host, r.URL.RequestURI(), err)
+			http.Error(w, fmt.Sprintf("%s is not running on port %d", host, port), http.StatusBadGateway)
+		},
+	}
+	proxy.ServeHTTP(w, r)
+}
+
+// Run serves HTTPS on HTTPSAddr and redirects HTTP on HTTPAddr until ctx is
+// cancelled.
+func (b *Builtin) Run(ctx context.Context) error {
+	if b.CA == nil {
+		return errors.New("certificate authority is required")
+	}
+	if err := b.Reload(); err != nil {
+		return err
+	}
+
+	httpsListener, err := net.Listen("tcp", b.HTTPSAddr)
+	if err != nil {
+		return err
+	}
+	servers := []*http.Server{{
+		Handler:           b,
+		TLSConfig:         &tls.Config{GetCertificate: b.getCertificate, MinVersion: tls.VersionTLS12},
+		ReadHeaderTimeout: 10 * time.Second,
+	}}
+	listeners := []net.Listener{tls.NewListener(httpsListener, servers[0].TLSConfig)}
+	if b.HTTPAddr != "" {
+		httpListener, err := net.Listen("tcp", b.HTTPAddr)
+		if err != nil {
+			_ = httpsListener.Close()
+			return err
+		}
+		servers = append(servers, &http.Server{
+			Handler:           http.HandlerFunc(b.redirect),
+			ReadHeaderTimeout: 10 * time.Second,
+		})
+		listeners = append(listeners, httpListener)
+	}
+	b.logf("Serving %s", strings.Join(listenerAddrs(listeners), ", "))
+
+	errc := make(chan error, len(servers)+1)
+	for i, server := range servers {
+		go func(server *http.Server, listener net.Listener) {
+			errc <- server.Serve(listener)
+		}(server, listeners[i])
+	}
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		if err := b.watchRegistry(watchCtx); err != nil {
+			errc <- err
+		}
+	}()
+
+	var runErr error
+	select {
+	case err := <-errc:
+		runErr = err
+	case <-ctx.Done():
+	}
+	shutdown, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelShutdown()
+	for _, server := range servers {
+		_ = server.Shutdown(shutdown)
+	}
+	if errors.Is(runErr, http.ErrServerClosed) {
+		return nil
+	}
+	return runErr
+}
+
+// watchRegistry reloads routes whenever the registry file is replaced or
+// written. The directory is watched because the registry is saved by
+// renaming a temporary file over it.
+func (b *Builtin) watchRegistry(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	if err := watcher.Add(filepath.Dir(b.ProjectsPath)); err != nil {
+		return err
+	}
+	name := filepath.Base(b.ProjectsPath)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Base(event.Name) != name || !(event.Has(fsnotify.Create) || event.Has(fsnotify.Write)) {
+				continue
+			}
+			if err := b.Reload(); err != nil {
+				b.logf("Failed to reload routes: %v", err)
+				continue
+			}
+			b.logf("Reloaded routes")
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func (b *Builtin) redirect(w http.ResponseWriter, r *http.Request) {
+	host := requestHost(r)
+	if _, port, err := net.SplitHostPort(b.HTTPSAddr); err == nil && port != "443" {
+		host = net.JoinHostPort(host, port)
+	}
+	http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+}
+
+func (b *Builtin) logf(format string, args ...any) {
+	if b.Log != nil {
+		fmt.Fprintf(b.Log, "%s "+format+"\n", append([]any{time.Now().Format(time.RFC3339)}, args...)...)
+	}
+}
+
+func requestHost(r *http.Request) string {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+func listenerAddrs(listeners []net.Listener) []string {
+	addrs := make([]string, 0, len(listeners))
+	for _, listener := range listeners {
+		addrs = append(addrs, listener.Addr().String())
+	}
+	return addrs
+}
@@ -0,0 +1,376 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexcabrera/justvibin/internal/config"
+	"github.com/alexcabrera/justvibin/internal/registry"
+)
+
+func init() {
+	// Keep the tests independent of the developer's own config.toml and of
+	// any Caddy running locally.
+	builtinBackend = func() bool { return false }
+	adminURL = ""
+}
+
+func useBuiltinBackend(t *testing.T, builtin bool) {
+	t.Helper()
+	previous := builtinBackend
+	builtinBackend = func() bool { return builtin }
+	t.Cleanup(func() {
+		builtinBackend = previous
+	})
+}
+
+func backendPort(t *testing.T, server *httptest.Server) int {
+	t.Helper()
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("parse url: %v", err)
+	}
+	var port int
+	fmt.Sscanf(u.Port(), "%d", &port)
+	return port
+}
+
+func TestCAIssuesLocalhostCertificates(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "ca")
+	ca, err := LoadOrCreateCA(dir)
+	if err != nil {
+		t.Fatalf("create CA: %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(dir, CAKeyName)); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("expected private CA key, got %v (%v)", info, err)
+	}
+	reloaded, err := LoadOrCreateCA(dir)
+	if err != nil || !reloaded.Cert.Equal(ca.Cert) {
+		t.Fatalf("expected the stored CA to be reused (%v)", err)
+	}
+
+	leaf, err := reloaded.GetCertificate(&tls.ClientHelloInfo{ServerName: "myapp.localhost"})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+	if _, err := leaf.Leaf.Verify(x509.VerifyOptions{DNSName: "myapp.localhost", Roots: roots}); err != nil {
+		t.Fatalf("verify leaf: %v", err)
+	}
+	if again, _ := reloaded.GetCertificate(&tls.ClientHelloInfo{ServerName: "myapp.localhost"}); again != leaf {
+		t.Fatalf("expected cached certificate")
+	}
+	if _, err := reloaded.GetCertificate(&tls.ClientHelloInfo{ServerName: "example.com"}); err == nil {
+		t.Fatalf("expected non-localhost host to be refused")
+	}
+}
+
+func TestBuiltinRoutesByHost(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "host=%s proto=%s", r.Host, r.Header.Get("X-Forwarded-Proto"))
+	}))
+	defer backend.Close()
+	projectsPath := filepath.Join(t.TempDir(), "projects.json")
+	if _, err := registry.Register(projectsPath, "myapp", backendPort(t, backend), "/tmp/myapp", "static"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	b := &Builtin{ProjectsPath: projectsPath}
+	if err := b.Reload(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	front := httptest.NewServer(b)
+	defer front.Close()
+
+	for host, want := range map[string]string{
+		"myapp.localhost": "host=myapp.localhost proto=http",
+		"other.localhost": "no justvibin project at other.localhost",
+	} {
+		req, _ := http.NewRequest(http.MethodGet, front.URL+"/", nil)
+		req.Host = host
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("get %s: %v", host, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if !strings.Contains(string(body), want) {
+			t.Fatalf("%s: expected %q, got %d %q", host, want, resp.StatusCode, body)
+		}
+	}
+}
+
+func TestBuiltinRoutesAliasesAndWildcards(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "host=%s", r.Host)
+	}))
+	defer backend.Close()
+	dir := t.TempDir()
+	projectsPath := filepath.Join(dir, "projects.json")
+	if _, err := registry.Register(projectsPath, "myapp", backendPort(t, backend), "/tmp/myapp", "static"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	for _, host := range []string{"*.myapp.localhost", "myapp.test"} {
+		if _, err := registry.AddAlias(projectsPath, "myapp", host); err != nil {
+			t.Fatalf("alias: %v", err)
+		}
+	}
+	ca, err := LoadOrCreateCA(filepath.Join(dir, "ca"))
+	if err != nil {
+		t.Fatalf("create CA: %v", err)
+	}
+	b := &Builtin{ProjectsPath: projectsPath, CA: ca}
+	if err := b.Reload(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	front := httptest.NewServer(b)
+	defer front.Close()
+
+	for host, want := range map[string]string{
+		"myapp.test":             "host=myapp.test",
+		"acme.myapp.localhost":   "host=acme.myapp.localhost",
+		"a.acme.myapp.localhost": "no justvibin project",
+		"myapp.localhost":        "host=myapp.localhost",
+	} {
+		req, _ := http.NewRequest(http.MethodGet, front.URL+"/", nil)
+		req.Host = host
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("get %s: %v", host, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if !strings.Contains(string(body), want) {
+			t.Fatalf("%s: expected %q, got %q", host, want, body)
+		}
+	}
+
+	cert, err := b.getCertificate(&tls.ClientHelloInfo{ServerName: "acme.myapp.localhost"})
+	if err != nil {
+		t.Fatalf("certificate: %v", err)
+	}
+	if names := cert.Leaf.DNSNames; len(names) != 1 || names[0] != "*.myapp.localhost" {
+		t.Fatalf("expected wildcard certificate, got %v", names)
+	}
+	if _, err := b.getCertificate(&tls.ClientHelloInfo{ServerName: "myapp.test"}); err != nil {
+		t.Fatalf("expected certificate for alias: %v", err)
+	}
+	if _, err := b.getCertificate(&tls.ClientHelloInfo{ServerName: "example.com"}); err == nil {
+		t.Fatalf("expected unrouted host to be refused")
+	}
+}
+
+func TestBuiltinRoutesPathsToProcesses(t *testing.T) {
+	backend := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "%s %s", name, r.URL.RequestURI())
+		}))
+	}
+	api, web := backend("api"), backend("web")
+	defer api.Close()
+	defer web.Close()
+	projectsPath := filepath.Join(t.TempDir(), "projects.json")
+	err := registry.Save(projectsPath, map[string]registry.Project{"app": {
+		Port:   backendPort(t, web),
+		Ports:  map[string]int{"api": backendPort(t, api)},
+		Routes: map[string]string{"/api/*": "api"},
+	}})
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	b := &Builtin{ProjectsPath: projectsPath}
+	if err := b.Reload(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	front := httptest.NewServer(b)
+	defer front.Close()
+
+	for path, want := range map[string]string{
+		"/api/users?page=2": "api /users?page=2",
+		"/apiary":           "web /apiary",
+		"/":                 "web /",
+	} {
+		req, _ := http.NewRequest(http.MethodGet, front.URL+path, nil)
+		req.Host = "app.localhost"
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("get %s: %v", path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if string(body) != want {
+			t.Fatalf("%s: expected %q, got %q", path, want, body)
+		}
+	}
+}
+
+func TestBuiltinPassesWebSocketsThrough(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" {
+			http.Error(w, "upgrade required", http.StatusUpgradeRequired)
+			return
+		}
+		conn, rw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		fmt.Fprint(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+		_ = rw.Flush()
+		line, _ := rw.ReadString('\n')
+		fmt.Fprint(rw, "echo "+line)
+		_ = rw.Flush()
+	}))
+	defer backend.Close()
+	projectsPath := filepath.Join(t.TempDir(), "projects.json")
+	if _, err := registry.Register(projectsPath, "myapp", backendPort(t, backend), "/tmp/myapp", "static"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	b := &Builtin{ProjectsPath: projectsPath}
+	if err := b.Reload(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	front := httptest.NewServer(b)
+	defer front.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(front.URL, "http://"))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	fmt.Fprint(conn, "GET /socket HTTP/1.1\r\nHost: myapp.localhost\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil || resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected 101, got %v (%v)", resp, err)
+	}
+	fmt.Fprint(conn, "hello\n")
+	if line, err := reader.ReadString('\n'); err != nil || line != "echo hello\n" {
+		t.Fatalf("expected echoed frame, got %q (%v)", line, err)
+	}
+}
+
+func TestBuiltinRunServesHTTPSAndReloadsRoutes(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "hello from myapp")
+	}))
+	defer backend.Close()
+	dir := t.TempDir()
+	projectsPath := filepath.Join(dir, "projects.json")
+	if err := registry.Save(projectsPath, map[string]registry.Project{}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	ca, err := LoadOrCreateCA(filepath.Join(dir, "ca"))
+	if err != nil {
+		t.Fatalf("create CA: %v", err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	_ = listener.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b := &Builtin{ProjectsPath: projectsPath, CA: ca, HTTPSAddr: addr}
+	done := make(chan error, 1)
+	go func() { done <- b.Run(ctx) }()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{RootCAs: roots, ServerName: "myapp.localhost"},
+	}}
+	get := func() (int, string) {
+		req, _ := http.NewRequest(http.MethodGet, "https://"+addr+"/", nil)
+		req.Host = "myapp.localhost"
+		resp, err := client.Do(req)
+		if err != nil {
+			return 0, err.Error()
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, _ := get()
+		if status == http.StatusNotFound {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("proxy did not come up")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if _, err := registry.Register(projectsPath, "myapp", backendPort(t, backend), "/tmp/myapp", "static"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	for {
+		status, body := get()
+		if status == http.StatusOK && body == "hello from myapp" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected route after registry change, got %d %q", status, body)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("run: %v", err)
+	}
+}
+
+func TestBuiltinBackendSkipsCaddy(t *testing.T) {
+	useBuiltinBackend(t, true)
+	useServiceManager(t, systemdManager{})
+	previous := executable
+	executable = func() (string, error) { return "/usr/local/bin/justvibin", nil }
+	t.Cleanup(func() { executable = previous })
+
+	ctx := context.Background()
+	root := t.TempDir()
+	runner := &fakeRunner{}
+	caddyfilePath := filepath.Join(root, "Caddyfile")
+	if _, err := GenerateCaddyfile(ctx, runner, filepath.Join(root, "projects.json"), caddyfilePath); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if err := ReloadProxy(ctx, runner, caddyfilePath); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if len(runner.calls) != 0 {
+		t.Fatalf("expected no caddy commands, got %+v", runner.calls)
+	}
+
+	unitPath := filepath.Join(root, config.ProxyUnitName)
+	if err := CreatePlist(ctx, runner, unitPath, caddyfilePath, filepath.Join(root, "proxy.log"), filepath.Join(root, "proxy.err")); err != nil {
+		t.Fatalf("create unit: %v", err)
+	}
+	data, err := os.ReadFile(unitPath)
+	if err != nil {
+		t.Fatalf("read unit: %v", err)
+	}
+	if !strings.Contains(string(data), "ExecStart=\"/usr/local/bin/justvibin\" __proxy\n") || strings.Contains(string(data), "ExecReload") {
+		t.Fatalf("unexpected unit %s", data)
+	}
+}
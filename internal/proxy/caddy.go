@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/alexcabrera/justvibin/internal/config"
@@ -13,26 +14,42 @@ import (
 	"github.com/alexcabrera/justvibin/internal/registry"
 )
 
-func GenerateCaddyfile(ctx context.Context, runner execx.Runner, projectsPath, caddyfilePath string) error {
+// GenerateCaddyfile writes the Caddyfile for the registry. When justvibin's
+// Caddy answers on its admin API, the changed routes are pushed to it first
+// and the Caddyfile is only kept for the next restart, so neither `caddy
+// validate` nor `caddy reload` has to run; live reports that case, where
+// ReloadProxy is not needed. It does nothing with the builtin backend, which
+// reads the registry directly.
+func GenerateCaddyfile(ctx context.Context, runner execx.Runner, projectsPath, caddyfilePath string) (bool, error) {
 	if caddyfilePath == "" {
-		return errors.New("caddyfile path is required")
+		return false, errors.New("caddyfile path is required")
+	}
+	if builtinBackend() {
+		return false, nil
 	}
 	if runner == nil {
 		runner = execx.NewSystemRunner()
 	}
 	entries, err := registry.List(projectsPath)
 	if err != nil {
-		return err
+		return false, err
+	}
+	// The global snippet lives next to the Caddyfile, in the config dir.
+	snippets, err := readSnippets(filepath.Join(filepath.Dir(caddyfilePath), config.CaddySnippetName), entries)
+	if err != nil {
+		return false, err
 	}
-	content := buildCaddyfile(entries)
+	content := buildCaddyfile(entries, snippets)
+	// Snippets are Caddyfile directives, which the JSON routes cannot carry.
+	live := snippets.empty() && Admin{URL: adminURL}.Sync(ctx, Routes(entries)) == nil
 
 	if err := os.MkdirAll(filepath.Dir(caddyfilePath), 0755); err != nil {
-		return err
+		return false, err
 	}
 
 	tempFile, err := os.CreateTemp(filepath.Dir(caddyfilePath), "Caddyfile.*")
 	if err != nil {
-		return err
+		return false, err
 	}
 	tempPath := tempFile.Name()
 	defer func() {
@@ -41,24 +58,27 @@ func GenerateCaddyfile(ctx context.Context, runner execx.Runner, projectsPath, c
 
 	if _, err := tempFile.Write([]byte(content)); err != nil {
 		_ = tempFile.Close()
-		return err
+		return false, err
 	}
 	if err := tempFile.Close(); err != nil {
-		return err
+		return false, err
 	}
 
-	if err := ValidateCaddyfile(ctx, runner, tempPath); err != nil {
-		return err
+	// Caddy already checked the routes when it accepted them.
+	if !live {
+		if err := ValidateCaddyfile(ctx, runner, tempPath); err != nil {
+			return false, snippets.blame(ctx, runner, filepath.Dir(caddyfilePath), entries, err)
+		}
 	}
 
 	if err := backupFile(caddyfilePath); err != nil {
-		return err
+		return false, err
 	}
 
 	if err := os.Rename(tempPath, caddyfilePath); err != nil {
-		return err
+		return false, err
 	}
-	return nil
+	return live, nil
 }
 
 func ValidateCaddyfile(ctx context.Context, runner execx.Runner, caddyfilePath string) error {
@@ -71,10 +91,16 @@ func ValidateCaddyfile(ctx context.Context, runner execx.Runner, caddyfilePath s
 	return runner.Run(ctx, "caddy", "validate", "--config", caddyfilePath)
 }
 
+// ReloadProxy makes a running Caddy pick up the Caddyfile. Callers skip it
+// when GenerateCaddyfile applied the routes live. The builtin backend reloads
+// by itself when the registry changes.
 func ReloadProxy(ctx context.Context, runner execx.Runner, caddyfilePath string) error {
 	if caddyfilePath == "" {
 		return errors.New("caddyfile path is required")
 	}
+	if builtinBackend() {
+		return nil
+	}
 	if runner == nil {
 		runner = execx.NewSystemRunner()
 	}
@@ -84,25 +110,203 @@ func ReloadProxy(ctx context.Context, runner execx.Runner, caddyfilePath string)
 	return runner.Run(ctx, "caddy", "reload", "--config", caddyfilePath)
 }
 
-func IsProxyRunning(ctx context.Context, runner execx.Runner) bool {
-	if runner == nil {
-		runner = execx.NewSystemRunner()
+// Route maps a project's hostnames to the local port serving it. A host may
+// be a "*." wildcard covering one subdomain level.
+type Route struct {
+	Name  string
+	Hosts []string
+	Port  int
+	// Paths send matching requests to other ports, longest prefix first.
+	// Requests no path matches go to Port.
+	Paths []PathRoute
+}
+
+// PathRoute sends requests under a "/prefix/*" pattern to Port with the
+// prefix stripped, as Caddy's handle_path does.
+type PathRoute struct {
+	Path string
+	Port int
+}
+
+// Prefix is the part of the pattern stripped from matching requests.
+func (p PathRoute) Prefix() string {
+	return strings.TrimSuffix(strings.TrimSuffix(p.Path, "*"), "/")
+}
+
+// Match reports whether path falls under the pattern and returns it with the
+// prefix stripped.
+func (p PathRoute) Match(path string) (string, bool) {
+	prefix := p.Prefix()
+	if !strings.HasPrefix(path, prefix+"/") {
+		return "", false
 	}
-	return runner.Run(ctx, "launchctl", "list", config.ProxyLabel) == nil
+	return path[len(prefix):], true
 }
 
-func buildCaddyfile(entries []registry.Entry) string {
-	var builder strings.Builder
-	builder.WriteString("{\n\tlocal_certs\n}\n\n")
+// Routes returns the hostnames the proxy serves for the registry entries:
+// <name>.localhost and each alias.
+func Routes(entries []registry.Entry) []Route {
+	routes := make([]Route, 0, len(entries))
 	for _, entry := range entries {
 		if entry.Name == "" || entry.Project.Port <= 0 {
 			continue
 		}
-		builder.WriteString(fmt.Sprintf("https://%s.localhost {\n\treverse_proxy localhost:%d\n}\n\n", entry.Name, entry.Project.Port))
+		routes = append(routes, Route{
+			Name:  entry.Name,
+			Hosts: registry.Hosts(entry.Name, entry.Project.Aliases),
+			Port:  entry.Project.Port,
+			Paths: pathRoutes(entry.Project),
+		})
+	}
+	return routes
+}
+
+func pathRoutes(project registry.Project) []PathRoute {
+	paths := make([]PathRoute, 0, len(project.Routes))
+	for path, process := range project.Routes {
+		paths = append(paths, PathRoute{Path: path, Port: project.ProcessPort(process)})
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if len(paths[i].Path) != len(paths[j].Path) {
+			return len(paths[i].Path) > len(paths[j].Path)
+		}
+		return paths[i].Path < paths[j].Path
+	})
+	return paths
+}
+
+func buildCaddyfile(entries []registry.Entry, snippets snippets) string {
+	var builder strings.Builder
+	// Naming the server marks a running Caddy as justvibin's for Admin.Sync.
+	builder.WriteString("{\n\tlocal_certs\n\tservers :443 {\n\t\tname " + adminServer + "\n\t}\n}\n\n")
+	for _, route := range Routes(entries) {
+		sites := make([]string, len(route.Hosts))
+		for i, host := range route.Hosts {
+			sites[i] = "https://" + host
+		}
+		builder.WriteString(strings.Join(sites, ", ") + " {\n")
+		builder.WriteString(snippets.global.indented())
+		builder.WriteString(snippets.projects[route.Name].indented())
+		if len(route.Paths) == 0 {
+			builder.WriteString(fmt.Sprintf("\treverse_proxy localhost:%d\n}\n\n", route.Port))
+			continue
+		}
+		catchAll := false
+		for _, path := range route.Paths {
+			builder.WriteString(fmt.Sprintf("\thandle_path %s {\n\t\treverse_proxy localhost:%d\n\t}\n", path.Path, path.Port))
+			catchAll = catchAll || path.Path == "/*"
+		}
+		if !catchAll {
+			builder.WriteString(fmt.Sprintf("\thandle {\n\t\treverse_proxy localhost:%d\n\t}\n", route.Port))
+		}
+		builder.WriteString("}\n\n")
 	}
 	return builder.String()
 }
 
+// snippet is a Caddyfile fragment merged into site blocks.
+type snippet struct {
+	path    string
+	content string
+}
+
+func (s snippet) indented() string {
+	content := strings.TrimSpace(s.content)
+	if content == "" {
+		return ""
+	}
+	var builder strings.Builder
+	for _, line := range strings.Split(content, "\n") {
+		if line = strings.TrimRight(line, " \t\r"); line != "" {
+			builder.WriteString("\t" + line)
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// snippets are the global snippet, merged into every site, and each
+// project's Caddyfile.justvibin, merged into its own site.
+type snippets struct {
+	global   snippet
+	projects map[string]snippet
+}
+
+func readSnippets(globalPath string, entries []registry.Entry) (snippets, error) {
+	read := func(path string) (snippet, error) {
+		data, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return snippet{}, err
+		}
+		return snippet{path: path, content: string(data)}, nil
+	}
+	global, err := read(globalPath)
+	if err != nil {
+		return snippets{}, err
+	}
+	result := snippets{global: global, projects: map[string]snippet{}}
+	for _, entry := range entries {
+		if entry.Project.Path == "" {
+			continue
+		}
+		project, err := read(filepath.Join(entry.Project.Path, config.ProjectSnippetName))
+		if err != nil {
+			return snippets{}, err
+		}
+		if strings.TrimSpace(project.content) != "" {
+			result.projects[entry.Name] = project
+		}
+	}
+	return result, nil
+}
+
+func (s snippets) empty() bool {
+	return strings.TrimSpace(s.global.content) == "" && len(s.projects) == 0
+}
+
+// blame finds the snippet that makes validation fail by validating the
+// Caddyfile with each snippet on its own. It returns err unchanged when no
+// single snippet is at fault.
+func (s snippets) blame(ctx context.Context, runner execx.Runner, dir string, entries []registry.Entry, err error) error {
+	if s.empty() {
+		return err
+	}
+	type candidate struct {
+		label    string
+		snippets snippets
+	}
+	var candidates []candidate
+	if strings.TrimSpace(s.global.content) != "" {
+		candidates = append(candidates, candidate{"global snippet " + s.global.path, snippets{global: s.global}})
+	}
+	names := make([]string, 0, len(s.projects))
+	for name := range s.projects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		only := snippets{projects: map[string]snippet{name: s.projects[name]}}
+		candidates = append(candidates, candidate{fmt.Sprintf("snippet of project '%s' (%s)", name, s.projects[name].path), only})
+	}
+	for _, c := range candidates {
+		file, createErr := os.CreateTemp(dir, "Caddyfile.*")
+		if createErr != nil {
+			return err
+		}
+		_, writeErr := file.WriteString(buildCaddyfile(entries, c.snippets))
+		_ = file.Close()
+		validateErr := writeErr
+		if validateErr == nil {
+			validateErr = ValidateCaddyfile(ctx, runner, file.Name())
+		}
+		_ = os.Remove(file.Name())
+		if validateErr != nil {
+			return fmt.Errorf("%s breaks the Caddyfile: %w", c.label, validateErr)
+		}
+	}
+	return err
+}
+
 func backupFile(path string) error {
 	info, err := os.Stat(path)
 	if err != nil {
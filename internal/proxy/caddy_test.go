@@ -3,6 +3,7 @@ package proxy
 import (
 	"context"
 	"errors"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
@@ -48,10 +49,13 @@ func TestBuildCaddyfile(t *testing.T) {
 		{Name: "beta", Project: registry.Project{Port: 0}},
 	}
 
-	content := buildCaddyfile(entries)
+	content := buildCaddyfile(entries, snippets{})
 	if !strings.Contains(content, "local_certs") {
 		t.Fatalf("expected local_certs")
 	}
+	if !strings.Contains(content, "servers :443 {\n\t\tname justvibin\n\t}") {
+		t.Fatalf("expected the server named for the admin API, got %q", content)
+	}
 	if !strings.Contains(content, "https://alpha.localhost") {
 		t.Fatalf("expected alpha block")
 	}
@@ -60,6 +64,43 @@ func TestBuildCaddyfile(t *testing.T) {
 	}
 }
 
+func TestBuildCaddyfileServesAliases(t *testing.T) {
+	entries := []registry.Entry{
+		{Name: "alpha", Project: registry.Project{Port: 3000, Aliases: []string{"*.alpha.localhost", "alpha.test"}}},
+	}
+
+	content := buildCaddyfile(entries, snippets{})
+	want := "https://alpha.localhost, https://*.alpha.localhost, https://alpha.test {\n\treverse_proxy localhost:3000\n}\n"
+	if !strings.Contains(content, want) {
+		t.Fatalf("expected one site block for every host, got %q", content)
+	}
+}
+
+func TestBuildCaddyfileRendersPathRoutes(t *testing.T) {
+	entries := []registry.Entry{
+		{Name: "app", Project: registry.Project{
+			Port:   3000,
+			Ports:  map[string]int{"api": 3001, "web": 3002},
+			Routes: map[string]string{"/*": "web", "/api/*": "api"},
+		}},
+		{Name: "docs", Project: registry.Project{
+			Port:   3100,
+			Ports:  map[string]int{"search": 3101},
+			Routes: map[string]string{"/search/*": "search"},
+		}},
+	}
+
+	content := buildCaddyfile(entries, snippets{})
+	app := "https://app.localhost {\n\thandle_path /api/* {\n\t\treverse_proxy localhost:3001\n\t}\n\thandle_path /* {\n\t\treverse_proxy localhost:3002\n\t}\n}\n"
+	if !strings.Contains(content, app) {
+		t.Fatalf("expected app routes, got %q", content)
+	}
+	docs := "\thandle_path /search/* {\n\t\treverse_proxy localhost:3101\n\t}\n\thandle {\n\t\treverse_proxy localhost:3100\n\t}\n"
+	if !strings.Contains(content, docs) {
+		t.Fatalf("expected unmatched paths to fall back to the project port, got %q", content)
+	}
+}
+
 func TestGenerateCaddyfileWritesAndBacksUp(t *testing.T) {
 	ctx := context.Background()
 	root := t.TempDir()
@@ -83,7 +124,7 @@ func TestGenerateCaddyfileWritesAndBacksUp(t *testing.T) {
 		return nil
 	}}
 
-	if err := GenerateCaddyfile(ctx, runner, projectsPath, caddyfilePath); err != nil {
+	if _, err := GenerateCaddyfile(ctx, runner, projectsPath, caddyfilePath); err != nil {
 		t.Fatalf("generate: %v", err)
 	}
 
@@ -127,7 +168,7 @@ func TestGenerateCaddyfileValidationFailureKeepsExisting(t *testing.T) {
 		return nil
 	}}
 
-	if err := GenerateCaddyfile(ctx, runner, projectsPath, caddyfilePath); err == nil {
+	if _, err := GenerateCaddyfile(ctx, runner, projectsPath, caddyfilePath); err == nil {
 		t.Fatalf("expected error")
 	}
 
@@ -140,7 +181,91 @@ func TestGenerateCaddyfileValidationFailureKeepsExisting(t *testing.T) {
 	}
 }
 
+func TestBuildCaddyfileMergesSnippets(t *testing.T) {
+	entries := []registry.Entry{
+		{Name: "alpha", Project: registry.Project{Port: 3000}},
+		{Name: "beta", Project: registry.Project{Port: 3001}},
+	}
+	content := buildCaddyfile(entries, snippets{
+		global:   snippet{content: "encode gzip\n"},
+		projects: map[string]snippet{"beta": {content: "header {\n\tX-Env dev\n}"}},
+	})
+	if !strings.Contains(content, "https://alpha.localhost {\n\tencode gzip\n\treverse_proxy") {
+		t.Fatalf("expected global snippet in alpha site, got %q", content)
+	}
+	if !strings.Contains(content, "https://beta.localhost {\n\tencode gzip\n\theader {\n\t\tX-Env dev\n\t}\n\treverse_proxy") {
+		t.Fatalf("expected both snippets in beta site, got %q", content)
+	}
+}
+
+func TestGenerateCaddyfileBlamesBrokenProjectSnippet(t *testing.T) {
+	admin := newCaddyfileAdmin()
+	server := httptest.NewServer(admin)
+	defer server.Close()
+	useAdmin(t, server.URL)
+
+	ctx := context.Background()
+	root := t.TempDir()
+	projectsPath := filepath.Join(root, "projects.json")
+	caddyfilePath := filepath.Join(root, "Caddyfile")
+	alphaDir := filepath.Join(root, "alpha")
+	betaDir := filepath.Join(root, "beta")
+	for _, dir := range []string{alphaDir, betaDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	if _, err := registry.Register(projectsPath, "alpha", 3000, alphaDir, "hypertext"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if _, err := registry.Register(projectsPath, "beta", 3001, betaDir, "hypertext"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "caddy.snippet"), []byte("encode gzip\n"), 0644); err != nil {
+		t.Fatalf("write snippet: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(alphaDir, "Caddyfile.justvibin"), []byte("header X-App alpha\n"), 0644); err != nil {
+		t.Fatalf("write snippet: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(betaDir, "Caddyfile.justvibin"), []byte("bogus_directive\n"), 0644); err != nil {
+		t.Fatalf("write snippet: %v", err)
+	}
+
+	runner := &fakeRunner{run: func(name string, args ...string) error {
+		if name == "caddy" && args[0] == "validate" {
+			data, _ := os.ReadFile(args[2])
+			if strings.Contains(string(data), "bogus_directive") {
+				return errors.New("unrecognized directive: bogus_directive")
+			}
+		}
+		return nil
+	}}
+	_, err := GenerateCaddyfile(ctx, runner, projectsPath, caddyfilePath)
+	if err == nil || !strings.Contains(err.Error(), "project 'beta'") || !strings.Contains(err.Error(), "bogus_directive") {
+		t.Fatalf("expected beta's snippet blamed, got %v", err)
+	}
+	if len(admin.takeRequests()) != 0 {
+		t.Fatalf("expected snippets to bypass the admin API")
+	}
+
+	if err := os.WriteFile(filepath.Join(betaDir, "Caddyfile.justvibin"), []byte("header X-App beta\n"), 0644); err != nil {
+		t.Fatalf("write snippet: %v", err)
+	}
+	live, err := GenerateCaddyfile(ctx, runner, projectsPath, caddyfilePath)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	data, _ := os.ReadFile(caddyfilePath)
+	if !strings.Contains(string(data), "header X-App alpha") || !strings.Contains(string(data), "header X-App beta") {
+		t.Fatalf("expected project snippets in Caddyfile, got %q", data)
+	}
+	if live {
+		t.Fatalf("expected a caddy reload for snippets")
+	}
+}
+
 func TestReloadProxySkipsWhenNotRunning(t *testing.T) {
+	useServiceManager(t, launchdManager{})
 	ctx := context.Background()
 	runner := &fakeRunner{run: func(name string, args ...string) error {
 		if name == "launchctl" {
@@ -160,6 +285,7 @@ func TestReloadProxySkipsWhenNotRunning(t *testing.T) {
 }
 
 func TestReloadProxyRunsWhenRunning(t *testing.T) {
+	useServiceManager(t, launchdManager{})
 	ctx := context.Background()
 	runner := &fakeRunner{run: func(name string, args ...string) error {
 		return nil
@@ -13,32 +13,37 @@ import (
 	execx "github.com/alexcabrera/justvibin/internal/exec"
 )
 
-func CreatePlist(ctx context.Context, runner execx.Runner, plistPath, caddyfilePath, logPath, errPath string) error {
-	if runner == nil {
-		runner = execx.NewSystemRunner()
-	}
+type launchdManager struct{}
+
+func (launchdManager) WriteConfig(_ context.Context, runner execx.Runner, plistPath, caddyfilePath, logPath, errPath string) error {
 	if plistPath == "" || caddyfilePath == "" || logPath == "" || errPath == "" {
 		return errors.New("plist paths are required")
 	}
-	caddyPath, err := runner.LookPath("caddy")
-	if err != nil {
-		return err
+	var content string
+	if builtinBackend() {
+		exe, err := executable()
+		if err != nil {
+			return err
+		}
+		content = plist([]string{exe, BuiltinProxyCommand}, logPath, errPath)
+	} else {
+		caddyPath, err := runner.LookPath("caddy")
+		if err != nil {
+			return err
+		}
+		content = buildPlist(caddyPath, caddyfilePath, logPath, errPath)
 	}
-	content := buildPlist(caddyPath, caddyfilePath, logPath, errPath)
 	if err := os.MkdirAll(filepath.Dir(plistPath), 0755); err != nil {
 		return err
 	}
 	return os.WriteFile(plistPath, []byte(content), 0644)
 }
 
-func InstallProxyService(ctx context.Context, runner execx.Runner, plistPath string) error {
-	if runner == nil {
-		runner = execx.NewSystemRunner()
-	}
+func (m launchdManager) Install(ctx context.Context, runner execx.Runner, plistPath string) error {
 	if plistPath == "" {
 		return errors.New("plist path is required")
 	}
-	if IsProxyRunning(ctx, runner) {
+	if m.IsRunning(ctx, runner) {
 		return nil
 	}
 	uid, err := currentUID()
@@ -48,14 +53,11 @@ func InstallProxyService(ctx context.Context, runner execx.Runner, plistPath str
 	return runner.Run(ctx, "launchctl", "bootstrap", "gui/"+uid, plistPath)
 }
 
-func UninstallProxyService(ctx context.Context, runner execx.Runner, plistPath string) error {
-	if runner == nil {
-		runner = execx.NewSystemRunner()
-	}
+func (m launchdManager) Uninstall(ctx context.Context, runner execx.Runner, plistPath string) error {
 	if plistPath == "" {
 		return errors.New("plist path is required")
 	}
-	if !IsProxyRunning(ctx, runner) {
+	if !m.IsRunning(ctx, runner) {
 		return nil
 	}
 	uid, err := currentUID()
@@ -68,14 +70,15 @@ func UninstallProxyService(ctx context.Context, runner execx.Runner, plistPath s
 	return nil
 }
 
-func RestartProxyService(ctx context.Context, runner execx.Runner, plistPath string) error {
-	if err := UninstallProxyService(ctx, runner, plistPath); err != nil {
-		return err
-	}
-	return InstallProxyService(ctx, runner, plistPath)
+func (launchdManager) IsRunning(ctx context.Context, runner execx.Runner) bool {
+	return runner.Run(ctx, "launchctl", "list", config.ProxyLabel) == nil
 }
 
 func buildPlist(caddyPath, caddyfilePath, logPath, errPath string) string {
+	return plist([]string{caddyPath, "run", "--config", caddyfilePath}, logPath, errPath)
+}
+
+func plist(args []string, logPath, errPath string) string {
 	entries := []string{
 		"<?xml version=\"1.0\" encoding=\"UTF-8\"?>",
 		"<!DOCTYPE plist PUBLIC \"-//Apple//DTD PLIST 1.0//EN\" \"http://www.apple.com/DTDs/PropertyList-1.0.dtd\">",
@@ -85,10 +88,11 @@ func buildPlist(caddyPath, caddyfilePath, logPath, errPath string) string {
 		fmt.Sprintf("    <string>%s</string>", config.ProxyLabel),
 		"    <key>ProgramArguments</key>",
 		"    <array>",
-		fmt.Sprintf("        <string>%s</string>", caddyPath),
-		"        <string>run</string>",
-		"        <string>--config</string>",
-		fmt.Sprintf("        <string>%s</string>", caddyfilePath),
+	}
+	for _, arg := range args {
+		entries = append(entries, fmt.Sprintf("        <string>%s</string>", arg))
+	}
+	entries = append(entries,
 		"    </array>",
 		"    <key>RunAtLoad</key>",
 		"    <true/>",
@@ -100,7 +104,7 @@ func buildPlist(caddyPath, caddyfilePath, logPath, errPath string) string {
 		fmt.Sprintf("    <string>%s</string>", errPath),
 		"</dict>",
 		"</plist>",
-	}
+	)
 	return strings.Join(entries, "\n")
 }
 
@@ -32,6 +32,7 @@ func TestBuildPlistIncludesPaths(t *testing.T) {
 }
 
 func TestCreatePlistWritesFile(t *testing.T) {
+	useServiceManager(t, launchdManager{})
 	ctx := context.Background()
 	root := t.TempDir()
 	plistPath := filepath.Join(root, "proxy.plist")
@@ -60,6 +61,7 @@ func TestCreatePlistWritesFile(t *testing.T) {
 }
 
 func TestInstallProxyServiceBootstrapsWhenStopped(t *testing.T) {
+	useServiceManager(t, launchdManager{})
 	ctx := context.Background()
 	plistPath := "/tmp/justvibin.plist"
 	uid := strconv.Itoa(os.Getuid())
@@ -90,6 +92,7 @@ func TestInstallProxyServiceBootstrapsWhenStopped(t *testing.T) {
 }
 
 func TestInstallProxyServiceSkipsWhenRunning(t *testing.T) {
+	useServiceManager(t, launchdManager{})
 	ctx := context.Background()
 	runner := &fakeRunner{run: func(name string, args ...string) error {
 		return nil
@@ -106,6 +109,7 @@ func TestInstallProxyServiceSkipsWhenRunning(t *testing.T) {
 }
 
 func TestUninstallProxyServiceBootoutWhenRunning(t *testing.T) {
+	useServiceManager(t, launchdManager{})
 	ctx := context.Background()
 	plistPath := "/tmp/justvibin.plist"
 	uid := strconv.Itoa(os.Getuid())
@@ -133,6 +137,7 @@ func TestUninstallProxyServiceBootoutWhenRunning(t *testing.T) {
 }
 
 func TestUninstallProxyServiceSkipsWhenStopped(t *testing.T) {
+	useServiceManager(t, launchdManager{})
 	ctx := context.Background()
 	runner := &fakeRunner{run: func(name string, args ...string) error {
 		if name == "launchctl" && len(args) > 0 && args[0] == "list" {
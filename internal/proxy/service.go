@@ -0,0 +1,66 @@
+package proxy
+
+import (
+	"context"
+	"os"
+	"runtime"
+
+	execx "github.com/alexcabrera/justvibin/internal/exec"
+)
+
+// ServiceManager registers the proxy with the host's service manager
+// (launchd on macOS, systemd --user on Linux). The service runs Caddy, or
+// `justvibin __proxy` with the builtin backend.
+type ServiceManager interface {
+	WriteConfig(ctx context.Context, runner execx.Runner, servicePath, caddyfilePath, logPath, errPath string) error
+	Install(ctx context.Context, runner execx.Runner, servicePath string) error
+	Uninstall(ctx context.Context, runner execx.Runner, servicePath string) error
+	IsRunning(ctx context.Context, runner execx.Runner) bool
+}
+
+var serviceManager = ServiceManagerFor(runtime.GOOS)
+
+var executable = os.Executable
+
+// ServiceManagerFor returns the service backend used on the given GOOS.
+func ServiceManagerFor(goos string) ServiceManager {
+	if goos == "linux" {
+		return systemdManager{}
+	}
+	return launchdManager{}
+}
+
+func CreatePlist(ctx context.Context, runner execx.Runner, plistPath, caddyfilePath, logPath, errPath string) error {
+	if runner == nil {
+		runner = execx.NewSystemRunner()
+	}
+	return serviceManager.WriteConfig(ctx, runner, plistPath, caddyfilePath, logPath, errPath)
+}
+
+func InstallProxyService(ctx context.Context, runner execx.Runner, plistPath string) error {
+	if runner == nil {
+		runner = execx.NewSystemRunner()
+	}
+	return serviceManager.Install(ctx, runner, plistPath)
+}
+
+func UninstallProxyService(ctx context.Context, runner execx.Runner, plistPath string) error {
+	if runner == nil {
+		runner = execx.NewSystemRunner()
+	}
+	return serviceManager.Uninstall(ctx, runner, plistPath)
+}
+
+func RestartProxyService(ctx context.Context, runner execx.Runner, plistPath string) error {
+	if err := UninstallProxyService(ctx, runner, plistPath); err != nil {
+		return err
+	}
+	return InstallProxyService(ctx, runner, plistPath)
+}
+
+func IsProxyRunning(ctx context.Context, runner execx.Runner) bool {
+	if runner == nil {
+		runner = execx.NewSystemRunner()
+	}
+	return serviceManager.IsRunning(ctx, runner)
+}
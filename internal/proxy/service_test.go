@@ -0,0 +1,21 @@
+package proxy
+
+import "testing"
+
+func useServiceManager(t *testing.T, manager ServiceManager) {
+	t.Helper()
+	previous := serviceManager
+	serviceManager = manager
+	t.Cleanup(func() {
+		serviceManager = previous
+	})
+}
+
+func TestServiceManagerForPlatform(t *testing.T) {
+	if _, ok := ServiceManagerFor("linux").(systemdManager); !ok {
+		t.Fatalf("expected systemd manager on linux")
+	}
+	if _, ok := ServiceManagerFor("darwin").(launchdManager); !ok {
+		t.Fatalf("expected launchd manager on darwin")
+	}
+}
@@ -0,0 +1,106 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexcabrera/justvibin/internal/config"
+	execx "github.com/alexcabrera/justvibin/internal/exec"
+)
+
+type systemdManager struct{}
+
+func (systemdManager) WriteConfig(_ context.Context, runner execx.Runner, unitPath, caddyfilePath, logPath, errPath string) error {
+	if unitPath == "" || caddyfilePath == "" || logPath == "" || errPath == "" {
+		return errors.New("unit paths are required")
+	}
+	var content string
+	if builtinBackend() {
+		exe, err := executable()
+		if err != nil {
+			return err
+		}
+		content = systemdUnit("builtin", systemdQuote(exe)+" "+BuiltinProxyCommand, "", logPath, errPath)
+	} else {
+		caddyPath, err := runner.LookPath("caddy")
+		if err != nil {
+			return err
+		}
+		content = buildSystemdUnit(caddyPath, caddyfilePath, logPath, errPath)
+	}
+	if err := os.MkdirAll(filepath.Dir(unitPath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(unitPath, []byte(content), 0644)
+}
+
+func (m systemdManager) Install(ctx context.Context, runner execx.Runner, unitPath string) error {
+	if unitPath == "" {
+		return errors.New("unit path is required")
+	}
+	if m.IsRunning(ctx, runner) {
+		return nil
+	}
+	if err := runner.Run(ctx, "systemctl", "--user", "daemon-reload"); err != nil {
+		return err
+	}
+	return runner.Run(ctx, "systemctl", "--user", "enable", "--now", config.ProxyUnitName)
+}
+
+func (m systemdManager) Uninstall(ctx context.Context, runner execx.Runner, unitPath string) error {
+	if unitPath == "" {
+		return errors.New("unit path is required")
+	}
+	if _, err := os.Stat(unitPath); err != nil && !m.IsRunning(ctx, runner) {
+		return nil
+	}
+	// Disable as well as stop, or the unit starts again at the next login.
+	return runner.Run(ctx, "systemctl", "--user", "disable", "--now", config.ProxyUnitName)
+}
+
+func (systemdManager) IsRunning(ctx context.Context, runner execx.Runner) bool {
+	return runner.Run(ctx, "systemctl", "--user", "is-active", "--quiet", config.ProxyUnitName) == nil
+}
+
+func buildSystemdUnit(caddyPath, caddyfilePath, logPath, errPath string) string {
+	return systemdUnit("Caddy",
+		fmt.Sprintf("%s run --config %s", systemdQuote(caddyPath), systemdQuote(caddyfilePath)),
+		fmt.Sprintf("%s reload --config %s", systemdQuote(caddyPath), systemdQuote(caddyfilePath)),
+		logPath, errPath)
+}
+
+// systemdQuote quotes a path for an Exec line, so paths with spaces stay one
+// argument and a literal % is not read as a unit specifier.
+func systemdQuote(path string) string {
+	path = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%").Replace(path)
+	return `"` + path + `"`
+}
+
+func systemdUnit(backend, execStart, execReload, logPath, errPath string) string {
+	entries := []string{
+		"[Unit]",
+		fmt.Sprintf("Description=justvibin HTTPS proxy (%s)", backend),
+		"After=network.target",
+		"",
+		"[Service]",
+		fmt.Sprintf("ExecStart=%s", execStart),
+	}
+	if execReload != "" {
+		entries = append(entries, fmt.Sprintf("ExecReload=%s", execReload))
+	}
+	entries = append(entries,
+		"Restart=always",
+		"RestartSec=2",
+		fmt.Sprintf("StandardOutput=append:%s", logPath),
+		fmt.Sprintf("StandardError=append:%s", errPath),
+		"",
+		"[Install]",
+		"WantedBy=default.target",
+		"",
+	)
+	return strings.Join(entries, "\n")
+}
@@ -0,0 +1,166 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexcabrera/justvibin/internal/config"
+)
+
+func TestBuildSystemdUnitIncludesPaths(t *testing.T) {
+	content := buildSystemdUnit("/usr/bin/caddy", "/tmp/Caddyfile", "/tmp/proxy.log", "/tmp/proxy.err")
+	for _, want := range []string{
+		`ExecStart="/usr/bin/caddy" run --config "/tmp/Caddyfile"`,
+		`ExecReload="/usr/bin/caddy" reload --config "/tmp/Caddyfile"`,
+		"StandardOutput=append:/tmp/proxy.log",
+		"StandardError=append:/tmp/proxy.err",
+		"WantedBy=default.target",
+	} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("expected %q in unit", want)
+		}
+	}
+}
+
+func TestBuildSystemdUnitQuotesPaths(t *testing.T) {
+	content := buildSystemdUnit("/opt/my tools/caddy", "/home/me/Application Support/100%/Caddyfile", "/tmp/proxy.log", "/tmp/proxy.err")
+	want := `ExecStart="/opt/my tools/caddy" run --config "/home/me/Application Support/100%%/Caddyfile"`
+	if !strings.Contains(content, want) {
+		t.Fatalf("expected %q in unit, got:\n%s", want, content)
+	}
+}
+
+func TestCreatePlistWritesSystemdUnit(t *testing.T) {
+	useServiceManager(t, systemdManager{})
+	ctx := context.Background()
+	root := t.TempDir()
+	unitPath := filepath.Join(root, "systemd", "user", config.ProxyUnitName)
+
+	runner := &fakeRunner{lookPath: func(name string) (string, error) {
+		if name != "caddy" {
+			return "", errors.New("unexpected binary")
+		}
+		return "/usr/bin/caddy", nil
+	}}
+
+	if err := CreatePlist(ctx, runner, unitPath, filepath.Join(root, "Caddyfile"), filepath.Join(root, "proxy.log"), filepath.Join(root, "proxy.err")); err != nil {
+		t.Fatalf("create unit: %v", err)
+	}
+
+	data, err := os.ReadFile(unitPath)
+	if err != nil {
+		t.Fatalf("read unit: %v", err)
+	}
+	if !strings.Contains(string(data), `ExecStart="/usr/bin/caddy" run`) {
+		t.Fatalf("expected caddy exec line in unit")
+	}
+}
+
+func TestInstallProxyServiceEnablesSystemdUnit(t *testing.T) {
+	useServiceManager(t, systemdManager{})
+	ctx := context.Background()
+
+	runner := &fakeRunner{run: func(name string, args ...string) error {
+		if name == "systemctl" && len(args) > 1 && args[1] == "is-active" {
+			return errors.New("inactive")
+		}
+		return nil
+	}}
+
+	if err := InstallProxyService(ctx, runner, "/tmp/justvibin-proxy.service"); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+
+	foundReload := false
+	foundEnable := false
+	for _, call := range runner.calls {
+		if call.name != "systemctl" || len(call.args) < 2 || call.args[0] != "--user" {
+			continue
+		}
+		switch call.args[1] {
+		case "daemon-reload":
+			foundReload = true
+		case "enable":
+			if strings.Join(call.args[2:], " ") != "--now "+config.ProxyUnitName {
+				t.Fatalf("unexpected enable args: %v", call.args)
+			}
+			foundEnable = true
+		}
+	}
+	if !foundReload || !foundEnable {
+		t.Fatalf("expected daemon-reload and enable calls")
+	}
+}
+
+func TestInstallProxyServiceSkipsWhenSystemdUnitActive(t *testing.T) {
+	useServiceManager(t, systemdManager{})
+	runner := &fakeRunner{}
+
+	if err := InstallProxyService(context.Background(), runner, "/tmp/justvibin-proxy.service"); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	for _, call := range runner.calls {
+		if call.name == "systemctl" && len(call.args) > 1 && call.args[1] == "enable" {
+			t.Fatalf("unexpected enable")
+		}
+	}
+}
+
+func TestUninstallProxyServiceDisablesSystemdUnit(t *testing.T) {
+	useServiceManager(t, systemdManager{})
+	runner := &fakeRunner{}
+
+	if err := UninstallProxyService(context.Background(), runner, "/tmp/justvibin-proxy.service"); err != nil {
+		t.Fatalf("uninstall: %v", err)
+	}
+
+	foundDisable := false
+	for _, call := range runner.calls {
+		if call.name == "systemctl" && strings.Join(call.args, " ") == "--user disable --now "+config.ProxyUnitName {
+			foundDisable = true
+		}
+	}
+	if !foundDisable {
+		t.Fatalf("expected disable --now call, got %v", runner.calls)
+	}
+}
+
+func TestUninstallProxyServiceDisablesStoppedSystemdUnit(t *testing.T) {
+	useServiceManager(t, systemdManager{})
+	unitPath := filepath.Join(t.TempDir(), config.ProxyUnitName)
+	if err := os.WriteFile(unitPath, []byte("[Unit]\n"), 0644); err != nil {
+		t.Fatalf("write unit: %v", err)
+	}
+	runner := &fakeRunner{run: func(name string, args ...string) error {
+		if len(args) > 1 && args[1] == "is-active" {
+			return errors.New("inactive")
+		}
+		return nil
+	}}
+
+	if err := UninstallProxyService(context.Background(), runner, unitPath); err != nil {
+		t.Fatalf("uninstall: %v", err)
+	}
+	last := runner.calls[len(runner.calls)-1]
+	if strings.Join(last.args, " ") != "--user disable --now "+config.ProxyUnitName {
+		t.Fatalf("expected an enabled but stopped unit to be disabled, got %v", runner.calls)
+	}
+}
+
+func TestIsProxyRunningUsesSystemctl(t *testing.T) {
+	useServiceManager(t, systemdManager{})
+	runner := &fakeRunner{run: func(name string, args ...string) error {
+		return errors.New("inactive")
+	}}
+
+	if IsProxyRunning(context.Background(), runner) {
+		t.Fatalf("expected proxy not running")
+	}
+	if len(runner.calls) != 1 || runner.calls[0].name != "systemctl" {
+		t.Fatalf("expected a single systemctl call")
+	}
+}
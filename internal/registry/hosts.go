@@ -0,0 +1,111 @@
+package registry
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Hosts returns every hostname a project is served on: <name>.localhost
+// followed by its aliases.
+func Hosts(name string, aliases []string) []string {
+	return append([]string{name + ".localhost"}, aliases...)
+}
+
+// URLs returns the HTTPS URL of every host in Hosts.
+func URLs(name string, aliases []string) []string {
+	hosts := Hosts(name, aliases)
+	urls := make([]string, len(hosts))
+	for i, host := range hosts {
+		urls[i] = "https://" + host
+	}
+	return urls
+}
+
+// NormalizeHost lowercases host and checks that it is a hostname with at
+// least two labels, optionally behind a single leading "*." wildcard that
+// covers one subdomain level.
+func NormalizeHost(host string) (string, error) {
+	normalized := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	labels := strings.Split(strings.TrimPrefix(normalized, "*."), ".")
+	if len(labels) < 2 {
+		return "", fmt.Errorf("invalid domain %q: expected a name such as myapp.test or *.myapp.localhost", host)
+	}
+	for _, label := range labels {
+		if !validLabel(label) {
+			return "", fmt.Errorf("invalid domain %q", host)
+		}
+	}
+	return normalized, nil
+}
+
+func validLabel(label string) bool {
+	if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+		return false
+	}
+	for _, r := range label {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// AddAlias adds host to a registered project. A host already served by
+// another project is refused; adding one the project already has is a no-op.
+func AddAlias(path, name, host string) (Project, error) {
+	host, err := NormalizeHost(host)
+	if err != nil {
+		return Project{}, err
+	}
+	var project Project
+	err = Update(path, func(projects map[string]Project) error {
+		existing, ok := projects[name]
+		if !ok {
+			return fmt.Errorf("project '%s' not found", name)
+		}
+		for other, p := range projects {
+			if other != name && slices.Contains(Hosts(other, p.Aliases), host) {
+				return fmt.Errorf("%s is already used by project '%s'", host, other)
+			}
+		}
+		if !slices.Contains(Hosts(name, existing.Aliases), host) {
+			existing.Aliases = append(existing.Aliases, host)
+		}
+		projects[name] = existing
+		project = existing
+		return nil
+	})
+	if err != nil {
+		return Project{}, err
+	}
+	return project, nil
+}
+
+// RemoveAlias removes host from a registered project, reporting whether the
+// project had it.
+func RemoveAlias(path, name, host string) (Project, bool, error) {
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	var project Project
+	removed := false
+	err := Update(path, func(projects map[string]Project) error {
+		existing, ok := projects[name]
+		if !ok {
+			return fmt.Errorf("project '%s' not found", name)
+		}
+		if i := slices.Index(existing.Aliases, host); i >= 0 {
+			existing.Aliases = slices.Delete(existing.Aliases, i, i+1)
+			if len(existing.Aliases) == 0 {
+				existing.Aliases = nil
+			}
+			removed = true
+		}
+		projects[name] = existing
+		project = existing
+		return nil
+	})
+	if err != nil {
+		return Project{}, false, err
+	}
+	return project, removed, nil
+}
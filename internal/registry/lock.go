@@ -0,0 +1,37 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// lockRegistry takes an exclusive advisory lock on a sidecar file next to the
+// registry so read-modify-write cycles from concurrent justvibin processes are
+// serialized. The returned func releases the lock.
+func lockRegistry(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := flock(file, syscall.LOCK_EX); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return func() {
+		_ = flock(file, syscall.LOCK_UN)
+		_ = file.Close()
+	}, nil
+}
+
+func flock(file *os.File, how int) error {
+	for {
+		err := syscall.Flock(int(file.Fd()), how)
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentRegisterNextKeepsEveryProject(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "projects.json")
+	const workers = 40
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("project-%02d", i)
+			if _, err := RegisterNext(path, name, "/tmp/"+name, "hypertext"); err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("register: %v", err)
+	}
+
+	projects, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(projects) != workers {
+		t.Fatalf("expected %d projects, got %d", workers, len(projects))
+	}
+	ports := map[int]string{}
+	for name, project := range projects {
+		if other, ok := ports[project.Port]; ok {
+			t.Fatalf("port %d assigned to both %s and %s", project.Port, other, name)
+		}
+		ports[project.Port] = name
+	}
+}
+
+func TestUpdateDoesNotSaveOnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "projects.json")
+	if _, err := Register(path, "alpha", 3000, "/tmp/alpha", "hypertext"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	boom := errors.New("boom")
+	err := Update(path, func(projects map[string]Project) error {
+		delete(projects, "alpha")
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("expected fn error, got %v", err)
+	}
+
+	exists, err := Exists(path, "alpha")
+	if err != nil || !exists {
+		t.Fatalf("expected alpha to survive failed update")
+	}
+}
+
+func TestRegisterNextReusesPortForSamePath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "projects.json")
+	first, err := RegisterNext(path, "alpha", "/tmp/alpha", "hypertext")
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if _, err := RegisterNext(path, "beta", "/tmp/beta", "hypertext"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	again, err := RegisterNext(path, "alpha", "/tmp/alpha", "hypertext")
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if again.Port != first.Port {
+		t.Fatalf("expected port %d to be kept, got %d", first.Port, again.Port)
+	}
+}
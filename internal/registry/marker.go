@@ -8,10 +8,17 @@ import (
 )
 
 type Marker struct {
-	Name     string `json:"name"`
-	Template string `json:"template"`
-	Port     int    `json:"port"`
-	Created  string `json:"created"`
+	Version  int      `json:"version"`
+	Name     string   `json:"name"`
+	Template string   `json:"template"`
+	Port     int      `json:"port"`
+	Created  string   `json:"created"`
+	Aliases  []string `json:"aliases,omitempty"`
+	// TemplateVersion, TemplateCommit and TemplateVariables record the
+	// template revision the project was scaffolded or last upgraded from.
+	TemplateVersion   string            `json:"template_version,omitempty"`
+	TemplateCommit    string            `json:"template_commit,omitempty"`
+	TemplateVariables map[string]string `json:"template_variables,omitempty"`
 }
 
 func WriteMarker(projectDir, name, template string, port int) (Marker, error) {
@@ -21,6 +28,11 @@ func WriteMarker(projectDir, name, template string, port int) (Marker, error) {
 		Port:     port,
 		Created:  time.Now().UTC().Format(time.RFC3339),
 	}
+	return writeMarker(projectDir, marker)
+}
+
+func writeMarker(projectDir string, marker Marker) (Marker, error) {
+	marker.Version = MarkerVersion
 	data, err := json.MarshalIndent(marker, "", "  ")
 	if err != nil {
 		return Marker{}, err
@@ -38,8 +50,30 @@ func ReadMarker(projectDir string) (Marker, error) {
 	if err != nil {
 		return Marker{}, err
 	}
+	return decodeMarker(data)
+}
+
+// decodeMarker parses a marker of any known version, migrating it in memory
+// to MarkerVersion. The file on disk is upgraded the next time it is written.
+func decodeMarker(data []byte) (Marker, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return Marker{}, err
+	}
+	version, err := markerVersion(raw)
+	if err != nil {
+		return Marker{}, err
+	}
+	raw, err = migrate(raw, version, MarkerVersion, markerMigrations)
+	if err != nil {
+		return Marker{}, err
+	}
+	migrated, err := json.Marshal(raw)
+	if err != nil {
+		return Marker{}, err
+	}
 	var marker Marker
-	if err := json.Unmarshal(data, &marker); err != nil {
+	if err := json.Unmarshal(migrated, &marker); err != nil {
 		return Marker{}, err
 	}
 	return marker, nil
@@ -60,13 +94,29 @@ func UpdateMarkerPort(projectDir string, port int) (Marker, error) {
 		return Marker{}, err
 	}
 	marker.Port = port
-	data, err := json.MarshalIndent(marker, "", "  ")
+	return writeMarker(projectDir, marker)
+}
+
+// UpdateMarkerAliases replaces the aliases in an existing marker file,
+// preserving other fields.
+func UpdateMarkerAliases(projectDir string, aliases []string) (Marker, error) {
+	marker, err := ReadMarker(projectDir)
 	if err != nil {
 		return Marker{}, err
 	}
-	path := filepath.Join(projectDir, ".justvibin")
-	if err := os.WriteFile(path, data, 0644); err != nil {
+	marker.Aliases = aliases
+	return writeMarker(projectDir, marker)
+}
+
+// UpdateMarkerTemplate records the template revision and variable values in
+// an existing marker file, preserving other fields.
+func UpdateMarkerTemplate(projectDir, version, commit string, variables map[string]string) (Marker, error) {
+	marker, err := ReadMarker(projectDir)
+	if err != nil {
 		return Marker{}, err
 	}
-	return marker, nil
+	marker.TemplateVersion = version
+	marker.TemplateCommit = commit
+	marker.TemplateVariables = variables
+	return writeMarker(projectDir, marker)
 }
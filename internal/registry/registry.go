@@ -13,11 +13,28 @@ import (
 	"github.com/alexcabrera/justvibin/internal/config"
 )
 
+// ErrCorruptRegistry is returned by Load when projects.json exists but cannot
+// be parsed. Callers must not overwrite the file in that case.
+var ErrCorruptRegistry = errors.New("project registry is corrupt")
+
 type Project struct {
-	Port     int    `json:"port"`
-	Path     string `json:"path"`
-	Template string `json:"template"`
-	Created  string `json:"created"`
+	Port        int               `json:"port"`
+	Path        string            `json:"path"`
+	Template    string            `json:"template"`
+	Created     string            `json:"created"`
+	Aliases     []string          `json:"aliases,omitempty"`
+	LastStarted string            `json:"last_started,omitempty"`
+	Ports       map[string]int    `json:"ports,omitempty"`
+	Routes      map[string]string `json:"routes,omitempty"`
+}
+
+// ProcessPort returns the port of a named process: its own registry port, or
+// the project port for the process that receives it.
+func (p Project) ProcessPort(process string) int {
+	if port, ok := p.Ports[process]; ok {
+		return port
+	}
+	return p.Port
 }
 
 type Entry struct {
@@ -33,61 +50,151 @@ func Load(path string) (map[string]Project, error) {
 		}
 		return nil, err
 	}
+	return decode(data)
+}
+
+func decode(data []byte) (map[string]Project, error) {
 	if len(data) == 0 {
 		return map[string]Project{}, nil
 	}
 
-	var projects map[string]Project
-	if err := json.Unmarshal(data, &projects); err != nil {
-		return map[string]Project{}, nil
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptRegistry, err)
 	}
-	if projects == nil {
-		projects = map[string]Project{}
+	raw, err := migrate(raw, registryVersion(raw), SchemaVersion, registryMigrations)
+	if err != nil {
+		return nil, err
 	}
-	return projects, nil
+	migrated, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var doc document
+	if err := json.Unmarshal(migrated, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptRegistry, err)
+	}
+	if doc.Projects == nil {
+		doc.Projects = map[string]Project{}
+	}
+	return doc.Projects, nil
 }
 
 func Save(path string, projects map[string]Project) error {
 	if projects == nil {
 		projects = map[string]Project{}
 	}
-	data, err := json.MarshalIndent(projects, "", "  ")
+	data, err := json.MarshalIndent(document{Version: SchemaVersion, Projects: projects}, "", "  ")
 	if err != nil {
 		return err
 	}
+	if err := backupRegistry(path); err != nil {
+		return err
+	}
 	return writeAtomically(path, data, 0644)
 }
 
-func Register(path, name string, port int, projectPath, template string) (Project, error) {
+// BackupPath returns the location of the copy Save keeps of the previous
+// registry contents.
+func BackupPath(path string) string {
+	return path + ".bak"
+}
+
+// backupRegistry copies the current registry to BackupPath before it is
+// replaced. A file that no longer parses is never copied over a good backup.
+func backupRegistry(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if _, err := decode(data); err != nil {
+		return nil
+	}
+	return writeAtomically(BackupPath(path), data, 0644)
+}
+
+// Update loads the registry, applies fn and saves the result while holding the
+// registry lock. Nothing is written if fn returns an error.
+func Update(path string, fn func(map[string]Project) error) error {
+	unlock, err := lockRegistry(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	projects, err := Load(path)
 	if err != nil {
-		return Project{}, err
+		return err
 	}
-	created := time.Now().UTC().Format(time.RFC3339)
-	if existing, ok := projects[name]; ok && existing.Created != "" {
-		created = existing.Created
+	if err := fn(projects); err != nil {
+		return err
 	}
-	project := Project{Port: port, Path: projectPath, Template: template, Created: created}
-	projects[name] = project
-	if err := Save(path, projects); err != nil {
+	return Save(path, projects)
+}
+
+func Register(path, name string, port int, projectPath, template string) (Project, error) {
+	var project Project
+	err := Update(path, func(projects map[string]Project) error {
+		project = upsert(projects, name, port, projectPath, template)
+		return nil
+	})
+	if err != nil {
+		return Project{}, err
+	}
+	return project, nil
+}
+
+// RegisterNext registers a project on the next free registry port, picking the
+// port under the same lock as the write so concurrent callers never collide.
+// A project re-registered from the same path keeps its existing port.
+func RegisterNext(path, name, projectPath, template string) (Project, error) {
+	var project Project
+	err := Update(path, func(projects map[string]Project) error {
+		port := nextPortIn(projects)
+		if existing, ok := projects[name]; ok && existing.Path == projectPath && existing.Port > 0 {
+			port = existing.Port
+		}
+		project = upsert(projects, name, port, projectPath, template)
+		return nil
+	})
+	if err != nil {
 		return Project{}, err
 	}
 	return project, nil
 }
 
 func Unregister(path, name string) (bool, error) {
-	projects, err := Load(path)
+	removed := false
+	err := Update(path, func(projects map[string]Project) error {
+		if _, ok := projects[name]; !ok {
+			return nil
+		}
+		delete(projects, name)
+		removed = true
+		return nil
+	})
 	if err != nil {
 		return false, err
 	}
-	if _, ok := projects[name]; !ok {
-		return false, nil
+	return removed, nil
+}
+
+func upsert(projects map[string]Project, name string, port int, projectPath, template string) Project {
+	project := projects[name]
+	if project.Path != projectPath {
+		project = Project{Created: project.Created}
 	}
-	delete(projects, name)
-	if err := Save(path, projects); err != nil {
-		return false, err
+	if project.Created == "" {
+		project.Created = time.Now().UTC().Format(time.RFC3339)
 	}
-	return true, nil
+	project.Port = port
+	project.Path = projectPath
+	project.Template = template
+	projects[name] = project
+	return project
 }
 
 func Get(path, name string) (Project, bool, error) {
@@ -176,16 +283,25 @@ func NextPort(path string) (int, error) {
 	if err != nil {
 		return 0, err
 	}
+	return nextPortIn(projects), nil
+}
+
+func nextPortIn(projects map[string]Project) int {
 	maxPort := config.BasePort - 1
 	for _, project := range projects {
 		if project.Port > maxPort {
 			maxPort = project.Port
 		}
+		for _, port := range project.Ports {
+			if port > maxPort {
+				maxPort = port
+			}
+		}
 	}
 	if maxPort < config.BasePort {
-		return config.BasePort, nil
+		return config.BasePort
 	}
-	return maxPort + 1, nil
+	return maxPort + 1
 }
 
 func IsPortAvailable(port int, projects map[string]Project) bool {
@@ -193,6 +309,11 @@ func IsPortAvailable(port int, projects map[string]Project) bool {
 		if project.Port == port {
 			return false
 		}
+		for _, used := range project.Ports {
+			if used == port {
+				return false
+			}
+		}
 	}
 	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
 	if err != nil {
@@ -204,22 +325,98 @@ func IsPortAvailable(port int, projects map[string]Project) bool {
 
 // UpdatePort updates the port for an existing project in the registry.
 func UpdatePort(path, name string, port int) (Project, error) {
-	projects, err := Load(path)
+	var project Project
+	err := Update(path, func(projects map[string]Project) error {
+		existing, ok := projects[name]
+		if !ok {
+			return fmt.Errorf("project '%s' not found", name)
+		}
+		existing.Port = port
+		projects[name] = existing
+		project = existing
+		return nil
+	})
 	if err != nil {
 		return Project{}, err
 	}
-	project, ok := projects[name]
-	if !ok {
-		return Project{}, fmt.Errorf("project '%s' not found", name)
+	return project, nil
+}
+
+// AssignProcessPorts gives every named process of a registered project its own
+// registry port, keeping ports already assigned and dropping ports for
+// processes that no longer exist.
+func AssignProcessPorts(path, name string, processes []string) (map[string]int, error) {
+	ports := map[string]int{}
+	err := Update(path, func(projects map[string]Project) error {
+		project, ok := projects[name]
+		if !ok {
+			return fmt.Errorf("project '%s' not found", name)
+		}
+		for _, process := range processes {
+			if port, ok := project.Ports[process]; ok {
+				ports[process] = port
+			}
+		}
+		project.Ports = ports
+		projects[name] = project
+		for _, process := range processes {
+			if _, ok := ports[process]; !ok {
+				ports[process] = nextPortIn(projects)
+			}
+		}
+		if len(ports) == 0 {
+			project.Ports = nil
+			projects[name] = project
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	project.Port = port
-	projects[name] = project
-	if err := Save(path, projects); err != nil {
-		return Project{}, err
+	return ports, nil
+}
+
+// SetRoutes records which process serves each path pattern of a registered
+// project. An empty map clears them. Unknown projects are ignored.
+func SetRoutes(path, name string, routes map[string]string) error {
+	err := Update(path, func(projects map[string]Project) error {
+		project, ok := projects[name]
+		if !ok {
+			return errNotRegistered
+		}
+		project.Routes = routes
+		if len(routes) == 0 {
+			project.Routes = nil
+		}
+		projects[name] = project
+		return nil
+	})
+	if errors.Is(err, errNotRegistered) {
+		return nil
 	}
-	return project, nil
+	return err
 }
 
+// MarkStarted records when a registered project was last started. Unknown
+// projects are ignored.
+func MarkStarted(path, name string, at time.Time) error {
+	err := Update(path, func(projects map[string]Project) error {
+		project, ok := projects[name]
+		if !ok {
+			return errNotRegistered
+		}
+		project.LastStarted = at.UTC().Format(time.RFC3339)
+		projects[name] = project
+		return nil
+	})
+	if errors.Is(err, errNotRegistered) {
+		return nil
+	}
+	return err
+}
+
+var errNotRegistered = errors.New("project not registered")
+
 func writeAtomically(path string, data []byte, perm os.FileMode) error {
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
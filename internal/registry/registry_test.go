@@ -1,6 +1,7 @@
 package registry
 
 import (
+	"errors"
 	"net"
 	"os"
 	"path/filepath"
@@ -66,18 +67,48 @@ func TestRegistryCRUD(t *testing.T) {
 	}
 }
 
-func TestRegistryInvalidJSONRecovery(t *testing.T) {
+func TestRegistryInvalidJSONIsCorrupt(t *testing.T) {
 	path := filepath.Join(t.TempDir(), "projects.json")
 	if err := os.WriteFile(path, []byte("{bad"), 0644); err != nil {
 		t.Fatalf("write: %v", err)
 	}
 
-	projects, err := Load(path)
+	if _, err := Load(path); !errors.Is(err, ErrCorruptRegistry) {
+		t.Fatalf("expected ErrCorruptRegistry, got %v", err)
+	}
+	if _, err := Register(path, "alpha", 3000, "/tmp/alpha", "hypertext"); !errors.Is(err, ErrCorruptRegistry) {
+		t.Fatalf("expected register to refuse corrupt registry, got %v", err)
+	}
+	data, err := os.ReadFile(path)
 	if err != nil {
-		t.Fatalf("load: %v", err)
+		t.Fatalf("read: %v", err)
 	}
-	if len(projects) != 0 {
-		t.Fatalf("expected empty projects on invalid json")
+	if string(data) != "{bad" {
+		t.Fatalf("expected corrupt registry to be left untouched, got %q", data)
+	}
+}
+
+func TestSaveKeepsBackupOfPreviousRegistry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "projects.json")
+	if _, err := Register(path, "alpha", 3000, "/tmp/alpha", "hypertext"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if _, err := os.Stat(BackupPath(path)); !os.IsNotExist(err) {
+		t.Fatalf("expected no backup for first save")
+	}
+	if _, err := Register(path, "beta", 3001, "/tmp/beta", "hypertext"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	data, err := os.ReadFile(BackupPath(path))
+	if err != nil {
+		t.Fatalf("read backup: %v", err)
+	}
+	backup, err := decode(data)
+	if err != nil {
+		t.Fatalf("decode backup: %v", err)
+	}
+	if _, ok := backup["alpha"]; !ok || len(backup) != 1 {
+		t.Fatalf("expected backup to hold previous registry, got %#v", backup)
 	}
 }
 
@@ -103,3 +134,79 @@ func TestNextPortSkipsUsed(t *testing.T) {
 		t.Fatalf("expected next port to be max+1")
 	}
 }
+
+func TestAssignProcessPortsKeepsAndReleasesPorts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "projects.json")
+	if _, err := Register(path, "alpha", 3000, "/tmp/alpha", "django"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	ports, err := AssignProcessPorts(path, "alpha", []string{"worker", "docs"})
+	if err != nil {
+		t.Fatalf("assign: %v", err)
+	}
+	if ports["worker"] == ports["docs"] || ports["worker"] == 3000 || ports["docs"] == 3000 {
+		t.Fatalf("expected distinct ports, got %#v", ports)
+	}
+
+	again, err := AssignProcessPorts(path, "alpha", []string{"worker"})
+	if err != nil {
+		t.Fatalf("assign: %v", err)
+	}
+	if again["worker"] != ports["worker"] || len(again) != 1 {
+		t.Fatalf("expected worker port kept and docs released, got %#v", again)
+	}
+
+	next, err := NextPort(path)
+	if err != nil {
+		t.Fatalf("next port: %v", err)
+	}
+	if next <= again["worker"] {
+		t.Fatalf("expected next port above process ports, got %d", next)
+	}
+}
+
+func TestAliases(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "projects.json")
+	for _, name := range []string{"alpha", "beta"} {
+		if _, err := Register(path, name, 3000, "/tmp/"+name, "hypertext"); err != nil {
+			t.Fatalf("register: %v", err)
+		}
+	}
+
+	project, err := AddAlias(path, "alpha", "*.Alpha.localhost.")
+	if err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if _, err := AddAlias(path, "alpha", "*.alpha.localhost"); err != nil {
+		t.Fatalf("add again: %v", err)
+	}
+	if project, _, _ = Get(path, "alpha"); len(project.Aliases) != 1 || project.Aliases[0] != "*.alpha.localhost" {
+		t.Fatalf("expected one normalized alias, got %v", project.Aliases)
+	}
+	if got := URLs("alpha", project.Aliases); len(got) != 2 || got[0] != "https://alpha.localhost" || got[1] != "https://*.alpha.localhost" {
+		t.Fatalf("unexpected urls %v", got)
+	}
+
+	if _, err := AddAlias(path, "gamma", "gamma.test"); err == nil {
+		t.Fatalf("expected unknown project to be refused")
+	}
+	for _, host := range []string{"*.alpha.localhost", "alpha.localhost"} {
+		if _, err := AddAlias(path, "beta", host); err == nil {
+			t.Fatalf("expected alpha's host %s to be refused for beta", host)
+		}
+	}
+	for _, host := range []string{"localhost", "*.localhost", "*.test", "my_app.test", "a.*.test", "-a.test"} {
+		if _, err := NormalizeHost(host); err == nil {
+			t.Fatalf("expected %q to be invalid", host)
+		}
+	}
+
+	project, removed, err := RemoveAlias(path, "alpha", "*.alpha.localhost")
+	if err != nil || !removed || project.Aliases != nil {
+		t.Fatalf("expected alias removed, got %v %v %v", project.Aliases, removed, err)
+	}
+	if _, removed, _ := RemoveAlias(path, "alpha", "alpha.test"); removed {
+		t.Fatalf("expected missing alias not to be removed")
+	}
+}
@@ -0,0 +1,123 @@
+package registry
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Repair sources reported by Repair.
+const (
+	RepairNone    = ""
+	RepairBackup  = "backup"
+	RepairMarkers = "markers"
+)
+
+// MaxScanResults caps how many markers ScanMarkers visits in one walk.
+const MaxScanResults = 100
+
+var scanSkipDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	".venv":        true,
+	"venv":         true,
+	"__pycache__":  true,
+	".config":      true,
+	"Library":      true,
+	".Trash":       true,
+}
+
+// ScanMarkers walks root looking for .justvibin markers and calls visit for
+// every marker that names a project and a port.
+func ScanMarkers(root string, visit func(projectDir string, marker Marker)) error {
+	count := 0
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if scanSkipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() != ".justvibin" {
+			return nil
+		}
+		if count >= MaxScanResults {
+			return filepath.SkipAll
+		}
+
+		projectDir := filepath.Dir(path)
+		marker, err := ReadMarker(projectDir)
+		if err != nil {
+			return nil
+		}
+		if marker.Name == "" || marker.Port == 0 {
+			return nil
+		}
+		visit(projectDir, marker)
+		count++
+		return nil
+	})
+	if err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+// Repair restores a corrupt registry from its backup, or rebuilds it from the
+// markers under scanRoot when the backup is missing or unreadable. The corrupt
+// file is kept next to the registry with a .corrupt suffix. A registry that
+// loads cleanly is left alone and RepairNone is returned.
+func Repair(path, scanRoot string) (string, map[string]Project, error) {
+	unlock, err := lockRegistry(path)
+	if err != nil {
+		return RepairNone, nil, err
+	}
+	defer unlock()
+
+	projects, err := Load(path)
+	if err == nil {
+		return RepairNone, projects, nil
+	}
+	if !errors.Is(err, ErrCorruptRegistry) {
+		return RepairNone, nil, err
+	}
+
+	source := RepairBackup
+	projects, err = loadBackup(path)
+	if err != nil {
+		source = RepairMarkers
+		projects = map[string]Project{}
+		now := time.Now().UTC().Format(time.RFC3339)
+		err = ScanMarkers(scanRoot, func(projectDir string, marker Marker) {
+			created := marker.Created
+			if created == "" {
+				created = now
+			}
+			projects[marker.Name] = Project{Port: marker.Port, Path: projectDir, Template: marker.Template, Created: created, Aliases: marker.Aliases}
+		})
+		if err != nil {
+			return RepairNone, nil, err
+		}
+	}
+
+	if err := os.Rename(path, path+".corrupt"); err != nil {
+		return RepairNone, nil, err
+	}
+	if err := Save(path, projects); err != nil {
+		return RepairNone, nil, err
+	}
+	return source, projects, nil
+}
+
+func loadBackup(path string) (map[string]Project, error) {
+	data, err := os.ReadFile(BackupPath(path))
+	if err != nil {
+		return nil, err
+	}
+	return decode(data)
+}
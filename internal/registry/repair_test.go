@@ -0,0 +1,85 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRepairRestoresFromBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "projects.json")
+	if _, err := Register(path, "alpha", 3000, "/tmp/alpha", "hypertext"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if _, err := Register(path, "beta", 3001, "/tmp/beta", "hypertext"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("{bad"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	source, projects, err := Repair(path, t.TempDir())
+	if err != nil {
+		t.Fatalf("repair: %v", err)
+	}
+	if source != RepairBackup {
+		t.Fatalf("expected backup source, got %q", source)
+	}
+	if _, ok := projects["alpha"]; !ok {
+		t.Fatalf("expected alpha restored, got %#v", projects)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(loaded) != 1 {
+		t.Fatalf("expected restored registry, got %#v", loaded)
+	}
+	if data, err := os.ReadFile(path + ".corrupt"); err != nil || string(data) != "{bad" {
+		t.Fatalf("expected corrupt file preserved")
+	}
+}
+
+func TestRepairRebuildsFromMarkers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "projects.json")
+	if err := os.WriteFile(path, []byte("{bad"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	scanRoot := t.TempDir()
+	projectDir := filepath.Join(scanRoot, "myapp")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if _, err := WriteMarker(projectDir, "myapp", "hypertext", 3005); err != nil {
+		t.Fatalf("marker: %v", err)
+	}
+
+	source, _, err := Repair(path, scanRoot)
+	if err != nil {
+		t.Fatalf("repair: %v", err)
+	}
+	if source != RepairMarkers {
+		t.Fatalf("expected markers source, got %q", source)
+	}
+	project, ok, err := Get(path, "myapp")
+	if err != nil || !ok {
+		t.Fatalf("expected myapp in rebuilt registry: %v", err)
+	}
+	if project.Port != 3005 || project.Path != projectDir {
+		t.Fatalf("unexpected project: %#v", project)
+	}
+}
+
+func TestRepairLeavesHealthyRegistry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "projects.json")
+	if _, err := Register(path, "alpha", 3000, "/tmp/alpha", "hypertext"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	source, projects, err := Repair(path, t.TempDir())
+	if err != nil {
+		t.Fatalf("repair: %v", err)
+	}
+	if source != RepairNone || len(projects) != 1 {
+		t.Fatalf("expected healthy registry untouched, got %q %#v", source, projects)
+	}
+}
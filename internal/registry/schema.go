@@ -0,0 +1,87 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SchemaVersion is the projects.json format written by Save. Version 1 is the
+// legacy bare map of project name to project with no envelope.
+const SchemaVersion = 2
+
+// MarkerVersion is the .justvibin format written by WriteMarker. Version 1 is
+// the legacy marker without a version field.
+const MarkerVersion = 2
+
+type document struct {
+	Version  int                `json:"version"`
+	Projects map[string]Project `json:"projects"`
+}
+
+// migration upgrades a raw document from one version to the next.
+type migration func(map[string]json.RawMessage) (map[string]json.RawMessage, error)
+
+// registryMigrations[n] upgrades a version n registry to version n+1.
+var registryMigrations = map[int]migration{
+	1: func(legacy map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+		projects, err := json.Marshal(legacy)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]json.RawMessage{
+			"version":  json.RawMessage("2"),
+			"projects": projects,
+		}, nil
+	},
+}
+
+// markerMigrations[n] upgrades a version n marker to version n+1.
+var markerMigrations = map[int]migration{
+	1: func(legacy map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+		legacy["version"] = json.RawMessage("2")
+		return legacy, nil
+	},
+}
+
+// registryVersion reports the schema version of a raw registry. A legacy bare
+// map has no numeric "version" key, since every value in it is a project
+// object.
+func registryVersion(raw map[string]json.RawMessage) int {
+	var version int
+	if err := json.Unmarshal(raw["version"], &version); err != nil {
+		return 1
+	}
+	return version
+}
+
+func markerVersion(raw map[string]json.RawMessage) (int, error) {
+	value, ok := raw["version"]
+	if !ok {
+		return 1, nil
+	}
+	var version int
+	if err := json.Unmarshal(value, &version); err != nil {
+		return 0, fmt.Errorf("invalid marker version: %s", value)
+	}
+	return version, nil
+}
+
+// migrate runs the migration chain on raw until it reaches target.
+func migrate(raw map[string]json.RawMessage, version, target int, chain map[int]migration) (map[string]json.RawMessage, error) {
+	if version > target {
+		return nil, fmt.Errorf("version %d is newer than supported version %d; upgrade justvibin", version, target)
+	}
+	for version < target {
+		step, ok := chain[version]
+		if !ok {
+			return nil, fmt.Errorf("no migration from version %d", version)
+		}
+		next, err := step(raw)
+		if err != nil {
+			return nil, fmt.Errorf("migrate from version %d: %w", version, err)
+		}
+		raw = next
+		version++
+	}
+	return raw, nil
+}
@@ -0,0 +1,115 @@
+package registry
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadMigratesLegacyBareMap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "projects.json")
+	legacy := `{"alpha":{"port":3000,"path":"/tmp/alpha","template":"hypertext","created":"2024-01-01T00:00:00Z"},"version":{"port":3001,"path":"/tmp/version","template":"static","created":""}}`
+	if err := os.WriteFile(path, []byte(legacy), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	projects, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if projects["alpha"].Port != 3000 || projects["version"].Port != 3001 {
+		t.Fatalf("unexpected projects: %#v", projects)
+	}
+
+	if _, err := Register(path, "beta", 3002, "/tmp/beta", "hypertext"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if doc.Version != SchemaVersion || len(doc.Projects) != 3 {
+		t.Fatalf("expected envelope at version %d with 3 projects, got %#v", SchemaVersion, doc)
+	}
+}
+
+func TestLoadRejectsNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "projects.json")
+	if err := os.WriteFile(path, []byte(`{"version":99,"projects":{}}`), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	_, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), "newer than supported") {
+		t.Fatalf("expected newer version error, got %v", err)
+	}
+}
+
+func TestRegisterKeepsProjectMetadata(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "projects.json")
+	if _, err := Register(path, "alpha", 3000, "/tmp/alpha", "hypertext"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	err := Update(path, func(projects map[string]Project) error {
+		project := projects["alpha"]
+		project.Aliases = []string{"a"}
+		projects["alpha"] = project
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	started := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := MarkStarted(path, "alpha", started); err != nil {
+		t.Fatalf("mark started: %v", err)
+	}
+	project, err := Register(path, "alpha", 3001, "/tmp/alpha", "hypertext")
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if len(project.Aliases) != 1 || project.LastStarted != "2025-01-02T03:04:05Z" {
+		t.Fatalf("expected metadata preserved, got %#v", project)
+	}
+}
+
+func TestReadMarkerMigratesLegacyMarker(t *testing.T) {
+	dir := t.TempDir()
+	legacy := `{"name":"myapp","template":"hypertext","port":3000,"created":"2024-01-01T00:00:00Z"}`
+	if err := os.WriteFile(filepath.Join(dir, ".justvibin"), []byte(legacy), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	marker, err := ReadMarker(dir)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if marker.Version != MarkerVersion || marker.Name != "myapp" || marker.Port != 3000 {
+		t.Fatalf("unexpected marker: %#v", marker)
+	}
+
+	if _, err := UpdateMarkerPort(dir, 3001); err != nil {
+		t.Fatalf("update port: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, ".justvibin"))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !strings.Contains(string(data), `"version": 2`) {
+		t.Fatalf("expected versioned marker on disk, got %s", data)
+	}
+}
+
+func TestReadMarkerRejectsNewerVersion(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".justvibin"), []byte(`{"version":99,"name":"myapp"}`), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := ReadMarker(dir); err == nil {
+		t.Fatalf("expected error for newer marker version")
+	}
+}
@@ -32,14 +32,10 @@ func MigrateSrvMarker(projectDir string) (Marker, bool, error) {
 		Port:     srv.Port,
 		Created:  time.Now().UTC().Format(time.RFC3339),
 	}
-	markerData, err := json.MarshalIndent(marker, "", "  ")
+	marker, err = writeMarker(projectDir, marker)
 	if err != nil {
 		return Marker{}, false, err
 	}
-	justvibinPath := filepath.Join(projectDir, ".justvibin")
-	if err := os.WriteFile(justvibinPath, markerData, 0644); err != nil {
-		return Marker{}, false, err
-	}
 	_ = os.Remove(srvPath)
 	return marker, true, nil
 }
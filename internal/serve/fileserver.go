@@ -0,0 +1,234 @@
+package serve
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// StaticOptions configures StaticHandler.
+type StaticOptions struct {
+	// Extensions are tried in order when a path has no matching file, so
+	// /about can serve about.html.
+	Extensions []string
+	// SPA serves the root index.html for unknown paths that do not look like
+	// files, for client-side routers.
+	SPA bool
+	// LiveReload reloads open pages when files under the root change.
+	// Exclude lists scaffold patterns whose changes are ignored.
+	LiveReload bool
+	Exclude    []string
+}
+
+const indexFile = "index.html"
+
+// fingerprinted matches asset names carrying a content hash, such as
+// app.3f9a1c2b.js or chunk-5D7Q2XKE.css, which are safe to cache forever.
+var fingerprinted = regexp.MustCompile(`[.-]([0-9a-zA-Z]{8,})\.[a-z0-9]+$`)
+
+func init() {
+	for ext, typ := range map[string]string{
+		".js":          "text/javascript; charset=utf-8",
+		".mjs":         "text/javascript; charset=utf-8",
+		".map":         "application/json",
+		".webmanifest": "application/manifest+json",
+		".ico":         "image/x-icon",
+		".md":          "text/markdown; charset=utf-8",
+		".txt":         "text/plain; charset=utf-8",
+	} {
+		_ = mime.AddExtensionType(ext, typ)
+	}
+}
+
+type staticHandler struct {
+	root   string
+	opts   StaticOptions
+	reload *LiveReload
+}
+
+// StaticHandler serves files under root with clean-URL extension fallback,
+// directory indexes and optional SPA fallback. Dotfiles other than
+// .well-known are never served. When reload is not nil, HTML pages get the
+// live reload client and reload's event stream is served at LiveReloadPath.
+func StaticHandler(root string, opts StaticOptions, reload *LiveReload) http.Handler {
+	exts := make([]string, 0, len(opts.Extensions))
+	for _, ext := range opts.Extensions {
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		exts = append(exts, ext)
+	}
+	opts.Extensions = exts
+	return staticHandler{root: root, opts: opts, reload: reload}
+}
+
+func (h staticHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	urlPath := path.Clean("/" + r.URL.Path)
+	if h.reload != nil && urlPath == LiveReloadPath {
+		h.reload.ServeHTTP(w, r)
+		return
+	}
+	if hidden(urlPath) {
+		http.NotFound(w, r)
+		return
+	}
+
+	name := filepath.Join(h.root, filepath.FromSlash(urlPath))
+	if info, err := os.Stat(name); err == nil {
+		if !info.IsDir() {
+			h.serveFile(w, r, name, info)
+			return
+		}
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			http.Redirect(w, r, urlPath+"/", http.StatusMovedPermanently)
+			return
+		}
+		index := filepath.Join(name, indexFile)
+		if info, err := os.Stat(index); err == nil && !info.IsDir() {
+			h.serveFile(w, r, index, info)
+			return
+		}
+	}
+
+	if !strings.HasSuffix(urlPath, "/") {
+		for _, ext := range h.opts.Extensions {
+			candidate := name + ext
+			if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+				h.serveFile(w, r, candidate, info)
+				return
+			}
+		}
+	}
+
+	if h.opts.SPA && path.Ext(urlPath) == "" {
+		index := filepath.Join(h.root, indexFile)
+		if info, err := os.Stat(index); err == nil && !info.IsDir() {
+			h.serveFile(w, r, index, info)
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+func (h staticHandler) serveFile(w http.ResponseWriter, r *http.Request, name string, info os.FileInfo) {
+	file, err := os.Open(name)
+	if err != nil {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	defer file.Close()
+
+	if typ := mime.TypeByExtension(filepath.Ext(name)); typ != "" {
+		w.Header().Set("Content-Type", typ)
+	}
+	w.Header().Set("Cache-Control", cacheControl(name))
+	etag := fmt.Sprintf(`W/"%x-%x"`, info.Size(), info.ModTime().UnixNano())
+	if h.reload != nil && strings.HasSuffix(name, ".html") {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			http.Error(w, "read failed", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("ETag", strings.TrimSuffix(etag, `"`)+`-lr"`)
+		http.ServeContent(w, r, name, info.ModTime(), bytes.NewReader(injectReloadScript(data)))
+		return
+	}
+	w.Header().Set("ETag", etag)
+	http.ServeContent(w, r, name, info.ModTime(), file)
+}
+
+// cacheControl lets browsers keep fingerprinted assets and makes them
+// revalidate everything else, so edits show up on the next reload.
+func cacheControl(name string) string {
+	match := fingerprinted.FindStringSubmatch(filepath.Base(name))
+	if strings.HasSuffix(name, ".html") || match == nil || !strings.ContainsAny(match[1], "0123456789") {
+		return "no-cache"
+	}
+	return "public, max-age=31536000, immutable"
+}
+
+func hidden(urlPath string) bool {
+	for _, part := range strings.Split(urlPath, "/") {
+		if strings.HasPrefix(part, ".") && part != ".well-known" {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeStatic serves handler on addr until ctx is cancelled, then shuts down
+// gracefully. Each request is logged to log.
+func ServeStatic(ctx context.Context, addr string, handler http.Handler, log io.Writer) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{
+		Handler:           logRequests(handler, log),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- server.Serve(listener) }()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdown); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, which
+// the live reload event stream needs for flushing.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func logRequests(next http.Handler, log io.Writer) http.Handler {
+	if log == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		fmt.Fprintf(log, "%s %s %d %s\n", r.Method, r.URL.RequestURI(), rec.status, time.Since(start).Round(time.Microsecond))
+	})
+}
@@ -0,0 +1,103 @@
+package serve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeSite(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"index.html":               "<h1>home</h1>",
+		"about.html":               "<h1>about</h1>",
+		"docs/index.html":          "<h1>docs</h1>",
+		"assets/app.3f9a1c2b.js":   "console.log(1)",
+		"assets/my-component.css":  "body{}",
+		".justvibin.pid":           "123",
+		".well-known/security.txt": "contact: me",
+	}
+	for name, body := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	return root
+}
+
+func get(t *testing.T, handler http.Handler, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+func TestStaticHandlerResolvesPaths(t *testing.T) {
+	handler := StaticHandler(writeSite(t), StaticOptions{Extensions: []string{"html"}}, nil)
+
+	cases := []struct {
+		target string
+		status int
+		body   string
+	}{
+		{"/", http.StatusOK, "home"},
+		{"/about", http.StatusOK, "about"},
+		{"/docs/", http.StatusOK, "docs"},
+		{"/docs", http.StatusMovedPermanently, ""},
+		{"/missing", http.StatusNotFound, ""},
+		{"/.justvibin.pid", http.StatusNotFound, ""},
+		{"/.well-known/security.txt", http.StatusOK, "contact"},
+		{"/../etc/passwd", http.StatusNotFound, ""},
+	}
+	for _, tc := range cases {
+		rec := get(t, handler, tc.target)
+		if rec.Code != tc.status || !strings.Contains(rec.Body.String(), tc.body) {
+			t.Errorf("%s: got %d %q, want %d containing %q", tc.target, rec.Code, rec.Body.String(), tc.status, tc.body)
+		}
+	}
+}
+
+func TestStaticHandlerSPAFallback(t *testing.T) {
+	handler := StaticHandler(writeSite(t), StaticOptions{SPA: true}, nil)
+
+	if rec := get(t, handler, "/dashboard/settings"); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "home") {
+		t.Fatalf("expected index.html for client route, got %d %q", rec.Code, rec.Body.String())
+	}
+	if rec := get(t, handler, "/assets/missing.js"); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for missing asset, got %d", rec.Code)
+	}
+}
+
+func TestStaticHandlerHeaders(t *testing.T) {
+	handler := StaticHandler(writeSite(t), StaticOptions{}, nil)
+
+	rec := get(t, handler, "/assets/app.3f9a1c2b.js")
+	if got := rec.Header().Get("Content-Type"); got != "text/javascript; charset=utf-8" {
+		t.Fatalf("unexpected content type %q", got)
+	}
+	if got := rec.Header().Get("Cache-Control"); !strings.Contains(got, "immutable") {
+		t.Fatalf("expected fingerprinted asset to be immutable, got %q", got)
+	}
+	if got := get(t, handler, "/assets/my-component.css").Header().Get("Cache-Control"); got != "no-cache" {
+		t.Fatalf("expected plain asset to revalidate, got %q", got)
+	}
+	if got := get(t, handler, "/").Header().Get("Cache-Control"); got != "no-cache" {
+		t.Fatalf("expected html to revalidate, got %q", got)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/assets/app.3f9a1c2b.js", nil)
+	req.Header.Set("If-None-Match", rec.Header().Get("ETag"))
+	notModified := httptest.NewRecorder()
+	handler.ServeHTTP(notModified, req)
+	if notModified.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 for matching ETag, got %d", notModified.Code)
+	}
+}
@@ -0,0 +1,166 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"time"
+)
+
+const (
+	DefaultMinBackoff = 500 * time.Millisecond
+	DefaultMaxBackoff = 30 * time.Second
+	// backoffResetAfter is how long a process must stay up before a later
+	// crash restarts it with the minimum backoff again.
+	backoffResetAfter = time.Minute
+)
+
+// Foreground runs a single server command attached to the terminal. Signals
+// received on Signals are forwarded to the server's process group; SIGINT and
+// SIGTERM also stop it. A server that exits with an error is restarted with
+// exponential backoff, and a clean exit ends Run. A value on Restart stops
+// the server gracefully and starts it again right away.
+type Foreground struct {
+	Runner      CommandRunner
+	Name        string
+	Args        []string
+	Signals     <-chan os.Signal
+	Restart     <-chan struct{}
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+	StopTimeout time.Duration
+	// OnCrash is called before each restart with the exit error and the
+	// delay until the next attempt.
+	OnCrash func(err error, delay time.Duration)
+}
+
+func (f Foreground) Run(ctx context.Context) error {
+	if f.Runner == nil {
+		return errors.New("runner is required")
+	}
+	minBackoff := f.MinBackoff
+	if minBackoff <= 0 {
+		minBackoff = DefaultMinBackoff
+	}
+	maxBackoff := f.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultMaxBackoff
+	}
+
+	backoff := minBackoff
+	for {
+		started := time.Now()
+		cmd, err := f.Runner.Start(f.Name, f.Args...)
+		if err != nil {
+			return err
+		}
+		stopped, restarted, err := f.wait(ctx, cmd.Process, cmd.Wait)
+		if stopped {
+			return nil
+		}
+		if restarted {
+			backoff = minBackoff
+			continue
+		}
+		if err == nil {
+			return nil
+		}
+
+		if time.Since(started) > backoffResetAfter {
+			backoff = minBackoff
+		}
+		if f.OnCrash != nil {
+			f.OnCrash(err, backoff)
+		}
+		if f.sleep(ctx, backoff) {
+			return nil
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// wait blocks until the process exits, forwarding signals to it. It reports
+// whether the exit was requested by a stop signal or context cancellation,
+// and whether it was requested on Restart.
+func (f Foreground) wait(ctx context.Context, process *os.Process, wait func() error) (bool, bool, error) {
+	done := make(chan error, 1)
+	go func() { done <- wait() }()
+
+	stopping, restarting := false, false
+	ctxDone := ctx.Done()
+	var kill <-chan time.Time
+	for {
+		select {
+		case err := <-done:
+			return stopping, restarting && !stopping, err
+		case sig := <-f.Signals:
+			signalGroup(process, sig)
+			if isStopSignal(sig) && !stopping {
+				stopping = true
+				kill = time.After(f.stopTimeout())
+			}
+		case <-ctxDone:
+			ctxDone = nil
+			if !stopping {
+				signalGroup(process, syscall.SIGTERM)
+				stopping = true
+				kill = time.After(f.stopTimeout())
+			}
+		case <-f.Restart:
+			if !stopping && !restarting {
+				signalGroup(process, syscall.SIGTERM)
+				restarting = true
+				kill = time.After(f.stopTimeout())
+			}
+		case <-kill:
+			signalGroup(process, syscall.SIGKILL)
+		}
+	}
+}
+
+// sleep waits out a restart delay and reports whether a stop was requested
+// in the meantime. A value on Restart cuts the delay short.
+func (f Foreground) sleep(ctx context.Context, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			return false
+		case <-f.Restart:
+			return false
+		case <-ctx.Done():
+			return true
+		case sig := <-f.Signals:
+			if isStopSignal(sig) {
+				return true
+			}
+		}
+	}
+}
+
+func (f Foreground) stopTimeout() time.Duration {
+	if f.StopTimeout > 0 {
+		return f.StopTimeout
+	}
+	return DefaultStopTimeout
+}
+
+func isStopSignal(sig os.Signal) bool {
+	return sig == os.Interrupt || sig == syscall.SIGTERM
+}
+
+// signalGroup sends sig to the process group led by process, falling back to
+// the process alone when it does not lead a group.
+func signalGroup(process *os.Process, sig os.Signal) {
+	if s, ok := sig.(syscall.Signal); ok {
+		if err := syscall.Kill(-process.Pid, s); err == nil {
+			return
+		}
+	}
+	_ = process.Signal(sig)
+}
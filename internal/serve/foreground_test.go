@@ -0,0 +1,128 @@
+package serve
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestForegroundRestartsCrashedServerWithBackoff(t *testing.T) {
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "count")
+	script := `n=$(cat count 2>/dev/null || echo 0); n=$((n+1)); echo $n > count; [ $n -ge 3 ] && exit 0; exit 1`
+
+	var delays []time.Duration
+	fg := Foreground{
+		Runner:     SystemRunner{Dir: dir, Setpgid: true},
+		Name:       "bash",
+		Args:       []string{"-c", script},
+		MinBackoff: 10 * time.Millisecond,
+		MaxBackoff: 15 * time.Millisecond,
+		OnCrash: func(err error, delay time.Duration) {
+			delays = append(delays, delay)
+		},
+	}
+	if err := fg.Run(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	data, err := os.ReadFile(counter)
+	if err != nil {
+		t.Fatalf("read counter: %v", err)
+	}
+	if strings.TrimSpace(string(data)) != "3" {
+		t.Fatalf("expected 3 runs, got %s", data)
+	}
+	if len(delays) != 2 || delays[0] != 10*time.Millisecond || delays[1] != 15*time.Millisecond {
+		t.Fatalf("unexpected backoff delays %v", delays)
+	}
+}
+
+func TestForegroundForwardsSignals(t *testing.T) {
+	dir := t.TempDir()
+	script := `trap 'echo hup >> signals' HUP; trap 'echo term >> signals; exit 0' TERM; while true; do sleep 0.05; done`
+	signals := make(chan os.Signal, 2)
+	fg := Foreground{
+		Runner:  SystemRunner{Dir: dir, Setpgid: true},
+		Name:    "bash",
+		Args:    []string{"-c", script},
+		Signals: signals,
+	}
+
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		signals <- syscall.SIGHUP
+		time.Sleep(300 * time.Millisecond)
+		signals <- syscall.SIGTERM
+	}()
+	done := make(chan error, 1)
+	go func() { done <- fg.Run(context.Background()) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("foreground did not stop on SIGTERM")
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "signals"))
+	if err != nil {
+		t.Fatalf("read signals: %v", err)
+	}
+	if got := strings.Fields(string(data)); len(got) != 2 || got[0] != "hup" || got[1] != "term" {
+		t.Fatalf("expected forwarded hup then term, got %q", data)
+	}
+}
+
+func TestForegroundRestartsOnRequest(t *testing.T) {
+	dir := t.TempDir()
+	script := `echo run >> runs; trap 'exit 0' TERM; while true; do sleep 0.05; done`
+	restart := make(chan struct{}, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	crashed := false
+	fg := Foreground{
+		Runner:  SystemRunner{Dir: dir, Setpgid: true},
+		Name:    "bash",
+		Args:    []string{"-c", script},
+		Restart: restart,
+		OnCrash: func(error, time.Duration) { crashed = true },
+	}
+	done := make(chan error, 1)
+	go func() { done <- fg.Run(ctx) }()
+
+	runs := func() int {
+		data, _ := os.ReadFile(filepath.Join(dir, "runs"))
+		return len(strings.Fields(string(data)))
+	}
+	waitFor := func(n int) {
+		deadline := time.Now().Add(5 * time.Second)
+		for runs() < n {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected %d runs, got %d", n, runs())
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+	waitFor(1)
+	time.Sleep(100 * time.Millisecond)
+	restart <- struct{}{}
+	waitFor(2)
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("foreground did not stop")
+	}
+	if crashed {
+		t.Fatalf("expected a requested restart not to count as a crash")
+	}
+}
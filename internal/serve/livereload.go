@@ -0,0 +1,126 @@
+package serve
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// LiveReloadPath is the server-sent events endpoint browsers listen on.
+const LiveReloadPath = "/__justvibin/livereload"
+
+var liveReloadScript = []byte(`<script>(function () {
+  var source = new EventSource("` + LiveReloadPath + `");
+  source.addEventListener("reload", function () { location.reload(); });
+})();</script>
+`)
+
+// LiveReload watches a directory tree and tells connected browsers to reload
+// when files change. Rapid saves are collapsed into one reload.
+type LiveReload struct {
+	Root     string
+	Exclude  []string
+	Debounce time.Duration
+
+	mu      sync.Mutex
+	clients map[chan struct{}]struct{}
+	done    chan struct{}
+}
+
+func NewLiveReload(root string, exclude []string) *LiveReload {
+	return &LiveReload{
+		Root:     root,
+		Exclude:  exclude,
+		Debounce: DefaultWatchDebounce,
+		clients:  map[chan struct{}]struct{}{},
+		done:     make(chan struct{}),
+	}
+}
+
+// watchRetry is how long Watch waits before restarting a failed watcher.
+var watchRetry = time.Second
+
+// Watch blocks until ctx is cancelled, broadcasting a reload after changes
+// under Root. Excluded paths and dotfiles are ignored. A watcher error, such
+// as an unreadable directory, is passed to report and the watcher restarts,
+// so connected browsers stay connected. Cancelling ctx disconnects every
+// browser so the HTTP server can shut down.
+func (l *LiveReload) Watch(ctx context.Context, report func(error)) {
+	defer close(l.done)
+	watcher := Watcher{Root: l.Root, Exclude: l.Exclude, Debounce: l.Debounce}
+	for {
+		err := watcher.Run(ctx, l.broadcast)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil && report != nil {
+			report(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(watchRetry):
+		}
+	}
+}
+
+func (l *LiveReload) broadcast() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for client := range l.clients {
+		select {
+		case client <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// ServeHTTP streams reload events to one browser.
+func (l *LiveReload) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher := http.NewResponseController(w)
+	client := make(chan struct{}, 1)
+	l.mu.Lock()
+	l.clients[client] = struct{}{}
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		delete(l.clients, client)
+		l.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	fmt.Fprint(w, ": connected\n\n")
+	if err := flusher.Flush(); err != nil {
+		return
+	}
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-l.done:
+			return
+		case <-client:
+			fmt.Fprint(w, "event: reload\ndata: {}\n\n")
+			if err := flusher.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// injectReloadScript adds the live reload client before </body>, or at the
+// end of documents without one.
+func injectReloadScript(html []byte) []byte {
+	i := bytes.LastIndex(bytes.ToLower(html), []byte("</body>"))
+	if i < 0 {
+		return append(html, liveReloadScript...)
+	}
+	out := make([]byte, 0, len(html)+len(liveReloadScript))
+	out = append(out, html[:i]...)
+	out = append(out, liveReloadScript...)
+	return append(out, html[i:]...)
+}
@@ -0,0 +1,179 @@
+package serve
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInjectReloadScript(t *testing.T) {
+	out := string(injectReloadScript([]byte("<html><BODY><p>hi</p></BODY></html>")))
+	if !strings.Contains(out, LiveReloadPath) || !strings.HasSuffix(out, "</script>\n</BODY></html>") {
+		t.Fatalf("expected script before </body>, got %q", out)
+	}
+	out = string(injectReloadScript([]byte("<p>fragment</p>")))
+	if !strings.HasPrefix(out, "<p>fragment</p><script>") {
+		t.Fatalf("expected script appended, got %q", out)
+	}
+}
+
+func TestLiveReloadNotifiesBrowsers(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "node_modules"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "index.html"), []byte("<body>v1</body>"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reload := NewLiveReload(root, []string{"node_modules"})
+	reload.Debounce = 20 * time.Millisecond
+	watching := make(chan struct{})
+	go func() {
+		reload.Watch(ctx, func(err error) { t.Errorf("watch: %v", err) })
+		close(watching)
+	}()
+
+	// Go through request logging as ServeStatic does; the event stream must
+	// still be flushed through the wrapped writer.
+	server := httptest.NewServer(logRequests(StaticHandler(root, StaticOptions{LiveReload: true}, reload), io.Discard))
+	defer server.Close()
+
+	page, err := http.Get(server.URL + "/")
+	if err != nil {
+		t.Fatalf("get page: %v", err)
+	}
+	body := make([]byte, 1024)
+	n, _ := page.Body.Read(body)
+	_ = page.Body.Close()
+	if !strings.Contains(string(body[:n]), "EventSource") {
+		t.Fatalf("expected live reload script in page, got %q", body[:n])
+	}
+
+	events, err := http.Get(server.URL + LiveReloadPath)
+	if err != nil {
+		t.Fatalf("get events: %v", err)
+	}
+	defer events.Body.Close()
+	lines := make(chan string, 16)
+	go func() {
+		scanner := bufio.NewScanner(events.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	if line := <-lines; line != ": connected" {
+		t.Fatalf("expected connected comment, got %q", line)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if err := os.WriteFile(filepath.Join(root, "node_modules", "dep.js"), []byte("x"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	select {
+	case line := <-lines:
+		if line != "" {
+			t.Fatalf("expected excluded change to be ignored, got %q", line)
+		}
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := os.WriteFile(filepath.Join(root, "index.html"), []byte("<body>v2</body>"), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	select {
+	case line := <-lines:
+		if line != "event: reload" {
+			t.Fatalf("expected reload event, got %q", line)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for reload event")
+	}
+
+	cancel()
+	<-watching
+}
+
+func TestLiveReloadSurvivesWatcherErrors(t *testing.T) {
+	previous := watchRetry
+	watchRetry = 20 * time.Millisecond
+	defer func() { watchRetry = previous }()
+
+	// Watching fails until the root exists.
+	root := filepath.Join(t.TempDir(), "site")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reload := NewLiveReload(root, nil)
+	reload.Debounce = 20 * time.Millisecond
+	failures := make(chan error, 64)
+	watching := make(chan struct{})
+	go func() {
+		reload.Watch(ctx, func(err error) {
+			select {
+			case failures <- err:
+			default:
+			}
+		})
+		close(watching)
+	}()
+
+	server := httptest.NewServer(reload)
+	defer server.Close()
+	events, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("get events: %v", err)
+	}
+	defer events.Body.Close()
+	lines := make(chan string, 16)
+	go func() {
+		scanner := bufio.NewScanner(events.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	if line := <-lines; line != ": connected" {
+		t.Fatalf("expected connected comment, got %q", line)
+	}
+	select {
+	case <-failures:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected the watcher error reported")
+	}
+
+	if err := os.MkdirAll(root, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if err := os.WriteFile(filepath.Join(root, "index.html"), []byte("<body>v1</body>"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	deadline := time.After(2 * time.Second)
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatalf("expected the browser to stay connected")
+			}
+			if line == "event: reload" {
+				cancel()
+				<-watching
+				return
+			}
+		case <-deadline:
+			t.Fatalf("timed out waiting for reload event")
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package serve
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrStalePID is returned by PIDRecord.Verify when the recorded PID is alive
+// but belongs to a different process than the one that was started.
+var ErrStalePID = errors.New("pid belongs to a different process")
+
+// PIDRecord is the content of a project's pid file. StartTime and Cmdline
+// identify the process so a PID recycled after the server exited (or after a
+// reboot) is not mistaken for it.
+type PIDRecord struct {
+	PID       int    `json:"pid"`
+	StartTime string `json:"start_time,omitempty"`
+	Cmdline   string `json:"cmdline,omitempty"`
+}
+
+// processIdentity returns the start time and command line of a live process.
+var processIdentity = func(pid int) (string, string, error) {
+	if _, err := os.Stat("/proc/self/stat"); err == nil {
+		return procfsIdentity(pid)
+	}
+	return psIdentity(pid)
+}
+
+// WritePIDFile records pid and its identity at path.
+func WritePIDFile(path string, pid int) error {
+	record := PIDRecord{PID: pid}
+	if start, cmdline, err := processIdentity(pid); err == nil {
+		record.StartTime = start
+		record.Cmdline = cmdline
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// ReadPIDFile reads a pid file. Files from older versions that hold only a
+// number are returned without identity; see VerifyPort.
+func ReadPIDFile(path string) (PIDRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return PIDRecord{}, err
+	}
+	data = bytes.TrimSpace(data)
+	if pid, err := strconv.Atoi(string(data)); err == nil {
+		return PIDRecord{PID: pid}, nil
+	}
+	var record PIDRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return PIDRecord{}, fmt.Errorf("invalid pid file: %w", err)
+	}
+	if record.PID <= 0 {
+		return PIDRecord{}, errors.New("invalid pid file: missing pid")
+	}
+	return record, nil
+}
+
+// Verify reports whether the recorded process is still the one running under
+// its PID. It returns ErrNotRunning when nothing runs under the PID and
+// ErrStalePID when something else does. The start time is authoritative; the
+// command line is compared only when no start time could be read, since
+// `bash -c` and some servers replace their command line after starting.
+func (r PIDRecord) Verify() error {
+	return r.VerifyPort(0)
+}
+
+// VerifyPort is Verify for a project served on port. A record without
+// identity, from a pid file older versions wrote, cannot be checked that
+// way; its live process is taken to be the server only while port is in
+// use, since the PID may have been reused after a reboot.
+func (r PIDRecord) VerifyPort(port int) error {
+	if r.StartTime == "" && r.Cmdline == "" {
+		if running, _ := pidRunning(r.PID); !running {
+			return ErrNotRunning
+		}
+		if port > 0 && portInUse(port) {
+			return nil
+		}
+		return ErrStalePID
+	}
+
+	start, cmdline, err := processIdentity(r.PID)
+	if err != nil {
+		if running, _ := pidRunning(r.PID); running {
+			return ErrStalePID
+		}
+		return ErrNotRunning
+	}
+	switch {
+	case r.StartTime != "" && start != "":
+		if r.StartTime != start {
+			return ErrStalePID
+		}
+	case r.Cmdline != "":
+		if r.Cmdline != cmdline {
+			return ErrStalePID
+		}
+	default:
+		return ErrStalePID
+	}
+	return nil
+}
+
+func procfsIdentity(pid int) (string, string, error) {
+	dir := filepath.Join("/proc", strconv.Itoa(pid))
+	stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return "", "", err
+	}
+	i := bytes.LastIndexByte(stat, ')')
+	if i < 0 {
+		return "", "", errors.New("malformed stat")
+	}
+	// Fields after the command name start at field 3 (state); starttime is
+	// field 22.
+	fields := strings.Fields(string(stat[i+1:]))
+	if len(fields) < 20 {
+		return "", "", errors.New("malformed stat")
+	}
+	if fields[0] == "Z" {
+		return "", "", ErrNotRunning
+	}
+	start := fields[19]
+	// Start times count clock ticks since boot, so qualify them with the
+	// boot ID to survive a reboot.
+	if bootID, err := os.ReadFile("/proc/sys/kernel/random/boot_id"); err == nil {
+		start = strings.TrimSpace(string(bootID)) + ":" + start
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, "cmdline"))
+	if err != nil {
+		return "", "", err
+	}
+	cmdline := strings.Join(strings.Split(strings.TrimRight(string(raw), "\x00"), "\x00"), " ")
+	return start, cmdline, nil
+}
+
+func psIdentity(pid int) (string, string, error) {
+	start, err := exec.Command("ps", "-o", "lstart=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return "", "", err
+	}
+	cmdline, err := exec.Command("ps", "-o", "command=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return "", "", err
+	}
+	return strings.TrimSpace(string(start)), strings.TrimSpace(string(cmdline)), nil
+}
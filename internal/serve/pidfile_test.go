@@ -0,0 +1,80 @@
+package serve
+
+import (
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+)
+
+func TestPIDFileVerifiesIdentity(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultPIDFile)
+	if err := WritePIDFile(path, os.Getpid()); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	record, err := ReadPIDFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if record.PID != os.Getpid() || record.StartTime == "" || record.Cmdline == "" {
+		t.Fatalf("expected identity in record, got %+v", record)
+	}
+	if err := record.Verify(); err != nil {
+		t.Fatalf("expected own process to verify, got %v", err)
+	}
+
+	record.StartTime = "recycled"
+	if err := record.Verify(); !errors.Is(err, ErrStalePID) {
+		t.Fatalf("expected stale pid for different start time, got %v", err)
+	}
+}
+
+func TestLegacyPIDFileTrustedOnlyWithPort(t *testing.T) {
+	writeLegacy := func(pid int) PIDRecord {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), DefaultPIDFile)
+		if err := os.WriteFile(path, []byte(strconv.Itoa(pid)), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		record, err := ReadPIDFile(path)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		return record
+	}
+	startSleep := func() *exec.Cmd {
+		t.Helper()
+		cmd := exec.Command("sleep", "5")
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		if err := cmd.Start(); err != nil {
+			t.Fatalf("start: %v", err)
+		}
+		t.Cleanup(func() {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+		})
+		return cmd
+	}
+
+	// A reused PID may lead its own process group, as shells and daemons do.
+	leader := writeLegacy(startSleep().Process.Pid)
+	if err := leader.Verify(); !errors.Is(err, ErrStalePID) {
+		t.Fatalf("expected a process group leader without the port to be stale, got %v", err)
+	}
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	if err := leader.VerifyPort(listener.Addr().(*net.TCPAddr).Port); err != nil {
+		t.Fatalf("expected a held project port to verify, got %v", err)
+	}
+
+	if err := writeLegacy(99999999).Verify(); !errors.Is(err, ErrNotRunning) {
+		t.Fatalf("expected dead pid to be not running, got %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package serve
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// procInfo is one row of the system process table.
+type procInfo struct {
+	PID    int
+	PPID   int
+	PGID   int
+	Zombie bool
+}
+
+// listProcesses reads the process table from /proc where available and from
+// ps otherwise (macOS).
+var listProcesses = func() ([]procInfo, error) {
+	if _, err := os.Stat("/proc/self/stat"); err == nil {
+		return procfsProcesses()
+	}
+	return psProcesses()
+}
+
+func procfsProcesses() ([]procInfo, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	var procs []procInfo
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join("/proc", entry.Name(), "stat"))
+		if err != nil {
+			continue
+		}
+		// The command name is parenthesised and may contain spaces, so the
+		// fixed fields start after the last ')'.
+		i := bytes.LastIndexByte(data, ')')
+		if i < 0 {
+			continue
+		}
+		fields := strings.Fields(string(data[i+1:]))
+		if len(fields) < 3 {
+			continue
+		}
+		ppid, _ := strconv.Atoi(fields[1])
+		pgid, _ := strconv.Atoi(fields[2])
+		procs = append(procs, procInfo{PID: pid, PPID: ppid, PGID: pgid, Zombie: fields[0] == "Z"})
+	}
+	return procs, nil
+}
+
+func psProcesses() ([]procInfo, error) {
+	out, err := exec.Command("ps", "-A", "-o", "pid=", "-o", "ppid=", "-o", "pgid=", "-o", "stat=").Output()
+	if err != nil {
+		return nil, err
+	}
+	var procs []procInfo
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		ppid, _ := strconv.Atoi(fields[1])
+		pgid, _ := strconv.Atoi(fields[2])
+		procs = append(procs, procInfo{PID: pid, PPID: ppid, PGID: pgid, Zombie: strings.HasPrefix(fields[3], "Z")})
+	}
+	return procs, scanner.Err()
+}
+
+// processTree returns the live processes that belong to pid: pid itself,
+// the members of its process group and all of their descendants, sorted.
+// Group members are found even after the leader has exited.
+func processTree(pid int) ([]int, error) {
+	procs, err := listProcesses()
+	if err != nil {
+		return nil, err
+	}
+	children := map[int][]int{}
+	members := map[int]bool{}
+	for _, p := range procs {
+		if p.Zombie {
+			continue
+		}
+		children[p.PPID] = append(children[p.PPID], p.PID)
+		if p.PID == pid || p.PGID == pid {
+			members[p.PID] = true
+		}
+	}
+
+	queue := make([]int, 0, len(members))
+	for member := range members {
+		queue = append(queue, member)
+	}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		for _, child := range children[next] {
+			if !members[child] {
+				members[child] = true
+				queue = append(queue, child)
+			}
+		}
+	}
+
+	tree := make([]int, 0, len(members))
+	for member := range members {
+		tree = append(tree, member)
+	}
+	sort.Ints(tree)
+	return tree, nil
+}
+
+// alive filters pids down to those still running (zombies count as exited).
+func alive(pids []int) ([]int, error) {
+	procs, err := listProcesses()
+	if err != nil {
+		return nil, err
+	}
+	running := map[int]bool{}
+	for _, p := range procs {
+		if !p.Zombie {
+			running[p.PID] = true
+		}
+	}
+	var out []int
+	for _, pid := range pids {
+		if running[pid] {
+			out = append(out, pid)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,117 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultReadyTimeout is how long start waits for a new server to accept
+// connections before giving up on it.
+const DefaultReadyTimeout = 30 * time.Second
+
+// ErrExited is returned by WaitReady when the server process dies before it
+// becomes ready.
+var ErrExited = errors.New("server exited during startup")
+
+var readyPollInterval = 100 * time.Millisecond
+
+// startupGrace is how long a server without a port to probe must stay up to
+// count as started.
+var startupGrace = time.Second
+
+// Probe describes how to tell that a server is ready: a TCP connection to
+// Port, or, when HealthPath is set, an HTTP GET answered with a status below
+// 400. A zero Port means nothing listens that start can probe, so the server
+// only has to survive startupGrace.
+type Probe struct {
+	Port       int
+	HealthPath string
+	Timeout    time.Duration
+}
+
+// WaitReady polls probe until it passes, pid stops running, ctx is cancelled
+// or the probe timeout elapses.
+func WaitReady(ctx context.Context, pid int, probe Probe) error {
+	timeout := probe.Timeout
+	if timeout <= 0 {
+		timeout = DefaultReadyTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if probe.Port == 0 {
+		return waitAlive(ctx, pid, startupGrace)
+	}
+
+	var lastErr error
+	ticker := time.NewTicker(readyPollInterval)
+	defer ticker.Stop()
+	for {
+		if lastErr = probe.check(ctx); lastErr == nil {
+			return nil
+		}
+		if running, err := alive([]int{pid}); err == nil && len(running) == 0 {
+			return ErrExited
+		}
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("server not ready after %s: %w", timeout, lastErr)
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitAlive returns ErrExited if pid stops running within grace.
+func waitAlive(ctx context.Context, pid int, grace time.Duration) error {
+	deadline := time.NewTimer(grace)
+	defer deadline.Stop()
+	ticker := time.NewTicker(readyPollInterval)
+	defer ticker.Stop()
+	for {
+		if running, err := alive([]int{pid}); err == nil && len(running) == 0 {
+			return ErrExited
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline.C:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p Probe) check(ctx context.Context) error {
+	addr := net.JoinHostPort("localhost", strconv.Itoa(p.Port))
+	if p.HealthPath == "" {
+		dialer := net.Dialer{Timeout: time.Second}
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+p.HealthPath, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("GET %s returned %s", p.HealthPath, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitReadyChecksHealthPath(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" || hits.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	port := server.Listener.Addr().(*net.TCPAddr).Port
+
+	probe := Probe{Port: port, HealthPath: "/healthz", Timeout: 5 * time.Second}
+	if err := WaitReady(context.Background(), os.Getpid(), probe); err != nil {
+		t.Fatalf("expected ready, got %v", err)
+	}
+	if hits.Load() < 3 {
+		t.Fatalf("expected probe to retry until healthy, got %d hits", hits.Load())
+	}
+}
+
+func TestWaitReadyTimesOut(t *testing.T) {
+	probe := Probe{Port: freePort(t), Timeout: 300 * time.Millisecond}
+	err := WaitReady(context.Background(), os.Getpid(), probe)
+	if err == nil || !strings.Contains(err.Error(), "not ready after 300ms") {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	if errors.Is(err, ErrExited) {
+		t.Fatalf("expected timeout, not exit")
+	}
+}
+
+func TestWaitReadyWithoutPortWaitsForGrace(t *testing.T) {
+	defer func(grace time.Duration) { startupGrace = grace }(startupGrace)
+	startupGrace = 200 * time.Millisecond
+
+	if err := WaitReady(context.Background(), os.Getpid(), Probe{Timeout: time.Second}); err != nil {
+		t.Fatalf("expected a live process to count as started, got %v", err)
+	}
+
+	cmd := exec.Command("sh", "-c", "exit 1")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	_ = cmd.Wait()
+	if err := WaitReady(context.Background(), cmd.Process.Pid, Probe{Timeout: time.Second}); !errors.Is(err, ErrExited) {
+		t.Fatalf("expected ErrExited, got %v", err)
+	}
+}
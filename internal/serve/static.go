@@ -2,11 +2,11 @@ package serve
 
 import (
 	"context"
-	"errors"
-	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"syscall"
 	"time"
 )
@@ -15,10 +15,33 @@ type CommandRunner interface {
 	Start(name string, args ...string) (*exec.Cmd, error)
 }
 
-type SystemRunner struct{}
+// SystemRunner starts real processes. The zero value runs the command in the
+// current directory with the current environment and discards its output.
+type SystemRunner struct {
+	Dir    string
+	Env    []string
+	Stdout io.Writer
+	Stderr io.Writer
+	// Setpgid puts the child in its own process group so signals can be sent
+	// to everything it spawns.
+	Setpgid bool
+	// Setsid starts the child in a new session, detached from the terminal.
+	Setsid bool
+}
 
-func (SystemRunner) Start(name string, args ...string) (*exec.Cmd, error) {
+func (r SystemRunner) Start(name string, args ...string) (*exec.Cmd, error) {
 	cmd := exec.Command(name, args...)
+	cmd.Dir = r.Dir
+	if len(r.Env) > 0 {
+		cmd.Env = append(os.Environ(), r.Env...)
+	}
+	cmd.Stdout = r.Stdout
+	cmd.Stderr = r.Stderr
+	if r.Setsid {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	} else if r.Setpgid {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	}
 	if err := cmd.Start(); err != nil {
 		return nil, err
 	}
@@ -27,39 +50,64 @@ func (SystemRunner) Start(name string, args ...string) (*exec.Cmd, error) {
 
 const DefaultPIDFile = ".justvibin.pid"
 
-func StartStaticServer(ctx context.Context, runner CommandRunner, port int, root string) (int, error) {
+// executable locates the justvibin binary, which runs the static server as
+// its hidden __serve-static command.
+var executable = os.Executable
+
+// StaticServerArgs returns the `justvibin __serve-static` arguments that serve
+// root on port.
+func StaticServerArgs(port int, root string, opts StaticOptions) []string {
+	args := []string{"__serve-static", "--port", strconv.Itoa(port), "--root", root}
+	for _, ext := range opts.Extensions {
+		args = append(args, "--ext", ext)
+	}
+	if opts.SPA {
+		args = append(args, "--spa")
+	}
+	if opts.LiveReload {
+		args = append(args, "--live-reload")
+		for _, pattern := range opts.Exclude {
+			args = append(args, "--exclude", pattern)
+		}
+	}
+	return args
+}
+
+// StartStaticServer serves root on port in the background and waits for it to
+// pass probe. The pid file is written to dir, the project directory.
+func StartStaticServer(ctx context.Context, runner CommandRunner, dir string, port int, root string, opts StaticOptions, probe Probe) (int, error) {
 	if runner == nil {
-		runner = SystemRunner{}
+		runner = SystemRunner{Setpgid: true}
 	}
 	if root == "" {
 		root = "."
 	}
+	if probe.Port == 0 {
+		probe.Port = port
+	}
 
-	cmd, err := runner.Start("caddy", "file-server", "--listen", fmt.Sprintf(":%d", port), "--root", root)
+	exe, err := executable()
 	if err != nil {
 		return 0, err
 	}
-	pid := cmd.Process.Pid
-	pidFile := filepath.Join(root, DefaultPIDFile)
-	if err := os.WriteFile(pidFile, []byte(fmt.Sprintf("%d", pid)), 0644); err != nil {
-		_ = cmd.Process.Kill()
+	cmd, err := runner.Start(exe, StaticServerArgs(port, root, opts)...)
+	if err != nil {
 		return 0, err
 	}
-
-	timer := time.NewTimer(500 * time.Millisecond)
-	defer timer.Stop()
-
-	select {
-	case <-ctx.Done():
+	pid := cmd.Process.Pid
+	if dir == "" {
+		dir = root
+	}
+	pidFile := filepath.Join(dir, DefaultPIDFile)
+	if err := WritePIDFile(pidFile, pid); err != nil {
 		_ = cmd.Process.Kill()
-		_ = os.Remove(pidFile)
-		return 0, ctx.Err()
-	case <-timer.C:
+		return 0, err
 	}
 
-	if err := cmd.Process.Signal(syscall.Signal(0)); err != nil {
+	if err := WaitReady(ctx, pid, probe); err != nil {
+		_, _ = Stop(pid, time.Second, nil)
 		_ = os.Remove(pidFile)
-		return 0, errors.New("failed to start server")
+		return 0, err
 	}
 	return pid, nil
 }
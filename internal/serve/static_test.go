@@ -3,10 +3,10 @@ package serve
 import (
 	"context"
 	"errors"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"syscall"
 	"testing"
 	"time"
 )
@@ -37,7 +37,14 @@ func TestStartStaticServerWritesPID(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
 
-	pid, err := StartStaticServer(ctx, fakeRunner{cmd: cmd}, 8080, root)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	pid, err := StartStaticServer(ctx, fakeRunner{cmd: cmd}, root, port, root, StaticOptions{}, Probe{})
 	if err != nil {
 		t.Fatalf("start static server: %v", err)
 	}
@@ -53,7 +60,7 @@ func TestStartStaticServerWritesPID(t *testing.T) {
 
 func TestStartStaticServerRunnerError(t *testing.T) {
 	ctx := context.Background()
-	_, err := StartStaticServer(ctx, fakeRunner{err: errors.New("boom")}, 8080, t.TempDir())
+	_, err := StartStaticServer(ctx, fakeRunner{err: errors.New("boom")}, "", 8080, t.TempDir(), StaticOptions{}, Probe{})
 	if err == nil {
 		t.Fatalf("expected error")
 	}
@@ -73,7 +80,7 @@ func TestStartStaticServerContextCancel(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
 
-	_, err := StartStaticServer(ctx, fakeRunner{cmd: cmd}, 8080, root)
+	_, err := StartStaticServer(ctx, fakeRunner{cmd: cmd}, root, freePort(t), root, StaticOptions{}, Probe{})
 	if err == nil {
 		t.Fatalf("expected error")
 	}
@@ -95,11 +102,19 @@ func TestStartStaticServerDetectsExitedProcess(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
 
-	_, err := StartStaticServer(ctx, fakeRunner{cmd: cmd}, 8080, root)
-	if err == nil {
-		t.Fatalf("expected error")
+	_, err := StartStaticServer(ctx, fakeRunner{cmd: cmd}, root, freePort(t), root, StaticOptions{}, Probe{})
+	if !errors.Is(err, ErrExited) {
+		t.Fatalf("expected ErrExited, got %v", err)
 	}
-	if !errors.Is(err, syscall.ESRCH) && err.Error() == "failed to start server" {
-		return
+}
+
+func freePort(t *testing.T) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
 	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	_ = listener.Close()
+	return port
 }
@@ -6,7 +6,6 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
-	"strings"
 	"syscall"
 )
 
@@ -35,23 +34,19 @@ func (p portLookupFunc) ProjectPort(name string) (int, error) {
 }
 
 func IsProjectRunning(projectDir, name string, lookup PortLookup) (bool, error) {
-	pidFile := filepath.Join(projectDir, DefaultPIDFile)
-	if pid, err := readPID(pidFile); err == nil {
-		if running, _ := pidRunning(pid); running {
-			return true, nil
+	var port int
+	var err error
+	if lookup != nil {
+		if name == "" {
+			port, err = lookup.CurrentPort(projectDir)
+		} else {
+			port, err = lookup.ProjectPort(name)
 		}
 	}
 
-	if lookup == nil {
-		return false, nil
-	}
-
-	var port int
-	var err error
-	if name == "" {
-		port, err = lookup.CurrentPort(projectDir)
-	} else {
-		port, err = lookup.ProjectPort(name)
+	pidFile := filepath.Join(projectDir, DefaultPIDFile)
+	if record, readErr := ReadPIDFile(pidFile); readErr == nil && record.VerifyPort(port) == nil {
+		return true, nil
 	}
 	if err != nil || port == 0 {
 		return false, err
@@ -59,18 +54,6 @@ func IsProjectRunning(projectDir, name string, lookup PortLookup) (bool, error)
 	return portInUse(port), nil
 }
 
-func readPID(path string) (int, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return 0, err
-	}
-	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
-	if err != nil {
-		return 0, err
-	}
-	return pid, nil
-}
-
 func pidRunning(pid int) (bool, error) {
 	process, err := os.FindProcess(pid)
 	if err != nil {
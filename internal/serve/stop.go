@@ -0,0 +1,127 @@
+package serve
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"time"
+)
+
+// ErrNotRunning is returned by Stop when no process from the tree is alive.
+var ErrNotRunning = errors.New("process not running")
+
+var (
+	stopPollInterval = 100 * time.Millisecond
+	killWait         = 2 * time.Second
+)
+
+// StopResult lists the processes a Stop call ended.
+type StopResult struct {
+	// Stopped exited on their own after SIGTERM.
+	Stopped []int
+	// Killed were still running at the timeout and received SIGKILL.
+	Killed []int
+	// PortBusy is set when released still reported false at the end.
+	PortBusy bool
+}
+
+// Stop sends SIGTERM to pid's process group and every process descended from
+// it, then waits up to timeout for all of them to exit and for released (when
+// not nil) to report that the server's port is free. Whatever is still
+// running after that receives SIGKILL.
+func Stop(pid int, timeout time.Duration, released func() bool) (StopResult, error) {
+	tree, err := processTree(pid)
+	if err != nil {
+		return StopResult{}, err
+	}
+	if len(tree) == 0 {
+		return StopResult{}, ErrNotRunning
+	}
+	signalTree(pid, tree, syscall.SIGTERM)
+
+	deadline := time.Now().Add(timeout)
+	for {
+		remaining, err := alive(tree)
+		if err != nil {
+			return StopResult{}, err
+		}
+		if len(remaining) == 0 && (released == nil || released()) {
+			return StopResult{Stopped: tree}, nil
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(stopPollInterval)
+	}
+
+	// Rescan so processes spawned during shutdown (reloaders, workers) are
+	// not left behind.
+	remaining, err := alive(tree)
+	if err != nil {
+		return StopResult{}, err
+	}
+	if current, err := processTree(pid); err == nil {
+		remaining = union(remaining, current)
+	}
+	signalTree(pid, remaining, syscall.SIGKILL)
+
+	result := StopResult{Killed: remaining}
+	killed := map[int]bool{}
+	for _, p := range remaining {
+		killed[p] = true
+	}
+	for _, p := range tree {
+		if !killed[p] {
+			result.Stopped = append(result.Stopped, p)
+		}
+	}
+
+	deadline = time.Now().Add(killWait)
+	for {
+		left, err := alive(remaining)
+		if err != nil {
+			return result, err
+		}
+		if len(left) == 0 && (released == nil || released()) {
+			return result, nil
+		}
+		if time.Now().After(deadline) {
+			result.PortBusy = released != nil && !released()
+			return result, nil
+		}
+		time.Sleep(stopPollInterval)
+	}
+}
+
+// signalTree signals pid's process group and any process in pids that has
+// moved to a different group. The caller's own process and group are never
+// signalled.
+func signalTree(pid int, pids []int, sig syscall.Signal) {
+	self := os.Getpid()
+	if pid != syscall.Getpgrp() {
+		_ = syscall.Kill(-pid, sig)
+	}
+	for _, p := range pids {
+		if p == self {
+			continue
+		}
+		if pgid, err := syscall.Getpgid(p); err == nil && pgid == pid && pid != syscall.Getpgrp() {
+			continue
+		}
+		_ = syscall.Kill(p, sig)
+	}
+}
+
+func union(a, b []int) []int {
+	seen := map[int]bool{}
+	var out []int
+	for _, list := range [][]int{a, b} {
+		for _, p := range list {
+			if !seen[p] {
+				seen[p] = true
+				out = append(out, p)
+			}
+		}
+	}
+	return out
+}
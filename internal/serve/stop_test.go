@@ -0,0 +1,82 @@
+package serve
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func startGroup(t *testing.T, script string) int {
+	t.Helper()
+	cmd, err := SystemRunner{Setpgid: true}.Start("bash", "-c", script)
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	go func() { _ = cmd.Wait() }()
+	return cmd.Process.Pid
+}
+
+func waitForFile(t *testing.T, path string) string {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		data, err := os.ReadFile(path)
+		if err == nil && strings.HasSuffix(string(data), "\n") {
+			return strings.TrimSpace(string(data))
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", path)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStopTerminatesProcessGroup(t *testing.T) {
+	ready := filepath.Join(t.TempDir(), "ready")
+	pid := startGroup(t, "sleep 30 & echo $! > "+ready+"; wait")
+	child, _ := strconv.Atoi(waitForFile(t, ready))
+
+	result, err := Stop(pid, 2*time.Second, nil)
+	if err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	if len(result.Killed) != 0 {
+		t.Fatalf("expected clean exit, killed %v", result.Killed)
+	}
+	if remaining, _ := alive([]int{child}); len(remaining) != 0 {
+		t.Fatalf("expected grandchild %d to exit", child)
+	}
+}
+
+func TestStopEscalatesToKill(t *testing.T) {
+	ready := filepath.Join(t.TempDir(), "ready")
+	pid := startGroup(t, `bash -c 'trap "" TERM; echo $$ > `+ready+`; while true; do sleep 0.1; done' & wait`)
+	child, _ := strconv.Atoi(waitForFile(t, ready))
+
+	result, err := Stop(pid, 300*time.Millisecond, nil)
+	if err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	found := false
+	for _, killed := range result.Killed {
+		if killed == child {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected %d in killed %v", child, result.Killed)
+	}
+	if remaining, _ := alive([]int{child}); len(remaining) != 0 {
+		t.Fatalf("expected %d to be gone", child)
+	}
+}
+
+func TestStopNotRunning(t *testing.T) {
+	if _, err := Stop(99999999, time.Second, nil); !errors.Is(err, ErrNotRunning) {
+		t.Fatalf("expected ErrNotRunning, got %v", err)
+	}
+}
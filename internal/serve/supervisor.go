@@ -0,0 +1,204 @@
+package serve
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// DefaultStopTimeout is how long a Supervisor waits after SIGTERM before it
+// kills a process group.
+const DefaultStopTimeout = 10 * time.Second
+
+var prefixColors = []string{"6", "2", "3", "5", "4", "1"}
+
+// Process is a shell command run under a Supervisor.
+type Process struct {
+	Name    string
+	Command string
+	Dir     string
+	Env     []string
+}
+
+// Supervisor runs a set of processes like a Procfile runner: output from each
+// is prefixed with its name, and when one exits or the context is cancelled
+// every process group is torn down.
+type Supervisor struct {
+	Processes []Process
+	Output    io.Writer
+	// Log, when set, also receives every prefixed line without colors.
+	Log         io.Writer
+	Styled      bool
+	StopTimeout time.Duration
+}
+
+type supervised struct {
+	process Process
+	cmd     *exec.Cmd
+	out     *prefixWriter
+	done    chan struct{}
+	err     error
+}
+
+func (s Supervisor) Run(ctx context.Context) error {
+	if len(s.Processes) == 0 {
+		return errors.New("no processes to supervise")
+	}
+	output := s.Output
+	if output == nil {
+		output = os.Stdout
+	}
+
+	width := 0
+	for _, process := range s.Processes {
+		if len(process.Name) > width {
+			width = len(process.Name)
+		}
+	}
+
+	var mu sync.Mutex
+	exited := make(chan *supervised, len(s.Processes))
+	running := make([]*supervised, 0, len(s.Processes))
+	for i, process := range s.Processes {
+		plain := fmt.Sprintf("%-*s | ", width, process.Name)
+		prefix := plain
+		if s.Styled {
+			color := prefixColors[i%len(prefixColors)]
+			prefix = lipgloss.NewStyle().Foreground(lipgloss.Color(color)).Render(plain)
+		}
+		out := &prefixWriter{mu: &mu, out: output, prefix: prefix, log: s.Log, plain: plain}
+
+		cmd := exec.Command("bash", "-c", process.Command)
+		cmd.Dir = process.Dir
+		cmd.Env = append(os.Environ(), process.Env...)
+		cmd.Stdout = out
+		cmd.Stderr = out
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		cmd.WaitDelay = s.stopTimeout()
+		if err := cmd.Start(); err != nil {
+			s.stop(running)
+			return fmt.Errorf("start %s: %w", process.Name, err)
+		}
+
+		p := &supervised{process: process, cmd: cmd, out: out, done: make(chan struct{})}
+		running = append(running, p)
+		go func() {
+			p.err = cmd.Wait()
+			out.Flush()
+			close(p.done)
+			exited <- p
+		}()
+	}
+
+	var result error
+	select {
+	case <-ctx.Done():
+	case p := <-exited:
+		if p.err != nil {
+			fmt.Fprintf(p.out, "exited: %v\n", p.err)
+			result = fmt.Errorf("process %s exited: %w", p.process.Name, p.err)
+		} else {
+			fmt.Fprintln(p.out, "exited")
+		}
+	}
+	s.stop(running)
+	return result
+}
+
+// stop sends SIGTERM to every process group that is still running and
+// escalates to SIGKILL after the stop timeout.
+func (s Supervisor) stop(processes []*supervised) {
+	for _, p := range processes {
+		if !p.exited() {
+			signalGroup(p.cmd.Process, syscall.SIGTERM)
+		}
+	}
+	deadline := time.NewTimer(s.stopTimeout())
+	defer deadline.Stop()
+wait:
+	for _, p := range processes {
+		select {
+		case <-p.done:
+		case <-deadline.C:
+			break wait
+		}
+	}
+	for _, p := range processes {
+		if !p.exited() {
+			signalGroup(p.cmd.Process, syscall.SIGKILL)
+			<-p.done
+		}
+	}
+}
+
+func (s Supervisor) stopTimeout() time.Duration {
+	if s.StopTimeout > 0 {
+		return s.StopTimeout
+	}
+	return DefaultStopTimeout
+}
+
+func (p *supervised) exited() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// prefixWriter writes complete lines to out with prefix prepended, and to log
+// with the uncolored prefix. Writers sharing a mutex never interleave within
+// a line.
+type prefixWriter struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	prefix string
+	log    io.Writer
+	plain  string
+	buf    []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if err := w.writeLine(w.buf[:i]); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush writes any trailing partial line.
+func (w *prefixWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		_ = w.writeLine(w.buf)
+		w.buf = nil
+	}
+}
+
+func (w *prefixWriter) writeLine(line []byte) error {
+	if w.log != nil {
+		fmt.Fprintf(w.log, "%s%s\n", w.plain, line)
+	}
+	_, err := fmt.Fprintf(w.out, "%s%s\n", w.prefix, line)
+	return err
+}
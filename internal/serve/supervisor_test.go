@@ -0,0 +1,81 @@
+package serve
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type syncBuffer struct {
+	mu sync.Mutex
+	sb strings.Builder
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sb.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sb.String()
+}
+
+func TestSupervisorPrefixesOutputAndStopsGroupOnExit(t *testing.T) {
+	out := &syncBuffer{}
+	sup := Supervisor{
+		Processes: []Process{
+			{Name: "web", Command: "echo listening on $PORT; sleep 30", Env: []string{"PORT=4000"}},
+			{Name: "worker", Command: "sleep 0.2; echo done; exit 3"},
+		},
+		Output:      out,
+		StopTimeout: 2 * time.Second,
+	}
+
+	start := time.Now()
+	err := sup.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "worker") {
+		t.Fatalf("expected worker exit error, got %v", err)
+	}
+	if time.Since(start) > 10*time.Second {
+		t.Fatalf("expected web to be stopped when worker exited")
+	}
+	text := out.String()
+	if !strings.Contains(text, "web    | listening on 4000") {
+		t.Fatalf("expected prefixed web output, got %q", text)
+	}
+	if !strings.Contains(text, "worker | done") {
+		t.Fatalf("expected prefixed worker output, got %q", text)
+	}
+}
+
+func TestSupervisorCancelKillsProcessGroup(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "child-alive")
+	sup := Supervisor{
+		Processes: []Process{
+			{Name: "tree", Dir: dir, Command: "(sleep 1; touch " + marker + ") & sleep 30"},
+		},
+		Output:      &syncBuffer{},
+		StopTimeout: time.Second,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		cancel()
+	}()
+	if err := sup.Run(ctx); err != nil {
+		t.Fatalf("expected clean shutdown, got %v", err)
+	}
+	time.Sleep(1500 * time.Millisecond)
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatalf("expected background child to be killed with the group")
+	}
+}
@@ -0,0 +1,115 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/alexcabrera/justvibin/internal/fsutil"
+	"github.com/fsnotify/fsnotify"
+)
+
+const DefaultWatchDebounce = 100 * time.Millisecond
+
+// Watcher reports file changes under Root. Include limits which files count
+// (everything when empty); Exclude and dotfiles are never watched. Patterns
+// use fsutil.Match. A burst of changes within Debounce is reported once.
+type Watcher struct {
+	Root     string
+	Include  []string
+	Exclude  []string
+	Debounce time.Duration
+}
+
+// Run calls changed after each burst of changes until ctx is cancelled.
+func (w Watcher) Run(ctx context.Context, changed func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	if err := w.addTree(watcher, w.Root); err != nil {
+		return err
+	}
+	debounce := w.Debounce
+	if debounce <= 0 {
+		debounce = DefaultWatchDebounce
+	}
+
+	var pending <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op == fsnotify.Chmod || w.ignored(event.Name) {
+				continue
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					_ = w.addTree(watcher, event.Name)
+				}
+			}
+			if !w.included(event.Name) {
+				continue
+			}
+			pending = time.After(debounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			// Events were dropped, so assume something changed.
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				pending = time.After(debounce)
+				continue
+			}
+			return err
+		case <-pending:
+			pending = nil
+			changed()
+		}
+	}
+}
+
+func (w Watcher) addTree(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != w.Root && w.ignored(path) {
+			return fs.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+func (w Watcher) ignored(path string) bool {
+	rel, err := filepath.Rel(w.Root, path)
+	if err != nil {
+		return true
+	}
+	for _, part := range strings.Split(rel, string(os.PathSeparator)) {
+		if strings.HasPrefix(part, ".") && part != "." {
+			return true
+		}
+	}
+	return fsutil.Match(rel, w.Exclude)
+}
+
+func (w Watcher) included(path string) bool {
+	if len(w.Include) == 0 {
+		return true
+	}
+	rel, err := filepath.Rel(w.Root, path)
+	return err == nil && fsutil.Match(rel, w.Include)
+}
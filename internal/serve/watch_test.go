@@ -0,0 +1,98 @@
+package serve
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatcherReportsIncludedChanges(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"app", "venv", ".git"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan struct{}, 8)
+	watcher := Watcher{Root: root, Include: []string{"*.py"}, Exclude: []string{"venv"}, Debounce: 20 * time.Millisecond}
+	done := make(chan error, 1)
+	go func() { done <- watcher.Run(ctx, func() { changes <- struct{}{} }) }()
+	time.Sleep(50 * time.Millisecond)
+
+	for _, name := range []string{"app/readme.md", "venv/site.py", ".git/hook.py"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("x"), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	select {
+	case <-changes:
+		t.Fatalf("expected unmatched and excluded files to be ignored")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := os.WriteFile(filepath.Join(root, "app", "views.py"), []byte("x"), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	select {
+	case <-changes:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for change")
+	}
+	select {
+	case <-changes:
+		t.Fatalf("expected one change for a burst of saves")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("run: %v", err)
+	}
+}
+
+func TestWatcherMatchesDirectoryGlobs(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"src/pkg", "lib"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan struct{}, 8)
+	watcher := Watcher{Root: root, Include: []string{"src/**/*.py"}, Debounce: 20 * time.Millisecond}
+	done := make(chan error, 1)
+	go func() { done <- watcher.Run(ctx, func() { changes <- struct{}{} }) }()
+	time.Sleep(50 * time.Millisecond)
+
+	if err := os.WriteFile(filepath.Join(root, "lib", "util.py"), []byte("x"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	select {
+	case <-changes:
+		t.Fatalf("expected files outside src to be ignored")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "src", "pkg", "views.py"), []byte("x"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	select {
+	case <-changes:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for change")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("run: %v", err)
+	}
+}